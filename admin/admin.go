@@ -0,0 +1,242 @@
+// Package admin provides a mountable web UI for browsing and curating pods
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Backend is the subset of the Mantr API the UI needs. *mantr.Client
+// implements it.
+type Backend interface {
+	ListPods(ctx context.Context) ([]mantr.Pod, error)
+	PodStats(ctx context.Context, pod string) (*mantr.PodStats, error)
+	SearchNodes(ctx context.Context, pod, query string, limit int) ([]mantr.Node, error)
+	Ingest(ctx context.Context, pod string, req *mantr.IngestRequest) (*mantr.IngestResponse, error)
+	WalkContext(ctx context.Context, req *mantr.WalkRequest) (*mantr.WalkResponse, error)
+}
+
+// Action is the kind of access a request needs
+type Action string
+
+// Actions checked by Options.Authorize
+const (
+	ActionRead  Action = "read"
+	ActionWrite Action = "write"
+)
+
+// Options configures the handler
+type Options struct {
+	// ReadOnly disables node and edge edits
+	ReadOnly bool
+	// Authenticate identifies the user of a request. Returning an error
+	// rejects the request with 401. When nil every request is allowed as
+	// an anonymous user.
+	Authenticate func(r *http.Request) (user string, err error)
+	// Authorize decides whether user may perform action on pod. When nil
+	// every authenticated user may do everything ReadOnly allows.
+	Authorize func(user string, action Action, pod string) bool
+}
+
+// Handler serves the pod administration UI and its JSON API. Mount it under
+// a prefix with http.StripPrefix.
+type Handler struct {
+	backend Backend
+	opts    Options
+	static  http.Handler
+}
+
+// NewHandler creates an admin UI handler
+func NewHandler(backend Backend, opts Options) *Handler {
+	sub, _ := fs.Sub(assets, "assets")
+	return &Handler{
+		backend: backend,
+		opts:    opts,
+		static:  http.FileServer(http.FS(sub)),
+	}
+}
+
+// BasicAuth returns an Authenticate hook that checks HTTP basic credentials
+// against users, a map from user name to password
+func BasicAuth(users map[string]string) func(*http.Request) (string, error) {
+	return func(r *http.Request) (string, error) {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			return "", errors.New("missing credentials")
+		}
+		want, found := users[user]
+		if !found || subtle.ConstantTimeCompare([]byte(want), []byte(pass)) != 1 {
+			return "", errors.New("invalid credentials")
+		}
+		return user, nil
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user := ""
+	if h.opts.Authenticate != nil {
+		var err error
+		if user, err = h.opts.Authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mantr admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.static.ServeHTTP(w, r)
+		return
+	}
+
+	h.serveAPI(w, r, user)
+}
+
+// serveAPI routes /api/config, /api/pods and /api/pods/{pod}/{stats,nodes,ingest,walk}
+func (h *Handler) serveAPI(w http.ResponseWriter, r *http.Request, user string) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "config" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]interface{}{"read_only": h.opts.ReadOnly, "user": user})
+
+	case len(parts) == 1 && parts[0] == "pods" && r.Method == http.MethodGet:
+		pods, err := h.backend.ListPods(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		visible := make([]mantr.Pod, 0, len(pods))
+		for _, p := range pods {
+			if h.allowed(user, ActionRead, p.Name) {
+				visible = append(visible, p)
+			}
+		}
+		writeJSON(w, map[string]interface{}{"pods": visible})
+
+	case len(parts) == 3 && parts[0] == "pods":
+		h.servePod(w, r, user, parts[1], parts[2])
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) servePod(w http.ResponseWriter, r *http.Request, user, pod, op string) {
+	action := ActionRead
+	if op == "ingest" {
+		action = ActionWrite
+	}
+	if !h.allowed(user, action, pod) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case op == "stats" && r.Method == http.MethodGet:
+		stats, err := h.backend.PodStats(r.Context(), pod)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, stats)
+
+	case op == "nodes" && r.Method == http.MethodGet:
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		nodes, err := h.backend.SearchNodes(r.Context(), pod, r.URL.Query().Get("q"), limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"nodes": nodes})
+
+	case op == "ingest" && r.Method == http.MethodPost:
+		var req mantr.IngestRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		resp, err := h.backend.Ingest(r.Context(), pod, &req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, resp)
+
+	case op == "walk" && r.Method == http.MethodPost:
+		var req mantr.WalkRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		req.Pod = pod
+		resp, err := h.backend.WalkContext(r.Context(), &req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, resp)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) allowed(user string, action Action, pod string) bool {
+	if action == ActionWrite && h.opts.ReadOnly {
+		return false
+	}
+	if h.opts.Authorize == nil {
+		return true
+	}
+	return h.opts.Authorize(user, action, pod)
+}
+
+// readJSON decodes a JSON request body. Only application/json bodies are
+// accepted so that cross-site form posts cannot trigger edits.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+		http.Error(w, "expected application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(v); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, mantr.ErrRateLimit):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, mantr.ErrInsufficientCredits):
+		status = http.StatusPaymentRequired
+	case errors.Is(err, mantr.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, mantr.ErrConflict):
+		status = http.StatusConflict
+	}
+	http.Error(w, err.Error(), status)
+}
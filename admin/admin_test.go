@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// fakeBackend serves two pods and records ingestion calls
+type fakeBackend struct {
+	err     error
+	ingests int
+}
+
+func (b *fakeBackend) ListPods(ctx context.Context) ([]mantr.Pod, error) {
+	return []mantr.Pod{{Name: "dharma"}, {Name: "private"}}, b.err
+}
+
+func (b *fakeBackend) PodStats(ctx context.Context, pod string) (*mantr.PodStats, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &mantr.PodStats{Pod: mantr.Pod{Name: pod}}, nil
+}
+
+func (b *fakeBackend) SearchNodes(ctx context.Context, pod, query string, limit int) ([]mantr.Node, error) {
+	return []mantr.Node{{ID: "karma"}}, b.err
+}
+
+func (b *fakeBackend) Ingest(ctx context.Context, pod string, req *mantr.IngestRequest) (*mantr.IngestResponse, error) {
+	b.ingests++
+	return &mantr.IngestResponse{NodesUpserted: len(req.Nodes)}, b.err
+}
+
+func (b *fakeBackend) WalkContext(ctx context.Context, req *mantr.WalkRequest) (*mantr.WalkResponse, error) {
+	return &mantr.WalkResponse{}, b.err
+}
+
+func TestHandlerAccess(t *testing.T) {
+	users := BasicAuth(map[string]string{"ana": "secret", "ravi": "pw"})
+	// ravi may only read the dharma pod
+	authorize := func(user string, action Action, pod string) bool {
+		return user == "ana" || (action == ActionRead && pod == "dharma")
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		method  string
+		path    string
+		user    string
+		pass    string
+		status  int
+		ingests int
+	}{
+		{"anonymous write", Options{}, "POST", "/api/pods/dharma/ingest", "", "", http.StatusOK, 1},
+		{"read-only write", Options{ReadOnly: true}, "POST", "/api/pods/dharma/ingest", "", "", http.StatusForbidden, 0},
+		{"read-only read", Options{ReadOnly: true}, "GET", "/api/pods/dharma/stats", "", "", http.StatusOK, 0},
+		{"missing credentials", Options{Authenticate: users}, "POST", "/api/pods/dharma/ingest", "", "", http.StatusUnauthorized, 0},
+		{"wrong password", Options{Authenticate: users}, "POST", "/api/pods/dharma/ingest", "ana", "guess", http.StatusUnauthorized, 0},
+		{"unauthenticated UI", Options{Authenticate: users}, "GET", "/", "", "", http.StatusUnauthorized, 0},
+		{"authenticated write", Options{Authenticate: users}, "POST", "/api/pods/dharma/ingest", "ana", "secret", http.StatusOK, 1},
+		{"authenticated write when read-only", Options{Authenticate: users, ReadOnly: true}, "POST", "/api/pods/dharma/ingest", "ana", "secret", http.StatusForbidden, 0},
+		{"write denied by authorize", Options{Authenticate: users, Authorize: authorize}, "POST", "/api/pods/dharma/ingest", "ravi", "pw", http.StatusForbidden, 0},
+		{"read allowed by authorize", Options{Authenticate: users, Authorize: authorize}, "GET", "/api/pods/dharma/stats", "ravi", "pw", http.StatusOK, 0},
+		{"read denied by authorize", Options{Authenticate: users, Authorize: authorize}, "GET", "/api/pods/private/nodes", "ravi", "pw", http.StatusForbidden, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{}
+			h := NewHandler(backend, tt.opts)
+
+			body := strings.NewReader("")
+			if tt.method == "POST" {
+				body = strings.NewReader(`{"nodes": [{"id": "ahimsa"}]}`)
+			}
+			r := httptest.NewRequest(tt.method, tt.path, body)
+			r.Header.Set("Content-Type", "application/json")
+			if tt.user != "" {
+				r.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if backend.ingests != tt.ingests {
+				t.Errorf("%d ingestion calls, want %d", backend.ingests, tt.ingests)
+			}
+		})
+	}
+}
+
+func TestHandlerListsVisiblePods(t *testing.T) {
+	h := NewHandler(&fakeBackend{}, Options{
+		Authenticate: func(r *http.Request) (string, error) { return "ravi", nil },
+		Authorize:    func(user string, action Action, pod string) bool { return pod == "dharma" },
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/pods", nil))
+
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "private") || !strings.Contains(w.Body.String(), "dharma") {
+		t.Errorf("status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestHandlerRejectsFormPosts(t *testing.T) {
+	backend := &fakeBackend{}
+	h := NewHandler(backend, Options{})
+	r := httptest.NewRequest("POST", "/api/pods/dharma/ingest", strings.NewReader("nodes=ahimsa"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnsupportedMediaType || backend.ingests != 0 {
+		t.Errorf("status = %d with %d ingestion calls", w.Code, backend.ingests)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{mantr.ErrNotFound, http.StatusNotFound},
+		{mantr.ErrConflict, http.StatusConflict},
+		{mantr.ErrRateLimit, http.StatusTooManyRequests},
+		{mantr.ErrInsufficientCredits, http.StatusPaymentRequired},
+		{errors.New("connection refused"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			h := NewHandler(&fakeBackend{err: tt.err}, Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/api/pods/missing/stats", nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
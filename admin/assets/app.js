@@ -0,0 +1,165 @@
+"use strict";
+
+const $ = (sel) => document.querySelector(sel);
+let current = null;
+
+async function api(path, body) {
+  const opts = body === undefined ? {} : {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  };
+  const resp = await fetch("api/" + path, opts);
+  if (!resp.ok) {
+    throw new Error((await resp.text()) || resp.statusText);
+  }
+  return resp.json();
+}
+
+function status(msg) {
+  $("#status").textContent = msg;
+}
+
+function podPath(op) {
+  return "pods/" + encodeURIComponent(current) + "/" + op;
+}
+
+async function loadConfig() {
+  const cfg = await api("config");
+  $("#user").textContent = cfg.user || "";
+  $("#mode").textContent = cfg.read_only ? "read-only" : "";
+  document.body.classList.toggle("read-only", cfg.read_only);
+}
+
+async function loadPods() {
+  const { pods } = await api("pods");
+  const list = $("#pods");
+  list.replaceChildren();
+  for (const pod of pods || []) {
+    const li = document.createElement("li");
+    li.textContent = pod.name;
+    li.onclick = () => selectPod(pod.name, li);
+    list.append(li);
+  }
+}
+
+async function selectPod(name, li) {
+  current = name;
+  document.querySelectorAll("#pods li").forEach((el) => el.classList.remove("active"));
+  li.classList.add("active");
+  $("#pod").hidden = false;
+  $("#pod-name").textContent = name;
+  $("#nodes tbody").replaceChildren();
+  $("#paths").replaceChildren();
+
+  const stats = await api(podPath("stats"));
+  const dl = $("#stats");
+  dl.replaceChildren();
+  const rows = {
+    Nodes: stats.nodes,
+    Edges: stats.edges,
+    Version: stats.version,
+    "Average degree": (stats.avg_degree || 0).toFixed(2),
+    "Max degree": stats.max_degree,
+    "Edge types": Object.entries(stats.edge_types || {}).map(([t, n]) => t + " (" + n + ")").join(", "),
+    Updated: stats.updated_at,
+  };
+  for (const [k, v] of Object.entries(rows)) {
+    const dt = document.createElement("dt");
+    dt.textContent = k;
+    const dd = document.createElement("dd");
+    dd.textContent = v;
+    dl.append(dt, dd);
+  }
+}
+
+async function search(ev) {
+  ev.preventDefault();
+  const q = new FormData(ev.target).get("q");
+  const { nodes } = await api(podPath("nodes") + "?q=" + encodeURIComponent(q));
+  const body = $("#nodes tbody");
+  body.replaceChildren();
+  for (const node of nodes || []) {
+    const tr = document.createElement("tr");
+    for (const v of [node.id, node.label || "", (node.aliases || []).join(", ")]) {
+      const td = document.createElement("td");
+      td.textContent = v;
+      tr.append(td);
+    }
+    const td = document.createElement("td");
+    const btn = document.createElement("button");
+    btn.textContent = "Edit";
+    btn.onclick = () => {
+      const f = $("#node-form");
+      f.id.value = node.id;
+      f.label.value = node.label || "";
+      f.aliases.value = (node.aliases || []).join(", ");
+    };
+    td.append(btn);
+    tr.append(td);
+    body.append(tr);
+  }
+}
+
+function ingestResult(resp) {
+  status("upserted " + resp.nodes_upserted + " nodes, " + resp.edges_upserted + " edges; deleted " +
+    resp.nodes_deleted + " nodes, " + resp.edges_deleted + " edges (version " + resp.version + ")");
+}
+
+async function editNode(ev) {
+  ev.preventDefault();
+  const f = new FormData(ev.target);
+  const id = f.get("id");
+  let req;
+  if (ev.submitter.value === "delete") {
+    if (!confirm("Delete node " + id + " and its edges?")) return;
+    req = { delete_nodes: [id] };
+  } else {
+    const aliases = f.get("aliases").split(",").map((a) => a.trim()).filter(Boolean);
+    req = { nodes: [{ id, label: f.get("label") || undefined, aliases }] };
+  }
+  ingestResult(await api(podPath("ingest"), req));
+}
+
+async function editEdge(ev) {
+  ev.preventDefault();
+  const f = new FormData(ev.target);
+  const edge = { from: f.get("from"), to: f.get("to"), type: f.get("type") || undefined };
+  let req;
+  if (ev.submitter.value === "delete") {
+    if (!confirm("Delete edge " + edge.from + " -> " + edge.to + "?")) return;
+    req = { delete_edges: [edge] };
+  } else {
+    req = { edges: [{ ...edge, weight: parseFloat(f.get("weight")) || 0 }] };
+  }
+  ingestResult(await api(podPath("ingest"), req));
+}
+
+async function walk(ev) {
+  ev.preventDefault();
+  const f = new FormData(ev.target);
+  const resp = await api(podPath("walk"), {
+    phonemes: f.get("phonemes").split(/\s+/).filter(Boolean),
+    depth: parseInt(f.get("depth"), 10),
+    limit: parseInt(f.get("limit"), 10),
+  });
+  const list = $("#paths");
+  list.replaceChildren();
+  for (const path of resp.paths || []) {
+    const li = document.createElement("li");
+    li.textContent = path.nodes.join(" → ") + " (" + path.score.toFixed(3) + ")";
+    list.append(li);
+  }
+  status((resp.paths || []).length + " paths, " + resp.credits_used + " credits");
+}
+
+function guard(fn) {
+  return (ev) => fn(ev).catch((err) => status("Error: " + err.message));
+}
+
+$("#search").onsubmit = guard(search);
+$("#node-form").onsubmit = guard(editNode);
+$("#edge-form").onsubmit = guard(editEdge);
+$("#walk").onsubmit = guard(walk);
+
+Promise.all([loadConfig(), loadPods()]).catch((err) => status("Error: " + err.message));
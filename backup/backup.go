@@ -0,0 +1,420 @@
+// Package backup exports pod snapshots on a schedule and restores them
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+var (
+	// ErrChecksum indicates a backup file does not match its recorded checksum
+	ErrChecksum = errors.New("backup: checksum mismatch")
+
+	// ErrVerify indicates a restored pod does not match the backup
+	ErrVerify = errors.New("backup: restored pod does not match backup")
+)
+
+const (
+	manifestExt = ".manifest.json"
+	stampFormat = "20060102T150405.000Z"
+)
+
+// Options configures a Manager
+type Options struct {
+	// Dir is the directory backups are written to, one subdirectory per pod
+	Dir string
+	// Pods lists the pods Run backs up
+	Pods []string
+	// Interval is how often Run takes backups (default 24h)
+	Interval time.Duration
+	// Retention is how many backups are kept per pod (default 7)
+	Retention int
+	// Key, when set, encrypts backups with AES-GCM. It must be 16, 24 or 32
+	// bytes long.
+	Key []byte
+	// OnError is called by Run when a scheduled backup fails
+	OnError func(pod string, err error)
+}
+
+// Backup describes a stored backup. It is saved as a manifest next to the
+// snapshot data.
+type Backup struct {
+	ID        string    `json:"id"`
+	Pod       mantr.Pod `json:"pod"`
+	Time      time.Time `json:"time"`
+	File      string    `json:"file"`
+	Checksum  string    `json:"checksum"`
+	Digest    string    `json:"digest"`
+	Encrypted bool      `json:"encrypted"`
+	Nodes     int       `json:"nodes"`
+	Edges     int       `json:"edges"`
+}
+
+// Manager takes and restores pod backups
+type Manager struct {
+	client *mantr.Client
+	opts   Options
+	aead   cipher.AEAD
+}
+
+// NewManager creates a backup manager
+func NewManager(client *mantr.Client, opts Options) (*Manager, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("backup directory cannot be empty")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7
+	}
+
+	m := &Manager{client: client, opts: opts}
+	if len(opts.Key) > 0 {
+		block, err := aes.NewCipher(opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		if m.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Run backs up every configured pod immediately and then every Interval
+// until ctx is cancelled
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, pod := range m.opts.Pods {
+			if _, err := m.Backup(ctx, pod); err != nil && m.opts.OnError != nil {
+				m.opts.OnError(pod, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Backup exports a pod, stores it and prunes backups beyond the retention
+func (m *Manager) Backup(ctx context.Context, pod string) (*Backup, error) {
+	meta, err := m.client.GetPod(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := m.client.ExportSnapshot(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Version == 0 {
+		snap.Version = meta.Version
+	}
+
+	now := time.Now().UTC()
+	id, err := newID(now)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backup{
+		ID:        id,
+		Pod:       *meta,
+		Time:      now,
+		Digest:    snap.Digest(),
+		Encrypted: m.aead != nil,
+		Nodes:     len(snap.Nodes),
+		Edges:     len(snap.Edges),
+	}
+	b.File = b.ID + ".snapshot.json.gz"
+	if b.Encrypted {
+		b.File += ".enc"
+	}
+
+	data, err := m.encode(snap)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	b.Checksum = hex.EncodeToString(sum[:])
+
+	dir := m.podDir(pod)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, b.File), data); err != nil {
+		return nil, err
+	}
+	manifest, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, b.ID+manifestExt), manifest); err != nil {
+		return nil, err
+	}
+
+	return b, m.prune(pod)
+}
+
+// List returns the backups of a pod, newest first
+func (m *Manager) List(pod string) ([]Backup, error) {
+	dir := m.podDir(pod)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), manifestExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var b Backup
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: %w", e.Name(), err)
+		}
+		backups = append(backups, b)
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Load reads a backup's snapshot, checking its checksum and graph digest
+func (m *Manager) Load(b *Backup) (*mantr.Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(m.podDir(b.Pod.Name), b.File))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != b.Checksum {
+		return nil, fmt.Errorf("%w: %s", ErrChecksum, b.File)
+	}
+
+	snap, err := m.decode(data, b.Encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Digest() != b.Digest {
+		return nil, fmt.Errorf("%w: %s graph digest", ErrChecksum, b.File)
+	}
+	return snap, nil
+}
+
+// RestoreMode selects how Restore writes into the target pod
+type RestoreMode int
+
+const (
+	// RestoreNew creates the target pod; it fails if the pod exists
+	RestoreNew RestoreMode = iota
+	// RestoreReplace makes an existing pod identical to the backup,
+	// deleting nodes and edges the backup does not contain
+	RestoreReplace
+)
+
+// RestoreOptions configures Restore
+type RestoreOptions struct {
+	// Target is the pod to restore into (default the backed-up pod)
+	Target string
+	Mode   RestoreMode
+	// SkipVerify skips comparing the restored pod with the backup
+	SkipVerify bool
+	// BatchSize is the ingestion batch size
+	BatchSize int
+}
+
+// Restore replays a backup into a pod and, unless SkipVerify is set,
+// re-exports the pod to check that its graph matches the backup
+func (m *Manager) Restore(ctx context.Context, b *Backup, opts RestoreOptions) error {
+	snap, err := m.Load(b)
+	if err != nil {
+		return err
+	}
+	target := opts.Target
+	if target == "" {
+		target = b.Pod.Name
+	}
+
+	switch opts.Mode {
+	case RestoreNew:
+		pod := b.Pod
+		pod.Name = target
+		if _, err := m.client.CreatePod(ctx, &pod); err != nil {
+			return fmt.Errorf("failed to create pod %s: %w", target, err)
+		}
+	case RestoreReplace:
+		if err := m.prepareReplace(ctx, target, snap, opts.BatchSize); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown restore mode %d", opts.Mode)
+	}
+
+	if _, err := m.client.IngestSnapshot(ctx, target, snap, opts.BatchSize); err != nil {
+		return fmt.Errorf("failed to restore pod %s: %w", target, err)
+	}
+
+	if opts.SkipVerify {
+		return nil
+	}
+	restored, err := m.client.ExportSnapshot(ctx, target)
+	if err != nil {
+		return fmt.Errorf("failed to verify pod %s: %w", target, err)
+	}
+	if restored.Digest() != b.Digest {
+		return fmt.Errorf("%w: %s has %d nodes and %d edges, backup has %d and %d",
+			ErrVerify, target, len(restored.Nodes), len(restored.Edges), b.Nodes, b.Edges)
+	}
+	return nil
+}
+
+// prepareReplace deletes the edges and nodes of target that are not in snap
+func (m *Manager) prepareReplace(ctx context.Context, target string, snap *mantr.Snapshot, batchSize int) error {
+	live, err := m.client.ExportSnapshot(ctx, target)
+	if err != nil {
+		return err
+	}
+	keepNodes := make(map[string]bool, len(snap.Nodes))
+	for _, n := range snap.Nodes {
+		keepNodes[n.ID] = true
+	}
+	keepEdges := make(map[mantr.EdgeKey]bool, len(snap.Edges))
+	for _, e := range snap.Edges {
+		keepEdges[e.Key()] = true
+	}
+
+	var edges []mantr.EdgeKey
+	for _, e := range live.Edges {
+		if !keepEdges[e.Key()] {
+			edges = append(edges, e.Key())
+		}
+	}
+	var nodes []string
+	for _, n := range live.Nodes {
+		if !keepNodes[n.ID] {
+			nodes = append(nodes, n.ID)
+		}
+	}
+
+	req := &mantr.IngestRequest{DeleteEdges: edges, DeleteNodes: nodes}
+	if _, err := m.client.IngestBatches(ctx, target, req, batchSize); err != nil {
+		return fmt.Errorf("failed to remove extra nodes and edges: %w", err)
+	}
+	return nil
+}
+
+// prune removes the oldest backups beyond the retention
+func (m *Manager) prune(pod string) error {
+	backups, err := m.List(pod)
+	if err != nil {
+		return err
+	}
+
+	dir := m.podDir(pod)
+	for i := m.opts.Retention; i < len(backups); i++ {
+		b := backups[i]
+		if err := os.Remove(filepath.Join(dir, b.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Remove(filepath.Join(dir, b.ID+manifestExt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newID names a backup after its time with a random suffix, so backups
+// taken within the same millisecond do not overwrite each other
+func newID(t time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return t.Format(stampFormat) + "-" + hex.EncodeToString(suffix), nil
+}
+
+func (m *Manager) podDir(pod string) string {
+	return filepath.Join(m.opts.Dir, url.PathEscape(pod))
+}
+
+// encode gzips a snapshot and encrypts it when a key is configured. The
+// nonce is prepended to the ciphertext.
+func (m *Manager) encode(snap *mantr.Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(snap); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	if m.aead == nil {
+		return buf.Bytes(), nil
+	}
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return m.aead.Seal(nonce, nonce, buf.Bytes(), nil), nil
+}
+
+func (m *Manager) decode(data []byte, encrypted bool) (*mantr.Snapshot, error) {
+	if encrypted {
+		if m.aead == nil {
+			return nil, fmt.Errorf("backup is encrypted but no key is configured")
+		}
+		n := m.aead.NonceSize()
+		if len(data) < n {
+			return nil, fmt.Errorf("backup is too short")
+		}
+		var err error
+		if data, err = m.aead.Open(nil, data[:n], data[n:], nil); err != nil {
+			return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+		}
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return mantr.ReadSnapshot(zr)
+}
+
+// writeFile writes data to a temporary file and renames it into place
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,305 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// podStore fakes the pod, snapshot and ingestion endpoints over in-memory
+// graphs
+type podStore struct {
+	mu   sync.Mutex
+	pods map[string]*mantr.Snapshot
+	// dropEdges makes ingestion ignore edges, so restores fail verification
+	dropEdges bool
+}
+
+func (s *podStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/pods")
+	name, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	switch {
+	case r.Method == "POST" && path == "":
+		var pod mantr.Pod
+		json.NewDecoder(r.Body).Decode(&pod)
+		if s.pods[pod.Name] != nil {
+			http.Error(w, "pod exists", http.StatusConflict)
+			return
+		}
+		s.pods[pod.Name] = &mantr.Snapshot{Pod: pod.Name, Nodes: []mantr.Node{}, Edges: []mantr.Edge{}}
+		json.NewEncoder(w).Encode(pod)
+		return
+	case s.pods[name] == nil:
+		http.NotFound(w, r)
+		return
+	}
+
+	snap := s.pods[name]
+	switch action {
+	case "":
+		json.NewEncoder(w).Encode(mantr.Pod{Name: name, Description: "Indian philosophy", Version: snap.Version})
+	case "snapshot":
+		json.NewEncoder(w).Encode(snap)
+	case "ingest":
+		var req mantr.IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.ingest(snap, &req)
+		json.NewEncoder(w).Encode(mantr.IngestResponse{Version: snap.Version})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *podStore) ingest(snap *mantr.Snapshot, req *mantr.IngestRequest) {
+	snap.Version++
+	for _, n := range req.Nodes {
+		snap.Nodes = append(removeNode(snap.Nodes, n.ID), n)
+	}
+	for _, id := range req.DeleteNodes {
+		snap.Nodes = removeNode(snap.Nodes, id)
+	}
+	if s.dropEdges {
+		return
+	}
+	for _, e := range req.Edges {
+		snap.Edges = append(removeEdge(snap.Edges, e.Key()), e)
+	}
+	for _, k := range req.DeleteEdges {
+		snap.Edges = removeEdge(snap.Edges, k)
+	}
+}
+
+func removeNode(nodes []mantr.Node, id string) []mantr.Node {
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n.ID != id {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func removeEdge(edges []mantr.Edge, key mantr.EdgeKey) []mantr.Edge {
+	out := edges[:0]
+	for _, e := range edges {
+		if e.Key() != key {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func dharma() *mantr.Snapshot {
+	return &mantr.Snapshot{
+		Pod:     "dharma",
+		Version: 3,
+		Nodes:   []mantr.Node{{ID: "karma", Label: "Karma"}, {ID: "samsara"}, {ID: "moksa"}},
+		Edges: []mantr.Edge{
+			{From: "karma", To: "samsara", Weight: 0.9, Type: "binds"},
+			{From: "moksa", To: "samsara", Weight: 0.4},
+		},
+	}
+}
+
+func newTestManager(t *testing.T, opts Options) (*Manager, *podStore) {
+	t.Helper()
+	store := &podStore{pods: map[string]*mantr.Snapshot{"dharma": dharma()}}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+
+	client, err := mantr.NewClient("vak_test", mantr.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+	m, err := NewManager(client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, store
+}
+
+func TestBackupLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{"plain", nil},
+		{"encrypted", []byte("0123456789abcdef")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, Options{Key: tt.key})
+			b, err := m.Backup(context.Background(), "dharma")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Encrypted != (tt.key != nil) || b.Nodes != 3 || b.Edges != 2 || b.Pod.Description != "Indian philosophy" {
+				t.Errorf("backup = %+v", b)
+			}
+
+			backups, err := m.List("dharma")
+			if err != nil || len(backups) != 1 || backups[0].ID != b.ID || backups[0].Checksum != b.Checksum {
+				t.Fatalf("List = %+v, %v", backups, err)
+			}
+			snap, err := m.Load(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snap.Digest() != dharma().Digest() || snap.Version != 3 {
+				t.Errorf("loaded %+v", snap)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := newTestManager(t, Options{Dir: dir, Key: []byte("0123456789abcdef")})
+	b, err := m.Backup(context.Background(), "dharma")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := newTestManager(t, Options{Dir: dir, Key: []byte("fedcba9876543210")})
+	if _, err := other.Load(b); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("Load with the wrong key = %v", err)
+	}
+	plain, _ := newTestManager(t, Options{Dir: dir})
+	if _, err := plain.Load(b); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("Load without a key = %v", err)
+	}
+}
+
+func TestLoadChecksum(t *testing.T) {
+	m, _ := newTestManager(t, Options{})
+	b, err := m.Backup(context.Background(), "dharma")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(m.podDir("dharma"), b.File)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Load(b); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Load = %v, want ErrChecksum", err)
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	m, _ := newTestManager(t, Options{Retention: 2})
+	ids := make(map[string]bool)
+	var latest []*Backup
+	for i := 0; i < 4; i++ {
+		b, err := m.Backup(context.Background(), "dharma")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[b.ID] = true
+		latest = append(latest, b)
+	}
+	if len(ids) != 4 {
+		t.Fatalf("backups share IDs: %v", ids)
+	}
+
+	backups, err := m.List("dharma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[0].ID != latest[3].ID || backups[1].ID != latest[2].ID {
+		t.Errorf("kept %+v", backups)
+	}
+	entries, err := os.ReadDir(m.podDir("dharma"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("%d files left, want 2 manifests and 2 snapshots", len(entries))
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RestoreOptions
+		setup   func(s *podStore)
+		target  string
+		wantErr string
+	}{
+		{
+			name:   "new pod",
+			opts:   RestoreOptions{Target: "dharma_restored"},
+			target: "dharma_restored",
+		},
+		{
+			name:    "existing pod",
+			opts:    RestoreOptions{},
+			target:  "dharma",
+			wantErr: "failed to create pod dharma",
+		},
+		{
+			name: "replace",
+			opts: RestoreOptions{Mode: RestoreReplace, BatchSize: 1},
+			setup: func(s *podStore) {
+				snap := s.pods["dharma"]
+				snap.Nodes = append(snap.Nodes, mantr.Node{ID: "ahimsa"})
+				snap.Edges = append(snap.Edges[:1], mantr.Edge{From: "ahimsa", To: "karma", Weight: 1})
+				snap.Edges[0].Weight = 0.1
+			},
+			target: "dharma",
+		},
+		{
+			name:    "failed verification",
+			opts:    RestoreOptions{Target: "dharma_restored"},
+			setup:   func(s *podStore) { s.dropEdges = true },
+			target:  "dharma_restored",
+			wantErr: ErrVerify.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, store := newTestManager(t, Options{})
+			b, err := m.Backup(context.Background(), "dharma")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			err = m.Restore(context.Background(), b, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Restore = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if store.pods[tt.target].Digest() != b.Digest {
+				t.Errorf("restored %+v", store.pods[tt.target])
+			}
+		})
+	}
+}
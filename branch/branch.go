@@ -0,0 +1,369 @@
+// Package branch clones pods into experimental branches, compares walks
+// between a branch and its parent, and merges branches back
+package branch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// ErrConflict indicates nodes or edges were changed both on the branch and on
+// its parent since the branch was created
+var ErrConflict = errors.New("branch: merge conflict")
+
+// Branch is a clone of a parent pod. Base is the parent's graph at the time
+// of the clone and is needed for three-way merges, so branches are saved
+// locally with Save.
+type Branch struct {
+	Name        string          `json:"name"`
+	Parent      string          `json:"parent"`
+	BaseVersion int64           `json:"base_version"`
+	Created     time.Time       `json:"created"`
+	Base        *mantr.Snapshot `json:"base"`
+}
+
+// Create clones parent into a new pod called name. The base graph is
+// exported from the clone rather than the parent, so writes to the parent
+// during the clone cannot leak into the base.
+func Create(ctx context.Context, client *mantr.Client, parent, name string) (*Branch, error) {
+	clone, err := client.ClonePod(ctx, parent, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", parent, err)
+	}
+	base, err := client.ExportSnapshot(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	base.Pod = parent
+
+	return &Branch{
+		Name:        name,
+		Parent:      parent,
+		BaseVersion: clone.Version,
+		Created:     time.Now().UTC(),
+		Base:        base,
+	}, nil
+}
+
+// Load reads a branch saved with Save
+func Load(path string) (*Branch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Branch
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode branch %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the branch, including its base graph, to a file
+func (b *Branch) Save(path string) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Ingest applies changes to the branch pod
+func (b *Branch) Ingest(ctx context.Context, client *mantr.Client, req *mantr.IngestRequest) (*mantr.IngestResponse, error) {
+	return client.Ingest(ctx, b.Name, req)
+}
+
+// QueryDiff compares the walk results of one query on the parent and the branch
+type QueryDiff struct {
+	Phonemes []string `json:"phonemes"`
+	// Overlap is the Jaccard similarity of the two path sets
+	Overlap     float64  `json:"overlap"`
+	ParentPaths int      `json:"parent_paths"`
+	BranchPaths int      `json:"branch_paths"`
+	ParentTop   float64  `json:"parent_top_score"`
+	BranchTop   float64  `json:"branch_top_score"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+}
+
+// Comparison summarizes walk differences over a query set
+type Comparison struct {
+	Queries     []QueryDiff `json:"queries"`
+	MeanOverlap float64     `json:"mean_overlap"`
+	CreditsUsed int         `json:"credits_used"`
+}
+
+// Compare walks every query on the parent and the branch. Pod is overridden
+// on each request; other fields such as Depth and Limit are used as given.
+func (b *Branch) Compare(ctx context.Context, client *mantr.Client, queries []mantr.WalkRequest) (*Comparison, error) {
+	cmp := &Comparison{}
+	for _, q := range queries {
+		parentReq, branchReq := q, q
+		parentReq.Pod, branchReq.Pod = b.Parent, b.Name
+
+		parent, err := client.WalkContext(ctx, &parentReq)
+		if err != nil {
+			return cmp, fmt.Errorf("walk %v on %s: %w", q.Phonemes, b.Parent, err)
+		}
+		branch, err := client.WalkContext(ctx, &branchReq)
+		if err != nil {
+			return cmp, fmt.Errorf("walk %v on %s: %w", q.Phonemes, b.Name, err)
+		}
+		cmp.CreditsUsed += parent.CreditsUsed + branch.CreditsUsed
+
+		d := diffPaths(parent.Paths, branch.Paths)
+		d.Phonemes = q.Phonemes
+		cmp.Queries = append(cmp.Queries, d)
+		cmp.MeanOverlap += d.Overlap
+	}
+	if len(cmp.Queries) > 0 {
+		cmp.MeanOverlap /= float64(len(cmp.Queries))
+	}
+	return cmp, nil
+}
+
+func diffPaths(parent, branch []mantr.PathResult) QueryDiff {
+	d := QueryDiff{ParentPaths: len(parent), BranchPaths: len(branch)}
+	if len(parent) > 0 {
+		d.ParentTop = parent[0].Score
+	}
+	if len(branch) > 0 {
+		d.BranchTop = branch[0].Score
+	}
+
+	inParent := make(map[string]bool, len(parent))
+	for _, p := range parent {
+		inParent[pathKey(p)] = true
+	}
+	inBranch := make(map[string]bool, len(branch))
+	for _, p := range branch {
+		k := pathKey(p)
+		inBranch[k] = true
+		if !inParent[k] {
+			d.Added = append(d.Added, k)
+		}
+	}
+	shared := 0
+	for _, p := range parent {
+		k := pathKey(p)
+		if inBranch[k] {
+			shared++
+		} else {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+
+	union := len(inParent) + len(inBranch) - shared
+	if union == 0 {
+		d.Overlap = 1
+	} else {
+		d.Overlap = float64(shared) / float64(union)
+	}
+	return d
+}
+
+func pathKey(p mantr.PathResult) string {
+	return strings.Join(p.Nodes, " -> ")
+}
+
+// Conflict is a node or edge changed differently on the branch and the parent
+type Conflict struct {
+	Node   string         `json:"node,omitempty"`
+	Edge   *mantr.EdgeKey `json:"edge,omitempty"`
+	Reason string         `json:"reason"`
+}
+
+// MergeResult describes a merge
+type MergeResult struct {
+	Changes   *mantr.IngestRequest `json:"changes"`
+	Conflicts []Conflict           `json:"conflicts,omitempty"`
+	Applied   bool                 `json:"applied"`
+}
+
+// MergeOptions configures Merge
+type MergeOptions struct {
+	// DryRun computes the merge without applying it
+	DryRun bool
+	// Force applies the branch's version of conflicting nodes and edges
+	Force bool
+	// BatchSize is the ingestion batch size
+	BatchSize int
+}
+
+// Merge applies the changes made on the branch since it was created to the
+// parent. Nodes and edges that were also changed on the parent in a
+// different way are conflicts; unless Force is set the merge is not applied
+// and ErrConflict is returned along with the result.
+func (b *Branch) Merge(ctx context.Context, client *mantr.Client, opts MergeOptions) (*MergeResult, error) {
+	if b.Base == nil {
+		return nil, fmt.Errorf("branch %s has no base snapshot", b.Name)
+	}
+
+	branchGraph, err := client.ExportSnapshot(ctx, b.Name)
+	if err != nil {
+		return nil, err
+	}
+	parentGraph, err := client.ExportSnapshot(ctx, b.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	ours := diff(b.Base, branchGraph)
+	theirs := diff(b.Base, parentGraph)
+	res := &MergeResult{Changes: ours.request(), Conflicts: conflicts(ours, theirs)}
+
+	if opts.DryRun {
+		return res, nil
+	}
+	if len(res.Conflicts) > 0 && !opts.Force {
+		return res, fmt.Errorf("%w: %d conflicts", ErrConflict, len(res.Conflicts))
+	}
+
+	if _, err := client.IngestBatches(ctx, b.Parent, res.Changes, opts.BatchSize); err != nil {
+		return res, err
+	}
+	res.Applied = true
+	return res, nil
+}
+
+// changes maps node IDs and edge keys to their new value, nil for deletions
+type changes struct {
+	nodes map[string]*mantr.Node
+	edges map[mantr.EdgeKey]*mantr.Edge
+}
+
+func diff(from, to *mantr.Snapshot) *changes {
+	c := &changes{nodes: make(map[string]*mantr.Node), edges: make(map[mantr.EdgeKey]*mantr.Edge)}
+
+	oldNodes := make(map[string]mantr.Node, len(from.Nodes))
+	for _, n := range from.Nodes {
+		oldNodes[n.ID] = n
+	}
+	seen := make(map[string]bool, len(to.Nodes))
+	for i, n := range to.Nodes {
+		seen[n.ID] = true
+		if old, ok := oldNodes[n.ID]; !ok || !reflect.DeepEqual(old, n) {
+			c.nodes[n.ID] = &to.Nodes[i]
+		}
+	}
+	for id := range oldNodes {
+		if !seen[id] {
+			c.nodes[id] = nil
+		}
+	}
+
+	oldEdges := make(map[mantr.EdgeKey]mantr.Edge, len(from.Edges))
+	for _, e := range from.Edges {
+		oldEdges[e.Key()] = e
+	}
+	seenEdges := make(map[mantr.EdgeKey]bool, len(to.Edges))
+	for i, e := range to.Edges {
+		seenEdges[e.Key()] = true
+		if old, ok := oldEdges[e.Key()]; !ok || !reflect.DeepEqual(old, e) {
+			c.edges[e.Key()] = &to.Edges[i]
+		}
+	}
+	for k := range oldEdges {
+		if !seenEdges[k] {
+			c.edges[k] = nil
+		}
+	}
+	return c
+}
+
+func conflicts(ours, theirs *changes) []Conflict {
+	var out []Conflict
+	for id, n := range ours.nodes {
+		if t, ok := theirs.nodes[id]; ok && !reflect.DeepEqual(n, t) {
+			out = append(out, Conflict{Node: id, Reason: "changed on both branch and parent"})
+		}
+	}
+	for k, e := range ours.edges {
+		key := k
+		if t, ok := theirs.edges[k]; ok && !reflect.DeepEqual(e, t) {
+			out = append(out, Conflict{Edge: &key, Reason: "changed on both branch and parent"})
+			continue
+		}
+		// an edge added on the branch must not point at a node the parent deleted
+		if e != nil {
+			if id := deletedEndpoint(k, theirs); id != "" {
+				out = append(out, Conflict{Edge: &key, Reason: "endpoint " + id + " deleted on parent"})
+			}
+		}
+	}
+	// nor may the branch delete a node the parent added or changed edges on
+	for k, t := range theirs.edges {
+		key := k
+		if _, ok := ours.edges[k]; ok || t == nil {
+			continue
+		}
+		if id := deletedEndpoint(k, ours); id != "" {
+			out = append(out, Conflict{Edge: &key, Reason: "endpoint " + id + " deleted on branch"})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return conflictKey(out[i]) < conflictKey(out[j])
+	})
+	return out
+}
+
+// deletedEndpoint returns the endpoint of k that c deletes, if any
+func deletedEndpoint(k mantr.EdgeKey, c *changes) string {
+	for _, id := range []string{k.From, k.To} {
+		if n, ok := c.nodes[id]; ok && n == nil {
+			return id
+		}
+	}
+	return ""
+}
+
+func conflictKey(c Conflict) string {
+	if c.Edge != nil {
+		return "e:" + c.Edge.From + "\x00" + c.Edge.To + "\x00" + c.Edge.Type
+	}
+	return "n:" + c.Node
+}
+
+// request converts changes into an ingestion request with a stable order
+func (c *changes) request() *mantr.IngestRequest {
+	req := &mantr.IngestRequest{}
+	for id, n := range c.nodes {
+		if n == nil {
+			req.DeleteNodes = append(req.DeleteNodes, id)
+		} else {
+			req.Nodes = append(req.Nodes, *n)
+		}
+	}
+	for k, e := range c.edges {
+		if e == nil {
+			req.DeleteEdges = append(req.DeleteEdges, k)
+		} else {
+			req.Edges = append(req.Edges, *e)
+		}
+	}
+
+	sort.Strings(req.DeleteNodes)
+	sort.Slice(req.Nodes, func(i, j int) bool { return req.Nodes[i].ID < req.Nodes[j].ID })
+	sort.Slice(req.Edges, func(i, j int) bool { return keyLess(req.Edges[i].Key(), req.Edges[j].Key()) })
+	sort.Slice(req.DeleteEdges, func(i, j int) bool { return keyLess(req.DeleteEdges[i], req.DeleteEdges[j]) })
+	return req
+}
+
+func keyLess(a, b mantr.EdgeKey) bool {
+	if a.From != b.From {
+		return a.From < b.From
+	}
+	if a.To != b.To {
+		return a.To < b.To
+	}
+	return a.Type < b.Type
+}
@@ -0,0 +1,315 @@
+package branch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// podStore fakes the clone, snapshot, ingestion and walk endpoints over
+// in-memory graphs
+type podStore struct {
+	mu      sync.Mutex
+	pods    map[string]*mantr.Snapshot
+	ingests int
+	// beforeClone runs when a clone is requested, to simulate a write
+	// racing with the clone
+	beforeClone func(s *podStore)
+}
+
+func (s *podStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/v1/walk" {
+		var req mantr.WalkRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := mantr.WalkResponse{CreditsUsed: 1}
+		for _, e := range s.pods[req.Pod].Edges {
+			if e.From == req.Phonemes[0] {
+				resp.Paths = append(resp.Paths, mantr.PathResult{Nodes: []string{e.From, e.To}, Score: e.Weight})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/pods/"), "/")
+	snap := s.pods[name]
+	if snap == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch action {
+	case "clone":
+		var body struct{ Name string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if s.beforeClone != nil {
+			s.beforeClone(s)
+		}
+		data, _ := json.Marshal(snap)
+		var clone mantr.Snapshot
+		json.Unmarshal(data, &clone)
+		s.pods[body.Name] = &clone
+		json.NewEncoder(w).Encode(mantr.Pod{Name: body.Name, Version: clone.Version})
+	case "snapshot":
+		json.NewEncoder(w).Encode(snap)
+	case "ingest":
+		var req mantr.IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.ingests++
+		snap.Version++
+		for _, n := range req.Nodes {
+			snap.Nodes = append(removeNode(snap.Nodes, n.ID), n)
+		}
+		for _, e := range req.Edges {
+			snap.Edges = append(removeEdge(snap.Edges, e.Key()), e)
+		}
+		for _, k := range req.DeleteEdges {
+			snap.Edges = removeEdge(snap.Edges, k)
+		}
+		for _, id := range req.DeleteNodes {
+			snap.Nodes = removeNode(snap.Nodes, id)
+		}
+		json.NewEncoder(w).Encode(mantr.IngestResponse{Version: snap.Version})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func removeNode(nodes []mantr.Node, id string) []mantr.Node {
+	var out []mantr.Node
+	for _, n := range nodes {
+		if n.ID != id {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func removeEdge(edges []mantr.Edge, key mantr.EdgeKey) []mantr.Edge {
+	var out []mantr.Edge
+	for _, e := range edges {
+		if e.Key() != key {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func newTestStore(t *testing.T) (*mantr.Client, *podStore) {
+	t.Helper()
+	store := &podStore{pods: map[string]*mantr.Snapshot{
+		"dharma": {
+			Pod:     "dharma",
+			Version: 3,
+			Nodes:   []mantr.Node{{ID: "karma"}, {ID: "samsara"}, {ID: "moksa"}},
+			Edges: []mantr.Edge{
+				{From: "karma", To: "samsara", Weight: 0.9},
+				{From: "karma", To: "moksa", Weight: 0.3},
+			},
+		},
+	}}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+
+	client, err := mantr.NewClient("vak_test", mantr.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, store
+}
+
+func TestCreate(t *testing.T) {
+	client, store := newTestStore(t)
+	// a write lands on the parent just before the clone; the base must
+	// match what was cloned
+	store.beforeClone = func(s *podStore) {
+		s.pods["dharma"].Nodes = append(s.pods["dharma"].Nodes, mantr.Node{ID: "ahimsa"})
+		s.pods["dharma"].Version++
+	}
+
+	b, err := Create(context.Background(), client, "dharma", "dharma_exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "dharma_exp" || b.Parent != "dharma" || b.BaseVersion != 4 {
+		t.Errorf("branch = %+v", b)
+	}
+	if b.Base.Digest() != store.pods["dharma_exp"].Digest() || b.Base.Pod != "dharma" {
+		t.Errorf("base = %+v", b.Base)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	client, store := newTestStore(t)
+	b, err := Create(context.Background(), client, "dharma", "dharma_exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.pods["dharma_exp"].Edges = []mantr.Edge{
+		{From: "karma", To: "samsara", Weight: 0.9},
+		{From: "karma", To: "ahimsa", Weight: 0.5},
+	}
+
+	cmp, err := b.Compare(context.Background(), client, []mantr.WalkRequest{
+		{Phonemes: []string{"karma"}},
+		{Phonemes: []string{"samsara"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []QueryDiff{
+		{
+			Phonemes: []string{"karma"}, Overlap: 1.0 / 3, ParentPaths: 2, BranchPaths: 2,
+			ParentTop: 0.9, BranchTop: 0.9,
+			Added: []string{"karma -> ahimsa"}, Removed: []string{"karma -> moksa"},
+		},
+		{Phonemes: []string{"samsara"}, Overlap: 1},
+	}
+	if !reflect.DeepEqual(cmp.Queries, want) {
+		t.Errorf("queries = %+v", cmp.Queries)
+	}
+	if cmp.MeanOverlap != (1.0/3+1)/2 || cmp.CreditsUsed != 4 {
+		t.Errorf("mean overlap %v, %d credits", cmp.MeanOverlap, cmp.CreditsUsed)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := &mantr.Snapshot{
+		Nodes: []mantr.Node{{ID: "karma"}, {ID: "samsara"}, {ID: "moksa"}},
+		Edges: []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.9}, {From: "karma", To: "moksa", Weight: 0.3}},
+	}
+	to := &mantr.Snapshot{
+		Nodes: []mantr.Node{{ID: "karma", Label: "Karma"}, {ID: "samsara"}, {ID: "ahimsa"}},
+		Edges: []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.9}, {From: "ahimsa", To: "karma", Weight: 1}},
+	}
+
+	want := &mantr.IngestRequest{
+		Nodes:       []mantr.Node{{ID: "ahimsa"}, {ID: "karma", Label: "Karma"}},
+		Edges:       []mantr.Edge{{From: "ahimsa", To: "karma", Weight: 1}},
+		DeleteNodes: []string{"moksa"},
+		DeleteEdges: []mantr.EdgeKey{{From: "karma", To: "moksa"}},
+	}
+	if got := diff(from, to).request(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %+v, want %+v", got, want)
+	}
+	if got := diff(from, from).request(); !reflect.DeepEqual(got, &mantr.IngestRequest{}) {
+		t.Errorf("diff of identical graphs = %+v", got)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	base := &mantr.Snapshot{
+		Nodes: []mantr.Node{{ID: "karma"}, {ID: "samsara"}, {ID: "moksa"}},
+		Edges: []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.9}},
+	}
+	edited := func(edit func(s *mantr.Snapshot)) *mantr.Snapshot {
+		s := &mantr.Snapshot{
+			Nodes: append([]mantr.Node(nil), base.Nodes...),
+			Edges: append([]mantr.Edge(nil), base.Edges...),
+		}
+		edit(s)
+		return s
+	}
+	relabel := func(label string) func(s *mantr.Snapshot) {
+		return func(s *mantr.Snapshot) { s.Nodes[0].Label = label }
+	}
+	reweight := func(w float64) func(s *mantr.Snapshot) {
+		return func(s *mantr.Snapshot) { s.Edges[0].Weight = w }
+	}
+	deleteMoksa := func(s *mantr.Snapshot) { s.Nodes = s.Nodes[:2] }
+	linkMoksa := func(s *mantr.Snapshot) {
+		s.Edges = append(s.Edges, mantr.Edge{From: "samsara", To: "moksa", Weight: 0.4})
+	}
+	addAhimsa := func(s *mantr.Snapshot) { s.Nodes = append(s.Nodes, mantr.Node{ID: "ahimsa"}) }
+
+	moksaEdge := &mantr.EdgeKey{From: "samsara", To: "moksa"}
+	tests := []struct {
+		name   string
+		branch func(s *mantr.Snapshot)
+		parent func(s *mantr.Snapshot)
+		want   []Conflict
+	}{
+		{"independent changes", relabel("Karma"), addAhimsa, nil},
+		{"same node change", relabel("Karma"), relabel("Karma"), nil},
+		{"node changed on both", relabel("Karma"), relabel("Kamma"), []Conflict{{Node: "karma", Reason: "changed on both branch and parent"}}},
+		{"edge changed on both", reweight(0.5), reweight(0.1), []Conflict{{Edge: &mantr.EdgeKey{From: "karma", To: "samsara"}, Reason: "changed on both branch and parent"}}},
+		{"branch links a node the parent deleted", linkMoksa, deleteMoksa, []Conflict{{Edge: moksaEdge, Reason: "endpoint moksa deleted on parent"}}},
+		{"branch deletes a node the parent linked", deleteMoksa, linkMoksa, []Conflict{{Edge: moksaEdge, Reason: "endpoint moksa deleted on branch"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conflicts(diff(base, edited(tt.branch)), diff(base, edited(tt.parent)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conflicts = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MergeOptions
+		parent  []mantr.Edge
+		wantErr error
+		applied bool
+	}{
+		{name: "clean", applied: true},
+		{name: "dry run", opts: MergeOptions{DryRun: true}},
+		{
+			name:    "conflict",
+			parent:  []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.1}},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "forced",
+			opts:    MergeOptions{Force: true},
+			parent:  []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.1}},
+			applied: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, store := newTestStore(t)
+			b, err := Create(ctx, client, "dharma", "dharma_exp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.Ingest(ctx, client, &mantr.IngestRequest{
+				Nodes: []mantr.Node{{ID: "ahimsa"}},
+				Edges: []mantr.Edge{{From: "karma", To: "samsara", Weight: 0.5}, {From: "ahimsa", To: "karma", Weight: 1}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.parent != nil {
+				store.pods["dharma"].Edges = append(removeEdge(store.pods["dharma"].Edges, tt.parent[0].Key()), tt.parent...)
+			}
+			store.ingests = 0
+
+			res, err := b.Merge(ctx, client, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Merge = %v, want %v", err, tt.wantErr)
+			}
+			if res.Applied != tt.applied || (store.ingests > 0) != tt.applied {
+				t.Fatalf("applied = %v with %d ingestion calls", res.Applied, store.ingests)
+			}
+			if len(res.Changes.Nodes) != 1 || len(res.Changes.Edges) != 2 {
+				t.Errorf("changes = %+v", res.Changes)
+			}
+			if tt.applied && store.pods["dharma"].Digest() != store.pods["dharma_exp"].Digest() {
+				t.Errorf("parent %+v differs from branch", store.pods["dharma"])
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package mantr
+
+// WalkBuilder assembles a WalkRequest step by step
+//
+//	req := mantr.NewWalkBuilder("fever", "infection").
+//		Pod("medicine").
+//		Direction(mantr.DirectionOut).
+//		EdgeTypes("causes").
+//		Build()
+type WalkBuilder struct {
+	req WalkRequest
+}
+
+// NewWalkBuilder starts a walk request from the given phonemes
+func NewWalkBuilder(phonemes ...string) *WalkBuilder {
+	return &WalkBuilder{req: WalkRequest{Phonemes: phonemes}}
+}
+
+// Pod sets the pod to walk
+func (b *WalkBuilder) Pod(pod string) *WalkBuilder {
+	b.req.Pod = pod
+	return b
+}
+
+// Depth sets the maximum path length
+func (b *WalkBuilder) Depth(depth int) *WalkBuilder {
+	b.req.Depth = depth
+	return b
+}
+
+// Limit sets the maximum number of paths
+func (b *WalkBuilder) Limit(limit int) *WalkBuilder {
+	b.req.Limit = limit
+	return b
+}
+
+// Mode sets the walk mode
+func (b *WalkBuilder) Mode(mode string) *WalkBuilder {
+	b.req.Mode = mode
+	return b
+}
+
+// Sample selects ModeSample with the given sample count, restart
+// probability and seed
+func (b *WalkBuilder) Sample(samples int, restart float64, seed int64) *WalkBuilder {
+	b.req.Mode = ModeSample
+	b.req.Samples = samples
+	b.req.RestartProbability = restart
+	b.req.Seed = seed
+	return b
+}
+
+// Direction restricts which way edges are followed
+func (b *WalkBuilder) Direction(direction string) *WalkBuilder {
+	b.req.Direction = direction
+	return b
+}
+
+// EdgeTypes limits the walk to edges of the given types
+func (b *WalkBuilder) EdgeTypes(types ...string) *WalkBuilder {
+	b.req.EdgeTypes = append(b.req.EdgeTypes, types...)
+	return b
+}
+
+// ExcludeEdgeTypes keeps the walk off edges of the given types
+func (b *WalkBuilder) ExcludeEdgeTypes(types ...string) *WalkBuilder {
+	b.req.ExcludeEdgeTypes = append(b.req.ExcludeEdgeTypes, types...)
+	return b
+}
+
+// Build returns the request. The builder can be reused; later changes do
+// not affect requests already built.
+func (b *WalkBuilder) Build() *WalkRequest {
+	req := b.req
+	req.Phonemes = append([]string(nil), b.req.Phonemes...)
+	req.EdgeTypes = append([]string(nil), b.req.EdgeTypes...)
+	req.ExcludeEdgeTypes = append([]string(nil), b.req.ExcludeEdgeTypes...)
+	return &req
+}
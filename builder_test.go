@@ -0,0 +1,46 @@
+package mantr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkBuilder(t *testing.T) {
+	b := NewWalkBuilder("fever", "infection").
+		Pod("medicine").
+		Depth(2).
+		Limit(5).
+		Sample(200, 0.15, 7).
+		Direction(DirectionOut).
+		EdgeTypes("causes").
+		EdgeTypes("treats").
+		ExcludeEdgeTypes("related")
+
+	want := &WalkRequest{
+		Phonemes:           []string{"fever", "infection"},
+		Pod:                "medicine",
+		Depth:              2,
+		Limit:              5,
+		Mode:               ModeSample,
+		Samples:            200,
+		RestartProbability: 0.15,
+		Seed:               7,
+		Direction:          DirectionOut,
+		EdgeTypes:          []string{"causes", "treats"},
+		ExcludeEdgeTypes:   []string{"related"},
+	}
+	req := b.Build()
+	if !reflect.DeepEqual(req, want) {
+		t.Fatalf("Build = %+v, want %+v", req, want)
+	}
+
+	// built requests are independent of the builder and of each other
+	req.EdgeTypes[0] = "changed"
+	b.EdgeTypes("prevents").Mode(ModeTopPaths)
+	if !reflect.DeepEqual(b.Build().EdgeTypes, []string{"causes", "treats", "prevents"}) {
+		t.Errorf("builder edge types = %v", b.Build().EdgeTypes)
+	}
+	if req.Mode != ModeSample || len(req.EdgeTypes) != 2 {
+		t.Errorf("built request changed: %+v", req)
+	}
+}
@@ -0,0 +1,235 @@
+package mantr
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached walk response with the validators needed to
+// revalidate it
+type CacheEntry struct {
+	Response     *WalkResponse
+	ETag         string
+	LastModified string
+	// Expires is when the entry must be revalidated; zero means always
+	Expires time.Time
+}
+
+// Fresh reports whether the entry can be served without revalidation
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	return !e.Expires.IsZero() && now.Before(e.Expires)
+}
+
+// Cache stores walk responses keyed by request
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// WithCache enables HTTP caching of walk responses. Fresh entries are served
+// locally; stale entries are revalidated with If-None-Match and
+// If-Modified-Since, and a 304 reply serves the cached response.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// CacheKey returns the cache key for a walk request
+func CacheKey(req *WalkRequest) string {
+	b, _ := json.Marshal(req)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedWalk performs a walk through the client cache
+func (c *Client) cachedWalk(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	key := CacheKey(req)
+	now := time.Now()
+
+	entry, ok := c.cache.Get(key)
+	if ok && entry.Fresh(now) {
+		return entry.served(0), nil
+	}
+
+	var walkResp *WalkResponse
+	err := c.meter(ctx, req.Pod, c.estimate(req), func() (int, error) {
+		var err error
+		walkResp, err = c.revalidate(ctx, req, key, entry, ok, now)
+		if err != nil {
+			return 0, err
+		}
+		return walkResp.CreditsUsed, nil
+	})
+	return walkResp, err
+}
+
+// revalidate sends a walk, conditional on the cached entry if there is one,
+// and updates the cache from the response
+func (c *Client) revalidate(ctx context.Context, req *WalkRequest, key string, entry *CacheEntry, ok bool, now time.Time) (*WalkResponse, error) {
+	httpReq, err := c.newRequest(ctx, "POST", "/v1/walk", req)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if entry.ETag != "" {
+			httpReq.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			httpReq.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		// servers that support free revalidation omit X-Credits-Used
+		credits, _ := strconv.Atoi(resp.Header.Get("X-Credits-Used"))
+		updated := *entry
+		if exp, store := expiry(resp.Header, now); store {
+			updated.Expires = exp
+		}
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			updated.ETag = etag
+		}
+		c.cache.Set(key, &updated)
+		return updated.served(credits), nil
+	}
+
+	var walkResp WalkResponse
+	if err := decodeResponse(resp, &walkResp); err != nil {
+		return nil, err
+	}
+
+	exp, store := expiry(resp.Header, now)
+	etag, lastMod := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if store && (etag != "" || lastMod != "" || !exp.IsZero()) {
+		stored := walkResp
+		stored.Paths = copyPaths(walkResp.Paths)
+		c.cache.Set(key, &CacheEntry{Response: &stored, ETag: etag, LastModified: lastMod, Expires: exp})
+	}
+
+	return &walkResp, nil
+}
+
+// served returns a copy of the cached response charged at credits
+func (e *CacheEntry) served(credits int) *WalkResponse {
+	resp := *e.Response
+	resp.Paths = copyPaths(e.Response.Paths)
+	resp.CreditsUsed = credits
+	resp.FromCache = true
+	return &resp
+}
+
+// copyPaths deep-copies paths so callers cannot modify cached entries
+func copyPaths(paths []PathResult) []PathResult {
+	out := make([]PathResult, len(paths))
+	for i, p := range paths {
+		p.Nodes = append([]string(nil), p.Nodes...)
+		p.EdgeTypes = append([]string(nil), p.EdgeTypes...)
+		p.Connects = append([]string(nil), p.Connects...)
+		p.Segments = append([]Segment(nil), p.Segments...)
+		out[i] = p
+	}
+	return out
+}
+
+// expiry derives an entry's expiry from Cache-Control. It reports false when
+// the response must not be stored; no-store wins over every other directive.
+func expiry(h http.Header, now time.Time) (time.Time, bool) {
+	var (
+		exp              time.Time
+		noStore, noCache bool
+	)
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		switch {
+		case directive == "no-store":
+			noStore = true
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if secs, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && secs > 0 {
+				exp = now.Add(time.Duration(secs) * time.Second)
+			}
+		}
+	}
+	if noStore {
+		return time.Time{}, false
+	}
+	if noCache {
+		return time.Time{}, true
+	}
+	return exp, true
+}
+
+// MemoryCache is an in-memory LRU Cache safe for concurrent use
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates an LRU cache holding up to size entries
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemoryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+// Set stores the entry for key, evicting the least recently used entry when
+// the cache is full
+func (m *MemoryCache) Set(key string, entry *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	if m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryItem).key)
+	}
+}
@@ -0,0 +1,220 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheServer answers walks with fixed caching headers and replies 304 to
+// requests whose validators match
+type cacheServer struct {
+	cacheControl string
+	etag         string
+	lastModified string
+
+	mu          sync.Mutex
+	walks       int
+	revalidated int
+	conditional []string
+}
+
+func (s *cacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.walks++
+	inm, ims := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+	if inm != "" || ims != "" {
+		s.conditional = append(s.conditional, inm+"|"+ims)
+	}
+	if s.cacheControl != "" {
+		w.Header().Set("Cache-Control", s.cacheControl)
+	}
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	if s.lastModified != "" {
+		w.Header().Set("Last-Modified", s.lastModified)
+	}
+	if (inm != "" && inm == s.etag) || (ims != "" && ims == s.lastModified) {
+		s.revalidated++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	json.NewEncoder(w).Encode(WalkResponse{
+		Paths:       []PathResult{{Nodes: []string{"karma", "samsara"}, Score: 0.9, Depth: 1}},
+		CreditsUsed: 3,
+	})
+}
+
+func TestCachedWalk(t *testing.T) {
+	tests := []struct {
+		name        string
+		server      *cacheServer
+		walks       int
+		revalidated int
+		conditional []string
+		fromCache   bool
+	}{
+		{
+			name:      "fresh entries are served locally",
+			server:    &cacheServer{cacheControl: "max-age=60"},
+			walks:     1,
+			fromCache: true,
+		},
+		{
+			name:        "stale entries are revalidated by ETag",
+			server:      &cacheServer{etag: `"v1"`},
+			walks:       2,
+			revalidated: 1,
+			conditional: []string{`"v1"|`},
+			fromCache:   true,
+		},
+		{
+			name:        "stale entries are revalidated by Last-Modified",
+			server:      &cacheServer{lastModified: "Mon, 12 Oct 2026 08:00:00 GMT"},
+			walks:       2,
+			revalidated: 1,
+			conditional: []string{"|Mon, 12 Oct 2026 08:00:00 GMT"},
+			fromCache:   true,
+		},
+		{
+			name:        "no-cache always revalidates",
+			server:      &cacheServer{cacheControl: "no-cache, max-age=60", etag: `"v1"`},
+			walks:       2,
+			revalidated: 1,
+			conditional: []string{`"v1"|`},
+			fromCache:   true,
+		},
+		{
+			name:   "no-store is not cached",
+			server: &cacheServer{cacheControl: "max-age=60, no-store", etag: `"v1"`},
+			walks:  2,
+		},
+		{
+			name:   "no-store wins after no-cache",
+			server: &cacheServer{cacheControl: "no-cache, no-store", etag: `"v1"`},
+			walks:  2,
+		},
+		{
+			name:   "no-store wins before no-cache",
+			server: &cacheServer{cacheControl: "no-store, no-cache", etag: `"v1"`},
+			walks:  2,
+		},
+		{
+			name:   "responses without validators are not cached",
+			server: &cacheServer{},
+			walks:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.server)
+			defer server.Close()
+			client, err := NewClient("vak_test", WithBaseURL(server.URL), WithCache(NewMemoryCache(10)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			first, err := client.WalkContext(ctx, &WalkRequest{Phonemes: []string{"karma"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := client.WalkContext(ctx, &WalkRequest{Phonemes: []string{"karma"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if first.FromCache || first.CreditsUsed != 3 {
+				t.Errorf("first walk: from cache %v, %d credits", first.FromCache, first.CreditsUsed)
+			}
+			if second.FromCache != tt.fromCache || (tt.fromCache && second.CreditsUsed != 0) {
+				t.Errorf("second walk: from cache %v, %d credits", second.FromCache, second.CreditsUsed)
+			}
+			if !reflect.DeepEqual(second.Paths, first.Paths) {
+				t.Errorf("second walk paths = %+v, want %+v", second.Paths, first.Paths)
+			}
+			s := tt.server
+			if s.walks != tt.walks || s.revalidated != tt.revalidated || !reflect.DeepEqual(s.conditional, tt.conditional) {
+				t.Errorf("server saw %d walks, %d revalidated, conditional headers %q", s.walks, s.revalidated, s.conditional)
+			}
+		})
+	}
+}
+
+func TestCachedWalkCopies(t *testing.T) {
+	server := httptest.NewServer(&cacheServer{cacheControl: "max-age=60"})
+	defer server.Close()
+	client, err := NewClient("vak_test", WithBaseURL(server.URL), WithCache(NewMemoryCache(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	walk := func() *WalkResponse {
+		resp, err := client.WalkContext(ctx, &WalkRequest{Phonemes: []string{"karma"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	// modifying the live response or a served copy must not change the
+	// cached entry
+	walk().Paths[0].Nodes[0] = "changed"
+	walk().Paths[0].Nodes[1] = "changed"
+	if nodes := walk().Paths[0].Nodes; !reflect.DeepEqual(nodes, []string{"karma", "samsara"}) {
+		t.Errorf("served nodes = %v", nodes)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		cacheControl string
+		exp          time.Time
+		store        bool
+	}{
+		{"", time.Time{}, true},
+		{"max-age=60", now.Add(time.Minute), true},
+		{"public, Max-Age=5", now.Add(5 * time.Second), true},
+		{"max-age=0", time.Time{}, true},
+		{"max-age=soon", time.Time{}, true},
+		{"no-cache, max-age=60", time.Time{}, true},
+		{"max-age=60, no-cache", time.Time{}, true},
+		{"no-store", time.Time{}, false},
+		{"no-cache, no-store", time.Time{}, false},
+		{"max-age=60, no-store", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("Cache-Control", tt.cacheControl)
+		exp, store := expiry(h, now)
+		if !exp.Equal(tt.exp) || store != tt.store {
+			t.Errorf("expiry(%q) = %v, %v, want %v, %v", tt.cacheControl, exp, store, tt.exp, tt.store)
+		}
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	m := NewMemoryCache(2)
+	m.Set("a", &CacheEntry{ETag: "a"})
+	m.Set("b", &CacheEntry{ETag: "b"})
+	m.Get("a")
+	m.Set("c", &CacheEntry{ETag: "c"})
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if e, ok := m.Get(key); !ok || e.ETag != key {
+			t.Errorf("Get(%q) = %v, %v", key, e, ok)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package mantr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -20,6 +22,12 @@ var (
 
 	// ErrRateLimit indicates rate limit exceeded
 	ErrRateLimit = errors.New("mantr: rate limit exceeded")
+
+	// ErrNotFound indicates the pod or resource does not exist
+	ErrNotFound = errors.New("mantr: not found")
+
+	// ErrNotSupported indicates the server does not implement an endpoint
+	ErrNotSupported = errors.New("mantr: not supported by server")
 )
 
 // Client is the Mantr API client
@@ -27,6 +35,10 @@ type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	cache      Cache
+	fallback   *OfflineWalker
+	limiter    *CreditLimiter
+	sims       *similarityCache
 }
 
 // NewClient creates a new Mantr API client
@@ -41,6 +53,7 @@ func NewClient(apiKey string, options ...Option) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		sims: newSimilarityCache(4096, 10*time.Minute),
 	}
 
 	for _, opt := range options {
@@ -50,64 +63,190 @@ func NewClient(apiKey string, options ...Option) (*Client, error) {
 	return client, nil
 }
 
+// Walker is implemented by anything that can answer walk requests
+type Walker interface {
+	WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error)
+}
+
 // Walk traverses the semantic graph
 func (c *Client) Walk(req *WalkRequest) (*WalkResponse, error) {
+	return c.WalkContext(context.Background(), req)
+}
+
+// WalkContext traverses the semantic graph, honoring ctx cancellation
+func (c *Client) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
 	if len(req.Phonemes) == 0 {
 		return nil, fmt.Errorf("phonemes cannot be empty")
 	}
 
-	// Set defaults
-	if req.Depth == 0 {
-		req.Depth = 3
+	req.setDefaults()
+
+	if c.cache != nil {
+		return c.cachedWalk(ctx, req)
 	}
-	if req.Limit == 0 {
-		req.Limit = 100
+
+	var walkResp WalkResponse
+	err := c.meter(ctx, req.Pod, c.estimate(req), func() (int, error) {
+		err := c.do(ctx, "POST", "/v1/walk", req, &walkResp)
+		return walkResp.CreditsUsed, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := json.Marshal(req)
+	return &walkResp, nil
+}
+
+// do sends a JSON request to the API and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	httpReq, err := c.newRequest(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, out)
+}
+
+// newRequest builds an authenticated API request with an optional JSON body
+func (c *Client) newRequest(ctx context.Context, method, path string, in interface{}) (*http.Request, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(b)
 	}
 
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/v1/walk", bytes.NewBuffer(body))
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if in != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("User-Agent", "mantr-go/1.0.0")
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	return httpReq, nil
+}
+
+// decodeResponse checks the status of resp and decodes its JSON body into out
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if err := checkStatus(resp); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		return nil, ErrAuthentication
-	} else if resp.StatusCode == 402 {
-		return nil, ErrInsufficientCredits
-	} else if resp.StatusCode == 429 {
-		return nil, ErrRateLimit
-	} else if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error: status %d", resp.StatusCode)
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if w, ok := out.(*WalkResponse); ok && w.RequestID == "" {
+		w.RequestID = resp.Header.Get("X-Request-ID")
 	}
 
-	var walkResp WalkResponse
-	if err := json.NewDecoder(resp.Body).Decode(&walkResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return nil
+}
+
+// checkStatus maps an API status code to an error
+func checkStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == 401:
+		return ErrAuthentication
+	case resp.StatusCode == 402:
+		return ErrInsufficientCredits
+	case resp.StatusCode == 404:
+		return ErrNotFound
+	case resp.StatusCode == 409:
+		return ErrConflict
+	case resp.StatusCode == 501:
+		return ErrNotSupported
+	case resp.StatusCode == 429:
+		return ErrRateLimit
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return &statusError{code: resp.StatusCode}
 	}
+	return nil
+}
 
-	return &walkResp, nil
+// statusError is an API error status without a dedicated sentinel error
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API error: status %d", e.code)
 }
 
+// Walk modes
+const (
+	// ModeTopPaths returns the highest-scored paths (the default)
+	ModeTopPaths = "top_paths"
+	// ModeSample samples random walks with restart and returns the sampled
+	// paths with their visit frequencies
+	ModeSample = "sample"
+	// ModeConnect returns paths that together link all seeds, approximating
+	// the smallest connecting tree; each path has at most Depth edges
+	ModeConnect = "connect"
+)
+
+// Traversal directions
+const (
+	// DirectionBoth follows edges either way (the default)
+	DirectionBoth = "both"
+	// DirectionOut follows edges from their source to their target
+	DirectionOut = "out"
+	// DirectionIn follows edges from their target back to their source
+	DirectionIn = "in"
+)
+
 // WalkRequest represents a walk API request
 type WalkRequest struct {
 	Phonemes []string `json:"phonemes"`
 	Pod      string   `json:"pod,omitempty"`
 	Depth    int      `json:"depth,omitempty"`
 	Limit    int      `json:"limit,omitempty"`
+
+	// Mode selects how paths are produced (default ModeTopPaths)
+	Mode string `json:"mode,omitempty"`
+	// RestartProbability is the chance, per step, that a sampled walk ends
+	// and restarts from a seed (ModeSample only)
+	RestartProbability float64 `json:"restart_probability,omitempty"`
+	// Samples is the number of random walks drawn (ModeSample only,
+	// default 1000)
+	Samples int `json:"samples,omitempty"`
+	// Seed makes sampling reproducible (ModeSample only)
+	Seed int64 `json:"seed,omitempty"`
+
+	// Direction restricts which way edges are followed (default
+	// DirectionBoth)
+	Direction string `json:"direction,omitempty"`
+	// EdgeTypes, when set, limits the walk to edges of these types
+	EdgeTypes []string `json:"edge_types,omitempty"`
+	// ExcludeEdgeTypes lists edge types the walk never follows
+	ExcludeEdgeTypes []string `json:"exclude_edge_types,omitempty"`
+}
+
+// setDefaults fills in the default depth, limit and sample count
+func (req *WalkRequest) setDefaults() {
+	if req.Depth == 0 {
+		req.Depth = 3
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+	if req.Mode == ModeSample && req.Samples == 0 {
+		req.Samples = 1000
+	}
 }
 
 // PathResult represents a single path in the graph
@@ -115,6 +254,20 @@ type PathResult struct {
 	Nodes []string `json:"nodes"`
 	Score float64  `json:"score"`
 	Depth int      `json:"depth"`
+
+	// Visits and Frequency report how often a sampled path was drawn
+	// (ModeSample only)
+	Visits    int     `json:"visits,omitempty"`
+	Frequency float64 `json:"frequency,omitempty"`
+
+	// EdgeTypes lists the type of each edge followed, in order
+	EdgeTypes []string `json:"edge_types,omitempty"`
+
+	// Connects lists the seed phonemes a path links (ModeConnect only)
+	Connects []string `json:"connects,omitempty"`
+
+	// Segments splits a cross-pod path by pod (CrossWalker only)
+	Segments []Segment `json:"segments,omitempty"`
 }
 
 // WalkResponse represents a walk API response
@@ -122,6 +275,10 @@ type WalkResponse struct {
 	Paths       []PathResult `json:"paths"`
 	LatencyUS   int          `json:"latency_us"`
 	CreditsUsed int          `json:"credits_used"`
+	RequestID   string       `json:"request_id,omitempty"`
+
+	// FromCache is set when the response was served from the client cache
+	FromCache bool `json:"-"`
 }
 
 // Option is a functional option for Client
@@ -133,3 +290,11 @@ func WithBaseURL(url string) Option {
 		c.baseURL = url
 	}
 }
+
+// WithLocalFallback answers requests for endpoints the server does not
+// support, such as Rank, from a local snapshot
+func WithLocalFallback(w *OfflineWalker) Option {
+	return func(c *Client) {
+		c.fallback = w
+	}
+}
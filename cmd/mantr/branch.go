@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/branch"
+)
+
+const defaultBranchDir = ".mantr/branches"
+
+func branchPath(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+func podBranch(args []string) error {
+	fs := flag.NewFlagSet("pod branch", flag.ExitOnError)
+	from := fs.String("from", "", "parent pod to clone")
+	dir := fs.String("dir", defaultBranchDir, "directory branch state is saved in")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod branch -from <pod> <branch>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *from == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a parent pod and a branch name are required")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	b, err := branch.Create(context.Background(), client, *from, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	if err := b.Save(branchPath(*dir, b.Name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "created branch %s from %s (%d nodes, %d edges)\n",
+		b.Name, b.Parent, len(b.Base.Nodes), len(b.Base.Edges))
+	return nil
+}
+
+func podCompare(args []string) error {
+	fs := flag.NewFlagSet("pod compare", flag.ExitOnError)
+	dir := fs.String("dir", defaultBranchDir, "directory branch state is saved in")
+	depth := fs.Int("depth", 0, "walk depth")
+	limit := fs.Int("limit", 0, "walk limit")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod compare [flags] <branch> <queries.txt>\n\nqueries.txt holds one query per line as space-separated phonemes")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("a branch and a query file are required")
+	}
+
+	b, err := branch.Load(branchPath(*dir, fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	queries, err := readQueries(fs.Arg(1), *depth, *limit)
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	cmp, err := b.Compare(context.Background(), client, queries)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cmp)
+}
+
+func podMerge(args []string) error {
+	fs := flag.NewFlagSet("pod merge", flag.ExitOnError)
+	dir := fs.String("dir", defaultBranchDir, "directory branch state is saved in")
+	dryRun := fs.Bool("dry-run", false, "show the merge without applying it")
+	force := fs.Bool("force", false, "apply the branch's version of conflicting nodes and edges")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod merge [flags] <branch>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a branch is required")
+	}
+
+	b, err := branch.Load(branchPath(*dir, fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := b.Merge(context.Background(), client, branch.MergeOptions{DryRun: *dryRun, Force: *force})
+	if res != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(res); encErr != nil {
+			return encErr
+		}
+	}
+	if errors.Is(err, branch.ErrConflict) {
+		return fmt.Errorf("%w; resolve them on the branch or rerun with -force", err)
+	}
+	return err
+}
+
+// readQueries reads one walk request per line of space-separated phonemes,
+// skipping blank lines and # comments
+func readQueries(path string, depth, limit int) ([]mantr.WalkRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queries []mantr.WalkRequest
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, mantr.WalkRequest{Phonemes: strings.Fields(line), Depth: depth, Limit: limit})
+	}
+	return queries, sc.Err()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func podCrosswalk(args []string) error {
+	fs := flag.NewFlagSet("pod crosswalk", flag.ExitOnError)
+	from := fs.String("from", "", "snapshot of the crosswalk's from pod")
+	to := fs.String("to", "", "snapshot of the crosswalk's to pod")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod crosswalk -from <snapshot> -to <snapshot> <crosswalk.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *from == "" || *to == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("two snapshots and one crosswalk file are required")
+	}
+
+	cw, err := mantr.LoadCrosswalk(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fromSnap, err := mantr.LoadSnapshot(*from)
+	if err != nil {
+		return err
+	}
+	toSnap, err := mantr.LoadSnapshot(*to)
+	if err != nil {
+		return err
+	}
+	if err := cw.Validate(fromSnap, toSnap); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d mappings between %s and %s are valid\n", len(cw.Mappings), cw.From, cw.To)
+	return nil
+}
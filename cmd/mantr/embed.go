@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/embed"
+)
+
+func podEmbed(args []string) error {
+	fs := flag.NewFlagSet("pod embed", flag.ExitOnError)
+	out := fs.String("o", "", "write the vectors to this file")
+	vectors := fs.String("vectors", "", "query saved vectors instead of training")
+	nearest := fs.String("nearest", "", "print the nodes nearest to this node")
+	k := fs.Int("k", 10, "number of neighbors printed by -nearest")
+	dim := fs.Int("dim", 64, "vector size")
+	walks := fs.Int("walks", 10, "walks per node")
+	length := fs.Int("length", 40, "nodes per walk")
+	window := fs.Int("window", 5, "skip-gram window")
+	seed := fs.Int64("seed", 0, "random seed")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod embed [flags] <snapshot.json>\n       mantr pod embed -vectors <vectors.json> -nearest <node>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var (
+		v   *embed.Vectors
+		err error
+	)
+	switch {
+	case *vectors != "" && fs.NArg() == 0:
+		v, err = embed.LoadVectors(*vectors)
+	case *vectors == "" && fs.NArg() == 1:
+		var snap *mantr.Snapshot
+		if snap, err = mantr.LoadSnapshot(fs.Arg(0)); err != nil {
+			return err
+		}
+		v, err = embed.Train(context.Background(), snap, embed.Options{
+			Dim: *dim, WalksPerNode: *walks, WalkLength: *length, Window: *window, Seed: *seed,
+		})
+	default:
+		fs.Usage()
+		return fmt.Errorf("a snapshot or -vectors is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		if err := v.Save(*out); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "wrote %d vectors to %s\n", v.Len(), *out)
+	}
+	if *nearest == "" {
+		if *out == "" {
+			return v.Write(os.Stdout)
+		}
+		return nil
+	}
+	if _, ok := v.Vector(*nearest); !ok {
+		return fmt.Errorf("no vector for %q", *nearest)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v.Nearest(*nearest, *k))
+}
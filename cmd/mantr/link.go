@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/entity"
+)
+
+func podLink(args []string) error {
+	flags := flag.NewFlagSet("pod link", flag.ExitOnError)
+	pod := flags.String("pod", "", "pod name, exported through the API when no snapshot is given")
+	snapshot := flags.String("snapshot", "", "pod snapshot file")
+	minConfidence := flags.Float64("min-confidence", 0.5, "minimum mention confidence")
+	maxEdits := flags.Int("max-edits", 1, "edit distance allowed for fuzzy matches (negative disables)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: mantr pod link [flags] <file or directory>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 || (*pod == "") == (*snapshot == "") {
+		flags.Usage()
+		return fmt.Errorf("one of -pod or -snapshot and at least one input are required")
+	}
+
+	var (
+		snap *mantr.Snapshot
+		err  error
+	)
+	if *snapshot != "" {
+		snap, err = mantr.LoadSnapshot(*snapshot)
+	} else {
+		var client *mantr.Client
+		if client, err = newClient(); err == nil {
+			snap, err = client.ExportSnapshot(context.Background(), *pod)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	l := entity.New(snap, entity.Options{MaxEdits: *maxEdits, MinConfidence: *minConfidence})
+	enc := json.NewEncoder(os.Stdout)
+	link := func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(struct {
+			Source string        `json:"source"`
+			Spans  []entity.Span `json:"spans"`
+		}{path, l.Link(string(data))})
+	}
+
+	for _, path := range flags.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			if err := link(path); err != nil {
+				return err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".md", ".markdown", ".txt":
+				return link(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Command mantr is a command-line tool for working with Mantr pods
+package main
+
+import (
+	"fmt"
+	"os"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+const usage = `usage: mantr <command> [arguments]
+
+commands:
+  pod build      build a pod from local documents
+  pod import     apply an ingestion batch file to a pod
+  pod taxonomy   import SKOS or OWL taxonomies from Turtle or RDF/XML
+  pod suggest    suggest missing edges from a snapshot or walk results
+  pod link       tag documents with the pod nodes they mention
+  pod embed      train node embeddings or query nearest neighbors
+  pod subgraph   extract the neighborhood of seed concepts
+  pod crosswalk  validate a crosswalk against two pod snapshots
+  pod plan       diff a pod spec against the live pod
+  pod apply      apply a pod spec or saved plan
+  pod branch     clone a pod into an experimental branch
+  pod compare    compare walks between a branch and its parent
+  pod merge      merge a branch back into its parent
+  run            run a retrieval pipeline definition
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "pod":
+		err = podCmd(os.Args[2:])
+	case "run":
+		err = runCmd(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "mantr: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if _, ok := err.(errChanges); ok {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mantr: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newClient creates an API client from MANTR_API_KEY and MANTR_BASE_URL
+func newClient() (*mantr.Client, error) {
+	var opts []mantr.Option
+	if u := os.Getenv("MANTR_BASE_URL"); u != "" {
+		opts = append(opts, mantr.WithBaseURL(u))
+	}
+	return mantr.NewClient(os.Getenv("MANTR_API_KEY"), opts...)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/corpus"
+	"github.com/Mantrnet/go-sdk/linkpred"
+)
+
+func podCmd(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: mantr pod <build|import|taxonomy|suggest|link|embed|subgraph|crosswalk|plan|apply|branch|compare|merge> [arguments]")
+	}
+
+	switch args[0] {
+	case "build":
+		return podBuild(args[1:])
+	case "import":
+		return podImport(args[1:])
+	case "taxonomy":
+		return podTaxonomy(args[1:])
+	case "suggest":
+		return podSuggest(args[1:])
+	case "link":
+		return podLink(args[1:])
+	case "embed":
+		return podEmbed(args[1:])
+	case "subgraph":
+		return podSubgraph(args[1:])
+	case "crosswalk":
+		return podCrosswalk(args[1:])
+	case "plan":
+		return podPlan(args[1:])
+	case "apply":
+		return podApply(args[1:])
+	case "branch":
+		return podBranch(args[1:])
+	case "compare":
+		return podCompare(args[1:])
+	case "merge":
+		return podMerge(args[1:])
+	default:
+		return fmt.Errorf("unknown pod command %q", args[0])
+	}
+}
+
+func podBuild(args []string) error {
+	fs := flag.NewFlagSet("pod build", flag.ExitOnError)
+	pod := fs.String("pod", "", "pod name")
+	out := fs.String("o", "", "write the snapshot to this file (default stdout)")
+	push := fs.Bool("push", false, "push the result through the ingestion API instead of writing a snapshot")
+	window := fs.Int("window", 5, "co-occurrence window in concepts")
+	minCount := fs.Int("min-count", 2, "minimum concept frequency")
+	minPMI := fs.Float64("min-pmi", 0, "minimum normalized PMI for an edge")
+	batch := fs.Int("batch", 0, "ingestion batch size")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod build [flags] <file or directory>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no input files")
+	}
+	if *push && *pod == "" {
+		return fmt.Errorf("-push requires -pod")
+	}
+
+	b := corpus.NewBuilder(corpus.Options{Window: *window, MinCount: *minCount, MinPMI: *minPMI})
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			err = b.AddDir(path)
+		} else {
+			err = b.AddFile(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return saveOrPush(b.Snapshot(*pod), *out, *push, *batch)
+}
+
+// saveOrPush pushes a snapshot to its pod through the ingestion API or writes
+// it to a file, or stdout when out is empty
+func saveOrPush(snap *mantr.Snapshot, out string, push bool, batch int) error {
+	if push {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		resp, err := client.IngestSnapshot(context.Background(), snap.Pod, snap, batch)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "pushed %d nodes and %d edges to %s (version %d)\n",
+			resp.NodesUpserted, resp.EdgesUpserted, snap.Pod, resp.Version)
+		return nil
+	}
+
+	if out == "" {
+		return snap.Write(os.Stdout)
+	}
+	if err := snap.Save(out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d nodes and %d edges to %s\n", len(snap.Nodes), len(snap.Edges), out)
+	return nil
+}
+
+func podImport(args []string) error {
+	fs := flag.NewFlagSet("pod import", flag.ExitOnError)
+	pod := fs.String("pod", "", "pod name")
+	atomic := fs.Bool("atomic", false, "apply the batch as one transaction, failing if the pod changes meanwhile")
+	emulate := fs.Bool("emulate-tx", false, "with -atomic, fall back to checked batched ingestion on servers without transactions (not atomic)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod import [-atomic [-emulate-tx]] -pod <name> <ingest.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *pod == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a pod and one ingestion file are required")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var req mantr.IngestRequest
+	if err := json.NewDecoder(f).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", fs.Arg(0), err)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	var resp *mantr.IngestResponse
+	if *atomic {
+		resp, err = client.RunTx(context.Background(), *pod, mantr.TxOptions{Emulate: *emulate}, func(tx *mantr.Tx) error {
+			tx.Add(&req)
+			return nil
+		})
+	} else {
+		resp, err = client.Ingest(context.Background(), *pod, &req)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "upserted %d nodes and %d edges, deleted %d nodes and %d edges (version %d)\n",
+		resp.NodesUpserted, resp.EdgesUpserted, resp.NodesDeleted, resp.EdgesDeleted, resp.Version)
+	return nil
+}
+
+func podSuggest(args []string) error {
+	fs := flag.NewFlagSet("pod suggest", flag.ExitOnError)
+	snapshot := fs.String("snapshot", "", "pod snapshot file")
+	limit := fs.Int("limit", 100, "maximum number of suggestions")
+	minScore := fs.Float64("min-score", 0, "minimum suggestion score")
+	edgeType := fs.String("type", "related", "edge type for suggested edges")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod suggest [flags] [walk-response.json...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *snapshot == "" && fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("a snapshot or walk responses are required")
+	}
+
+	p := linkpred.New()
+	if *snapshot != "" {
+		snap, err := mantr.LoadSnapshot(*snapshot)
+		if err != nil {
+			return err
+		}
+		p.AddSnapshot(snap)
+	}
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var resp mantr.WalkResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		p.AddWalk(&resp)
+	}
+
+	suggestions := p.Suggest(linkpred.Options{Limit: *limit, MinScore: *minScore})
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(linkpred.IngestRequest(suggestions, *edgeType))
+}
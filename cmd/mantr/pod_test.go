@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func writeDocs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	docs := map[string]string{
+		"a.md":  "Karma binds beings to samsara.",
+		"b.txt": "Samsara follows karma.",
+	}
+	for name, text := range docs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPodBuild(t *testing.T) {
+	dir := writeDocs(t)
+	out := filepath.Join(t.TempDir(), "snap.json")
+	if err := podCmd([]string{"build", "-pod", "dharma", "-o", out, dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := mantr.LoadSnapshot(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Pod != "dharma" || len(snap.Nodes) != 2 || len(snap.Edges) != 1 {
+		t.Errorf("snapshot = %+v, want karma and samsara joined by one edge", snap)
+	}
+}
+
+func TestPodBuildPush(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []mantr.IngestRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/pods/dharma/ingest" {
+			http.NotFound(w, r)
+			return
+		}
+		var req mantr.IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(mantr.IngestResponse{NodesUpserted: len(req.Nodes), EdgesUpserted: len(req.Edges), Version: 2})
+	}))
+	defer server.Close()
+	t.Setenv("MANTR_API_KEY", "vak_test")
+	t.Setenv("MANTR_BASE_URL", server.URL)
+
+	if err := podCmd([]string{"build", "-pod", "dharma", "-push", "-batch", "1", writeDocs(t)}); err != nil {
+		t.Fatal(err)
+	}
+	// one node per batch, then the edge
+	if len(requests) != 3 || len(requests[0].Nodes) != 1 || len(requests[2].Edges) != 1 {
+		t.Errorf("ingestion requests = %+v", requests)
+	}
+}
+
+func TestPodBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no command", nil, "usage"},
+		{"unknown command", []string{"grow"}, `unknown pod command "grow"`},
+		{"no input", []string{"build", "-o", "x.json"}, "no input files"},
+		{"push without pod", []string{"build", "-push", "docs"}, "-push requires -pod"},
+		{"missing input", []string{"build", filepath.Join(t.TempDir(), "missing")}, "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := podCmd(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("podCmd(%q) = %v, want an error containing %q", tt.args, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Mantrnet/go-sdk/podspec"
+)
+
+// errChanges is returned by pod plan -detailed-exitcode when the plan is not
+// empty
+type errChanges struct{}
+
+func (errChanges) Error() string { return "plan has changes" }
+
+func podPlan(args []string) error {
+	fs := flag.NewFlagSet("pod plan", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	out := fs.String("out", "", "also save the plan as JSON to this file for pod apply -plan")
+	detailed := fs.Bool("detailed-exitcode", false, "exit with status 2 when the plan has changes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod plan [flags] <pod.yaml>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("one spec file is required")
+	}
+
+	spec, err := podspec.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	plan, err := spec.Plan(context.Background(), client)
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		if err := writePlan(*out, plan); err != nil {
+			return err
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(plan)
+	} else {
+		err = plan.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *detailed && !plan.Empty() {
+		return errChanges{}
+	}
+	return nil
+}
+
+func podApply(args []string) error {
+	fs := flag.NewFlagSet("pod apply", flag.ExitOnError)
+	planFile := fs.String("plan", "", "apply a plan saved by pod plan -out instead of planning a spec")
+	yes := fs.Bool("auto-approve", false, "skip the confirmation prompt")
+	batch := fs.Int("batch", 0, "ingestion batch size")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod apply [flags] <pod.yaml>\n       mantr pod apply [flags] -plan <plan.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var plan *podspec.Plan
+	switch {
+	case *planFile != "" && fs.NArg() == 0:
+		f, err := os.Open(*planFile)
+		if err != nil {
+			return err
+		}
+		plan, err = podspec.ReadPlan(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case *planFile == "" && fs.NArg() == 1:
+		spec, err := podspec.Load(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if plan, err = spec.Plan(ctx, client); err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("either a spec file or -plan is required")
+	}
+
+	if err := plan.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if plan.Empty() {
+		fmt.Println("No changes.")
+		return nil
+	}
+	if !*yes && !confirm(fmt.Sprintf("Apply these changes to pod %s?", plan.Pod)) {
+		return fmt.Errorf("apply cancelled")
+	}
+
+	if err := plan.Apply(ctx, client, *batch); err != nil {
+		return err
+	}
+	fmt.Println("Apply complete.")
+	return nil
+}
+
+func writePlan(path string, plan *podspec.Plan) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(plan); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// confirm asks a yes/no question on stdin
+func confirm(question string) bool {
+	fmt.Printf("%s Only 'yes' will be accepted: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Mantrnet/go-sdk/pipeline"
+)
+
+func runCmd(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	query := fs.String("q", "", "query text (default: remaining arguments)")
+	asJSON := fs.Bool("json", false, "print the full result as JSON")
+	trace := fs.Bool("trace", false, "print stage trace events to stderr")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr run [flags] <pipeline.yaml> [query...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no pipeline file")
+	}
+	path := fs.Arg(0)
+	// allow flags after the pipeline file as well
+	fs.Parse(fs.Args()[1:])
+	if *query == "" {
+		*query = strings.Join(fs.Args(), " ")
+	}
+	if *query == "" {
+		return fmt.Errorf("no query")
+	}
+
+	var opts []pipeline.Option
+	if *trace {
+		opts = append(opts, pipeline.WithTracer(func(e pipeline.TraceEvent) {
+			switch e.Kind {
+			case "start":
+				fmt.Fprintf(os.Stderr, "[%s] start\n", e.Stage)
+			case "end":
+				fmt.Fprintf(os.Stderr, "[%s] end %s err=%v\n", e.Stage, e.Duration, e.Err)
+			default:
+				fmt.Fprintf(os.Stderr, "[%s] %s\n", e.Stage, e.Message)
+			}
+		}))
+	}
+
+	p, err := pipeline.Load(path, opts...)
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	res, runErr := p.Run(context.Background(), client, *query)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(res.Output)
+		for _, s := range res.Stages {
+			fmt.Fprintf(os.Stderr, "%-12s %-8s %10s %4d credits\n", s.Name, s.Type, s.Duration, s.CreditsUsed)
+		}
+	}
+	return runErr
+}
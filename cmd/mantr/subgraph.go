@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func podSubgraph(args []string) error {
+	fs := flag.NewFlagSet("pod subgraph", flag.ExitOnError)
+	pod := fs.String("pod", "", "pod name")
+	snapshot := fs.String("snapshot", "", "extract from this snapshot file instead of the API")
+	radius := fs.Int("radius", 2, "maximum hops from a seed")
+	maxNodes := fs.Int("max-nodes", 500, "maximum number of nodes")
+	format := fs.String("format", "json", "output format: json, dot or graphml")
+	out := fs.String("o", "", "write to this file (default stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod subgraph [flags] <seed>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no seeds")
+	}
+
+	ctx := context.Background()
+	var (
+		sub *mantr.Snapshot
+		err error
+	)
+	if *snapshot != "" {
+		snap, err := mantr.LoadSnapshot(*snapshot)
+		if err != nil {
+			return err
+		}
+		sub, err = mantr.NewOfflineWalker(snap).Subgraph(ctx, fs.Args(), *radius, *maxNodes)
+		if err != nil {
+			return err
+		}
+	} else {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		sub, err = client.ExportSubgraph(ctx, &mantr.SubgraphRequest{Seeds: fs.Args(), Pod: *pod, Radius: *radius, MaxNodes: *maxNodes})
+		if err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "json":
+		err = sub.Write(w)
+	case "dot":
+		err = sub.WriteDOT(w)
+	case "graphml":
+		err = sub.WriteGraphML(w)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return err
+}
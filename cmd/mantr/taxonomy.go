@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mantrnet/go-sdk/taxonomy"
+)
+
+// weightFlags collects repeated -weight type=value flags
+type weightFlags map[string]float64
+
+func (w weightFlags) String() string {
+	return fmt.Sprint(map[string]float64(w))
+}
+
+func (w weightFlags) Set(v string) error {
+	typ, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("expected type=weight, got %q", v)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	w[typ] = f
+	return nil
+}
+
+func podTaxonomy(args []string) error {
+	fs := flag.NewFlagSet("pod taxonomy", flag.ExitOnError)
+	pod := fs.String("pod", "", "pod name")
+	out := fs.String("o", "", "write the snapshot to this file (default stdout)")
+	push := fs.Bool("push", false, "push the result through the ingestion API instead of writing a snapshot")
+	lang := fs.String("lang", "en", "preferred label language")
+	batch := fs.Int("batch", 0, "ingestion batch size")
+	weights := weightFlags{}
+	fs.Var(weights, "weight", "edge weight as type=value, e.g. related=0.3 (repeatable; negative drops the relation)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mantr pod taxonomy [flags] <file.ttl|file.rdf>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no input files")
+	}
+	if *push && *pod == "" {
+		return fmt.Errorf("-push requires -pod")
+	}
+
+	im := taxonomy.NewImporter(taxonomy.Options{Weights: weights, Language: *lang})
+	for _, path := range fs.Args() {
+		if err := im.AddFile(path); err != nil {
+			return err
+		}
+	}
+	snap, err := im.Snapshot(*pod)
+	if err != nil {
+		return err
+	}
+	return saveOrPush(snap, *out, *push, *batch)
+}
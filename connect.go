@@ -0,0 +1,198 @@
+package mantr
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"sort"
+)
+
+// hop is a node reached over an arc, as stored in a connecting tree
+type hop struct {
+	prev string
+	arc  arc
+}
+
+// connect links the request's seeds with an approximate Steiner tree. The
+// tree starts at the first resolvable seed and repeatedly grows by the
+// cheapest path of at most Depth edges to a seed it does not contain yet,
+// where an edge costs one hop plus the log of how much weaker it is than the
+// heaviest edge. Each returned path runs from an already connected seed to
+// the newly connected one, so the part of the tree a branch grows from
+// counts towards its Depth; seeds that cannot be reached are left out.
+func (w *OfflineWalker) connect(ctx context.Context, req *WalkRequest, t *traversal) ([]PathResult, error) {
+	// group node IDs by the phoneme they resolve from
+	var groups [][]string
+	var names []string
+	for _, p := range req.Phonemes {
+		if ids := w.Resolve(p); len(ids) > 0 {
+			groups = append(groups, ids)
+			names = append(names, p)
+		}
+	}
+	if len(groups) < 2 {
+		return nil, nil
+	}
+
+	seedOf := make(map[string]int)
+	for i, ids := range groups {
+		for _, id := range ids {
+			if _, ok := seedOf[id]; !ok {
+				seedOf[id] = i
+			}
+		}
+	}
+
+	root := groups[0][0]
+	tree := map[string]hop{root: {}}
+	connected := make(map[int]bool)
+	// a seed is connected once any of its nodes is in the tree
+	grow := func() {
+		for i, ids := range groups {
+			for _, id := range ids {
+				if _, ok := tree[id]; ok {
+					connected[i] = true
+				}
+			}
+		}
+	}
+	grow()
+	isSeed := func(id string) bool {
+		i, ok := seedOf[id]
+		return ok && connected[i]
+	}
+
+	var out []PathResult
+	for len(connected) < len(groups) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// hops is each tree node's distance back through the tree to a seed
+		hops := make(map[string]int, len(tree))
+		for id := range tree {
+			hops[id] = 0
+			for n := id; !isSeed(n); n = tree[n].prev {
+				hops[id]++
+			}
+		}
+		start, branch := w.nearest(hops, connected, seedOf, req.Depth, t)
+		if branch == nil {
+			break
+		}
+
+		// extend the branch back through the tree to the nearest seed
+		for id := start; !isSeed(id); id = tree[id].prev {
+			branch = append([]hop{{prev: tree[id].prev, arc: tree[id].arc}}, branch...)
+		}
+		for _, h := range branch {
+			if _, ok := tree[h.arc.to]; !ok {
+				tree[h.arc.to] = h
+			}
+		}
+
+		p := PathResult{Nodes: []string{branch[0].prev}, Score: 1, Depth: len(branch)}
+		for _, h := range branch {
+			p.Nodes = append(p.Nodes, h.arc.to)
+			p.EdgeTypes = append(p.EdgeTypes, h.arc.typ)
+			p.Score *= h.arc.weight / w.maxEdge
+		}
+		grow()
+		for _, id := range p.Nodes {
+			if isSeed(id) && !contains(p.Connects, names[seedOf[id]]) {
+				p.Connects = append(p.Connects, names[seedOf[id]])
+			}
+		}
+		out = append(out, p)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > req.Limit {
+		out = out[:req.Limit]
+	}
+	return out, nil
+}
+
+// nearest runs Dijkstra from every tree node and returns the cheapest path
+// to a node of an unconnected seed whose edges, added to the tree node's
+// hops back to a seed, number at most maxHops. The path is returned as the
+// tree node it starts from and the hops taken.
+func (w *OfflineWalker) nearest(tree map[string]int, connected map[int]bool, seedOf map[string]int, maxHops int, t *traversal) (string, []hop) {
+	type state struct {
+		cost  float64
+		hops  int
+		reach hop
+	}
+	best := make(map[string]state, len(tree))
+	pq := &costQueue{}
+	for id, hops := range tree {
+		best[id] = state{hops: hops}
+		heap.Push(pq, costItem{id: id})
+	}
+
+	done := make(map[string]bool)
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(costItem)
+		if done[it.id] {
+			continue
+		}
+		done[it.id] = true
+
+		if i, ok := seedOf[it.id]; ok && !connected[i] {
+			var path []hop
+			id := it.id
+			for {
+				if _, inTree := tree[id]; inTree {
+					return id, path
+				}
+				path = append([]hop{best[id].reach}, path...)
+				id = best[id].reach.prev
+			}
+		}
+
+		cur := best[it.id]
+		if cur.hops >= maxHops {
+			continue
+		}
+		for _, a := range w.adj[it.id] {
+			if _, inTree := tree[a.to]; inTree || done[a.to] || !t.follows(a) {
+				continue
+			}
+			cost := cur.cost + 1 + math.Log(w.maxEdge/a.weight)
+			if s, ok := best[a.to]; ok && s.cost <= cost {
+				continue
+			}
+			best[a.to] = state{cost: cost, hops: cur.hops + 1, reach: hop{prev: it.id, arc: a}}
+			heap.Push(pq, costItem{id: a.to, cost: cost})
+		}
+	}
+	return "", nil
+}
+
+type costItem struct {
+	id   string
+	cost float64
+}
+
+// costQueue is a min-heap of nodes by path cost, ties broken by ID
+type costQueue []costItem
+
+func (q costQueue) Len() int { return len(q) }
+
+func (q costQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].id < q[j].id
+}
+
+func (q costQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *costQueue) Push(x interface{}) { *q = append(*q, x.(costItem)) }
+
+func (q *costQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
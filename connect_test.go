@@ -0,0 +1,93 @@
+package mantr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOfflineConnect(t *testing.T) {
+	// aaa and bbb hang off the hub xxx; ccc is two hops further through yyy
+	fork := &Snapshot{Nodes: []Node{{ID: "aaa"}, {ID: "bbb"}, {ID: "ccc"}, {ID: "xxx"}, {ID: "yyy"}}, Edges: []Edge{
+		{From: "aaa", To: "xxx", Weight: 1}, {From: "xxx", To: "bbb", Weight: 1},
+		{From: "xxx", To: "yyy", Weight: 1}, {From: "yyy", To: "ccc", Weight: 1},
+	}}
+
+	type path struct {
+		nodes    []string
+		connects []string
+	}
+	tests := []struct {
+		name     string
+		snap     *Snapshot
+		phonemes []string
+		depth    int
+		want     []path
+	}{
+		{
+			name:     "two seeds",
+			snap:     testSnapshot(),
+			phonemes: []string{"samsara", "moksa"},
+			want:     []path{{[]string{"samsara", "karma", "dharma", "moksa"}, []string{"samsara", "moksa"}}},
+		},
+		{
+			name:     "three seeds",
+			snap:     testSnapshot(),
+			phonemes: []string{"karma", "moksa", "samsara"},
+			depth:    2,
+			want: []path{
+				{[]string{"karma", "samsara"}, []string{"karma", "samsara"}},
+				{[]string{"karma", "dharma", "moksa"}, []string{"karma", "moksa"}},
+			},
+		},
+		{
+			name:     "out of range",
+			snap:     testSnapshot(),
+			phonemes: []string{"samsara", "moksa"},
+			depth:    2,
+		},
+		{
+			// the tree path aaa-xxx counts towards the branch to ccc
+			name:     "tree prefix within depth",
+			snap:     fork,
+			phonemes: []string{"aaa", "bbb", "ccc"},
+			depth:    3,
+			want: []path{
+				{[]string{"aaa", "xxx", "bbb"}, []string{"aaa", "bbb"}},
+				{[]string{"aaa", "xxx", "yyy", "ccc"}, []string{"aaa", "ccc"}},
+			},
+		},
+		{
+			name:     "tree prefix beyond depth",
+			snap:     fork,
+			phonemes: []string{"aaa", "bbb", "ccc"},
+			depth:    2,
+			want:     []path{{[]string{"aaa", "xxx", "bbb"}, []string{"aaa", "bbb"}}},
+		},
+		{
+			name:     "single seed",
+			snap:     testSnapshot(),
+			phonemes: []string{"karma", "nirvana"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewOfflineWalker(tt.snap).WalkContext(context.Background(), &WalkRequest{
+				Phonemes: tt.phonemes, Depth: tt.depth, Mode: ModeConnect,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []path
+			for _, p := range resp.Paths {
+				got = append(got, path{p.Nodes, p.Connects})
+				if p.Depth != len(p.Nodes)-1 || p.Depth > max(tt.depth, 3) {
+					t.Errorf("path %v has depth %d", p.Nodes, p.Depth)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
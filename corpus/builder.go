@@ -0,0 +1,211 @@
+// Package corpus builds Mantr pod graphs from local Markdown and text documents
+package corpus
+
+import (
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Options configures a Builder
+type Options struct {
+	// Extensions lists the file extensions scanned by AddDir
+	// (default .md, .markdown, .txt)
+	Extensions []string
+	// Window is how many following concepts a concept co-occurs with (default 5)
+	Window int
+	// MinCount drops concepts seen fewer times than this (default 2)
+	MinCount int
+	// MinPMI drops edges whose normalized PMI is not above this (default 0)
+	MinPMI float64
+	// MaxProvenance caps the provenance entries kept per node and edge (default 3)
+	MaxProvenance int
+}
+
+type pair struct {
+	a, b string
+}
+
+// Builder accumulates concept statistics over documents and turns them into
+// a pod snapshot with PMI-weighted co-occurrence edges
+type Builder struct {
+	opts      Options
+	counts    map[string]int
+	pairs     map[pair]int
+	nodeProv  map[string][]mantr.Provenance
+	pairProv  map[pair][]mantr.Provenance
+	tokens    int
+	pairTotal int
+}
+
+var (
+	codeFence = regexp.MustCompile("(?ms)^```.*?^```")
+	linkURL   = regexp.MustCompile(`\]\([^)]*\)|<https?://[^>]*>`)
+)
+
+// NewBuilder creates a corpus builder
+func NewBuilder(opts Options) *Builder {
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = []string{".md", ".markdown", ".txt"}
+	}
+	if opts.Window <= 0 {
+		opts.Window = 5
+	}
+	if opts.MinCount <= 0 {
+		opts.MinCount = 2
+	}
+	if opts.MaxProvenance <= 0 {
+		opts.MaxProvenance = 3
+	}
+
+	return &Builder{
+		opts:     opts,
+		counts:   make(map[string]int),
+		pairs:    make(map[pair]int),
+		nodeProv: make(map[string][]mantr.Provenance),
+		pairProv: make(map[pair][]mantr.Provenance),
+	}
+}
+
+// AddDir adds every matching file below root, skipping hidden directories
+func (b *Builder) AddDir(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !b.matches(path) {
+			return nil
+		}
+		return b.AddFile(path)
+	})
+}
+
+// AddFile adds a single document
+func (b *Builder) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	b.Add(path, string(data))
+	return nil
+}
+
+// Add adds document text under the given source name. Fenced code blocks and
+// link targets are ignored; offsets refer to the original text.
+func (b *Builder) Add(source, text string) {
+	text = blank(codeFence, text)
+	text = blank(linkURL, text)
+
+	concepts := mantr.ExtractConcepts(text)
+	for i, t := range concepts {
+		b.counts[t.Text]++
+		b.tokens++
+		if len(b.nodeProv[t.Text]) < b.opts.MaxProvenance {
+			b.nodeProv[t.Text] = append(b.nodeProv[t.Text], mantr.Provenance{Source: source, Offset: t.Offset})
+		}
+
+		for j := i + 1; j < len(concepts) && j <= i+b.opts.Window; j++ {
+			u := concepts[j]
+			if u.Text == t.Text {
+				continue
+			}
+			p := pair{t.Text, u.Text}
+			if p.b < p.a {
+				p.a, p.b = p.b, p.a
+			}
+			b.pairs[p]++
+			b.pairTotal++
+			prov := mantr.Provenance{Source: source, Offset: t.Offset}
+			if n := len(b.pairProv[p]); n < b.opts.MaxProvenance && (n == 0 || b.pairProv[p][n-1] != prov) {
+				b.pairProv[p] = append(b.pairProv[p], prov)
+			}
+		}
+	}
+}
+
+// Snapshot returns the graph built so far. Edge weights are normalized PMI
+// scores in (0, 1]; nodes and edges are sorted for stable output.
+func (b *Builder) Snapshot(pod string) *mantr.Snapshot {
+	snap := &mantr.Snapshot{Pod: pod, Nodes: []mantr.Node{}, Edges: []mantr.Edge{}}
+
+	for id, n := range b.counts {
+		if n < b.opts.MinCount {
+			continue
+		}
+		snap.Nodes = append(snap.Nodes, mantr.Node{
+			ID:         id,
+			Attrs:      map[string]string{"count": strconv.Itoa(n)},
+			Provenance: b.nodeProv[id],
+		})
+	}
+	sort.Slice(snap.Nodes, func(i, j int) bool { return snap.Nodes[i].ID < snap.Nodes[j].ID })
+
+	for p, n := range b.pairs {
+		if b.counts[p.a] < b.opts.MinCount || b.counts[p.b] < b.opts.MinCount {
+			continue
+		}
+		w := b.npmi(p, n)
+		if w <= b.opts.MinPMI {
+			continue
+		}
+		snap.Edges = append(snap.Edges, mantr.Edge{
+			From:       p.a,
+			To:         p.b,
+			Weight:     math.Round(w*1e4) / 1e4,
+			Type:       "co-occurs",
+			Provenance: b.pairProv[p],
+		})
+	}
+	sort.Slice(snap.Edges, func(i, j int) bool {
+		if snap.Edges[i].From != snap.Edges[j].From {
+			return snap.Edges[i].From < snap.Edges[j].From
+		}
+		return snap.Edges[i].To < snap.Edges[j].To
+	})
+
+	return snap
+}
+
+// npmi returns the normalized pointwise mutual information of a pair
+func (b *Builder) npmi(p pair, n int) float64 {
+	pxy := float64(n) / float64(b.pairTotal)
+	px := float64(b.counts[p.a]) / float64(b.tokens)
+	py := float64(b.counts[p.b]) / float64(b.tokens)
+	if pxy >= 1 {
+		return 1
+	}
+	// pairs and tokens are counted separately, so the ratio can exceed the
+	// bound of a true joint distribution
+	return math.Min(math.Log(pxy/(px*py))/-math.Log(pxy), 1)
+}
+
+func (b *Builder) matches(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range b.opts.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// blank replaces every match of re with spaces so offsets are preserved
+func blank(re *regexp.Regexp, text string) string {
+	return re.ReplaceAllStringFunc(text, func(m string) string {
+		return strings.Repeat(" ", len(m))
+	})
+}
@@ -0,0 +1,127 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func TestBuilderSnapshot(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		docs  []string
+		nodes []string
+		edges [][2]string
+	}{
+		{
+			name:  "co-occurring concepts",
+			docs:  []string{"Karma binds to samsara. Karma and samsara."},
+			nodes: []string{"karma", "samsara"},
+			edges: [][2]string{{"karma", "samsara"}},
+		},
+		{
+			name:  "rare concepts are dropped",
+			opts:  Options{MinCount: 1},
+			docs:  []string{"karma samsara", "dharma"},
+			nodes: []string{"dharma", "karma", "samsara"},
+			edges: [][2]string{{"karma", "samsara"}},
+		},
+		{
+			name:  "code fences and link targets are ignored",
+			docs:  []string{"karma samsara\n```\nmoksa moksa\n```\n[karma](https://moksa.example/moksa) samsara"},
+			nodes: []string{"karma", "samsara"},
+			edges: [][2]string{{"karma", "samsara"}},
+		},
+		{
+			name:  "window bounds co-occurrence",
+			opts:  Options{Window: 1},
+			docs:  []string{"karma dharma samsara", "karma dharma samsara"},
+			nodes: []string{"dharma", "karma", "samsara"},
+			edges: [][2]string{{"dharma", "karma"}, {"dharma", "samsara"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder(tt.opts)
+			for i, doc := range tt.docs {
+				b.Add(filepath.Join("docs", string(rune('a'+i))+".md"), doc)
+			}
+			snap := b.Snapshot("dharma")
+
+			var nodes []string
+			for _, n := range snap.Nodes {
+				nodes = append(nodes, n.ID)
+			}
+			var edges [][2]string
+			for _, e := range snap.Edges {
+				edges = append(edges, [2]string{e.From, e.To})
+				if e.Weight <= 0 || e.Weight > 1 || e.Type != "co-occurs" {
+					t.Errorf("edge %s-%s: weight %v, type %q", e.From, e.To, e.Weight, e.Type)
+				}
+			}
+			if !reflect.DeepEqual(nodes, tt.nodes) {
+				t.Errorf("nodes = %v, want %v", nodes, tt.nodes)
+			}
+			if !reflect.DeepEqual(edges, tt.edges) {
+				t.Errorf("edges = %v, want %v", edges, tt.edges)
+			}
+		})
+	}
+}
+
+func TestBuilderProvenance(t *testing.T) {
+	b := NewBuilder(Options{MaxProvenance: 2})
+	b.Add("a.md", "karma samsara karma samsara karma")
+	snap := b.Snapshot("dharma")
+
+	want := []mantr.Node{
+		{ID: "karma", Attrs: map[string]string{"count": "3"}, Provenance: []mantr.Provenance{{Source: "a.md", Offset: 0}, {Source: "a.md", Offset: 14}}},
+		{ID: "samsara", Attrs: map[string]string{"count": "2"}, Provenance: []mantr.Provenance{{Source: "a.md", Offset: 6}, {Source: "a.md", Offset: 20}}},
+	}
+	if !reflect.DeepEqual(snap.Nodes, want) {
+		t.Errorf("nodes = %+v, want %+v", snap.Nodes, want)
+	}
+	if len(snap.Edges) != 1 || snap.Edges[0].Weight != 1 {
+		t.Fatalf("edges = %+v, want one edge of weight 1", snap.Edges)
+	}
+	if prov := snap.Edges[0].Provenance; len(prov) != 2 || prov[0].Offset != 0 || prov[1].Offset != 6 {
+		t.Errorf("edge provenance = %+v", prov)
+	}
+}
+
+func TestBuilderAddDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.md":           "karma samsara",
+		"b.TXT":          "karma samsara",
+		"c.go":           "moksa moksa",
+		".hidden/d.md":   "moksa moksa",
+		"sub/e.markdown": "karma",
+	}
+	for name, text := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := NewBuilder(Options{})
+	if err := b.AddDir(root); err != nil {
+		t.Fatal(err)
+	}
+	snap := b.Snapshot("dharma")
+	counts := make(map[string]string)
+	for _, n := range snap.Nodes {
+		counts[n.ID] = n.Attrs["count"]
+	}
+	if want := map[string]string{"karma": "3", "samsara": "2"}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
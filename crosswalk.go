@@ -0,0 +1,299 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EdgeTypeCrosswalk is reported in PathResult.EdgeTypes where a path moves
+// from one pod to another
+const EdgeTypeCrosswalk = "crosswalk"
+
+// Crosswalk maps nodes of one pod to the nodes of another pod that describe
+// the same concepts. Mappings apply in both directions.
+type Crosswalk struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping pairs a node of the From pod with a node of the To pod
+type Mapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Weight scales the score of paths crossing here (default 1)
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Segment is the part of a cross-pod path that lies in one pod. Start and
+// End are inclusive indexes into the path's nodes.
+type Segment struct {
+	Pod   string `json:"pod"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// LoadCrosswalk reads a JSON crosswalk file
+func LoadCrosswalk(path string) (*Crosswalk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Crosswalk
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode crosswalk %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Validate checks the crosswalk against snapshots of its two pods: the pod
+// names must match and every mapped node must exist. Duplicate mappings and
+// negative weights are also reported.
+func (c *Crosswalk) Validate(from, to *Snapshot) error {
+	var problems []string
+	if c.From == "" || c.To == "" {
+		problems = append(problems, "from and to pods are required")
+	} else if c.From == c.To {
+		problems = append(problems, "from and to pods are the same")
+	}
+	if from.Pod != "" && from.Pod != c.From {
+		problems = append(problems, fmt.Sprintf("from snapshot is pod %q, not %q", from.Pod, c.From))
+	}
+	if to.Pod != "" && to.Pod != c.To {
+		problems = append(problems, fmt.Sprintf("to snapshot is pod %q, not %q", to.Pod, c.To))
+	}
+
+	fromIDs, toIDs := nodeIDs(from), nodeIDs(to)
+	seen := make(map[Mapping]bool, len(c.Mappings))
+	for i, m := range c.Mappings {
+		if !fromIDs[m.From] {
+			problems = append(problems, fmt.Sprintf("mapping %d: node %q not in %s", i, m.From, c.From))
+		}
+		if !toIDs[m.To] {
+			problems = append(problems, fmt.Sprintf("mapping %d: node %q not in %s", i, m.To, c.To))
+		}
+		if m.Weight < 0 {
+			problems = append(problems, fmt.Sprintf("mapping %d: negative weight", i))
+		}
+		key := Mapping{From: m.From, To: m.To}
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("mapping %d: duplicate %s -> %s", i, m.From, m.To))
+		}
+		seen[key] = true
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid crosswalk %s -> %s: %s", c.From, c.To, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func nodeIDs(s *Snapshot) map[string]bool {
+	ids := make(map[string]bool, len(s.Nodes))
+	for _, n := range s.Nodes {
+		ids[n.ID] = true
+	}
+	return ids
+}
+
+// PodWalkers routes walk requests to a walker per pod, for example an
+// OfflineWalker per snapshot
+type PodWalkers map[string]Walker
+
+// WalkContext walks req.Pod with its walker
+func (m PodWalkers) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	w, ok := m[req.Pod]
+	if !ok {
+		return nil, fmt.Errorf("%w: pod %q", ErrNotFound, req.Pod)
+	}
+	return w.WalkContext(ctx, req)
+}
+
+// crossing is a mapped node in another pod
+type crossing struct {
+	pod    string
+	id     string
+	weight float64
+}
+
+// CrossWalker walks across pods linked by crosswalks. It walks the
+// request's pod and continues each path into other pods wherever its last
+// node, or its seed, is mapped. Seeds whose phonemes normalize to a mapped
+// node ID cross even when they have no paths in their own pod. The wrapped
+// walker must resolve node IDs given as phonemes. Each pod is entered at
+// most once per path, and Depth bounds the edges walked across all pods,
+// counting each crossing as one.
+type CrossWalker struct {
+	walker Walker
+	index  map[string]map[string][]crossing
+	// names maps normalized forms of mapped node IDs to the IDs, per pod
+	names map[string]map[string][]string
+}
+
+// NewCrossWalker creates a cross-pod walker over w
+func NewCrossWalker(w Walker, crosswalks ...*Crosswalk) *CrossWalker {
+	cw := &CrossWalker{
+		walker: w,
+		index:  make(map[string]map[string][]crossing),
+		names:  make(map[string]map[string][]string),
+	}
+	for _, c := range crosswalks {
+		for _, m := range c.Mappings {
+			weight := m.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			cw.add(c.From, m.From, crossing{pod: c.To, id: m.To, weight: weight})
+			cw.add(c.To, m.To, crossing{pod: c.From, id: m.From, weight: weight})
+		}
+	}
+	return cw
+}
+
+func (cw *CrossWalker) add(pod, id string, to crossing) {
+	if cw.index[pod] == nil {
+		cw.index[pod] = make(map[string][]crossing)
+		cw.names[pod] = make(map[string][]string)
+	}
+	if len(cw.index[pod][id]) == 0 {
+		key := Normalize(id)
+		cw.names[pod][key] = append(cw.names[pod][key], id)
+	}
+	cw.index[pod][id] = append(cw.index[pod][id], to)
+}
+
+// WalkContext walks req.Pod and the pods reachable from it through
+// crosswalks. Every path carries its per-pod segments.
+func (cw *CrossWalker) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	if req.Pod == "" {
+		return nil, fmt.Errorf("cross-pod walks require a pod")
+	}
+	req.setDefaults()
+
+	resp, err := cw.walker.WalkContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := &WalkResponse{LatencyUS: resp.LatencyUS, CreditsUsed: resp.CreditsUsed, RequestID: resp.RequestID}
+
+	// walks from a crossing are shared by all paths reaching it
+	type start struct {
+		pod   string
+		id    string
+		depth int
+	}
+	cache := make(map[start]*WalkResponse)
+	walkFrom := func(s start) (*WalkResponse, error) {
+		if r, ok := cache[s]; ok {
+			return r, nil
+		}
+		sub := *req
+		sub.Phonemes, sub.Pod, sub.Depth = []string{s.id}, s.pod, s.depth
+		r, err := cw.walker.WalkContext(ctx, &sub)
+		if err != nil {
+			return nil, fmt.Errorf("walk %s in %s: %w", s.id, s.pod, err)
+		}
+		out.CreditsUsed += r.CreditsUsed
+		out.LatencyUS += r.LatencyUS
+		cache[s] = r
+		return r, nil
+	}
+
+	var paths []PathResult
+	for _, p := range resp.Paths {
+		p.Segments = []Segment{{Pod: req.Pod, End: len(p.Nodes) - 1}}
+		paths = append(paths, p)
+	}
+
+	// extend paths pod by pod; each round crosses one more pod boundary
+	frontier := paths
+	// seeds may cross before any edge is walked, including mapped seeds
+	// without paths in their own pod
+	var seeds []string
+	for _, p := range resp.Paths {
+		seeds = append(seeds, p.Nodes[0])
+	}
+	for _, phoneme := range req.Phonemes {
+		seeds = append(seeds, cw.names[req.Pod][Normalize(phoneme)]...)
+	}
+	seen := make(map[string]bool)
+	for _, id := range seeds {
+		if !seen[id] {
+			seen[id] = true
+			frontier = append(frontier, PathResult{Nodes: []string{id}, Score: 1, Segments: []Segment{{Pod: req.Pod}}})
+		}
+	}
+	for len(frontier) > 0 {
+		var next []PathResult
+		for _, p := range frontier {
+			last := p.Segments[len(p.Segments)-1]
+			// the crossing takes one edge and the walk beyond it at least one
+			remaining := req.Depth - p.Depth - 1
+			if remaining <= 0 {
+				continue
+			}
+			for _, x := range cw.index[last.Pod][p.Nodes[len(p.Nodes)-1]] {
+				if entered(p, x.pod) {
+					continue
+				}
+				r, err := walkFrom(start{pod: x.pod, id: x.id, depth: remaining})
+				if err != nil {
+					return nil, err
+				}
+				for _, q := range r.Paths {
+					if len(q.Nodes) == 0 || q.Nodes[0] != x.id {
+						continue
+					}
+					next = append(next, join(p, q, x))
+				}
+			}
+		}
+		paths = append(paths, next...)
+		frontier = next
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool { return paths[i].Score > paths[j].Score })
+	if len(paths) > req.Limit {
+		paths = paths[:req.Limit]
+	}
+	out.Paths = paths
+	return out, nil
+}
+
+func entered(p PathResult, pod string) bool {
+	for _, s := range p.Segments {
+		if s.Pod == pod {
+			return true
+		}
+	}
+	return false
+}
+
+// join continues p with q, a path walked from crossing x
+func join(p, q PathResult, x crossing) PathResult {
+	types := p.EdgeTypes
+	if len(types) < len(p.Nodes)-1 {
+		types = make([]string, len(p.Nodes)-1)
+		copy(types, p.EdgeTypes)
+	}
+	qTypes := q.EdgeTypes
+	if len(qTypes) < len(q.Nodes)-1 {
+		qTypes = make([]string, len(q.Nodes)-1)
+		copy(qTypes, q.EdgeTypes)
+	}
+
+	nodes := append(append([]string(nil), p.Nodes...), q.Nodes...)
+	edgeTypes := append(append(append([]string(nil), types...), EdgeTypeCrosswalk), qTypes...)
+	segments := append(append([]Segment(nil), p.Segments...), Segment{Pod: x.pod, Start: len(p.Nodes), End: len(nodes) - 1})
+	return PathResult{
+		Nodes:     nodes,
+		EdgeTypes: edgeTypes,
+		Segments:  segments,
+		Score:     p.Score * x.weight * q.Score,
+		Depth:     p.Depth + 1 + q.Depth,
+	}
+}
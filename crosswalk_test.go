@@ -0,0 +1,101 @@
+package mantr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// testCrossWalker links testSnapshot to an English pod. ahimsa has no edges
+// in its own pod.
+func testCrossWalker() *CrossWalker {
+	dharma := testSnapshot()
+	dharma.Nodes = append(dharma.Nodes, Node{ID: "ahimsa"})
+	english := &Snapshot{
+		Pod:   "english",
+		Nodes: []Node{{ID: "duty"}, {ID: "action"}, {ID: "rebirth"}, {ID: "nonviolence"}},
+		Edges: []Edge{
+			{From: "duty", To: "action", Type: "requires", Weight: 0.8},
+			{From: "action", To: "rebirth", Type: "causes", Weight: 0.6},
+			{From: "nonviolence", To: "duty", Type: "part_of", Weight: 0.5},
+		},
+	}
+	walkers := PodWalkers{
+		"dharma":  NewOfflineWalker(dharma),
+		"english": NewOfflineWalker(english),
+	}
+	return NewCrossWalker(walkers, &Crosswalk{
+		From: "dharma",
+		To:   "english",
+		Mappings: []Mapping{
+			{From: "dharma", To: "duty"},
+			{From: "karma", To: "action"},
+			{From: "ahimsa", To: "nonviolence", Weight: 0.5},
+		},
+	})
+}
+
+func TestCrossWalkerDepth(t *testing.T) {
+	cw := testCrossWalker()
+
+	for _, depth := range []int{1, 2, 3, 4} {
+		resp, err := cw.WalkContext(context.Background(), &WalkRequest{Pod: "dharma", Phonemes: []string{"dharma"}, Depth: depth, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		crossed := false
+		for _, p := range resp.Paths {
+			if len(p.EdgeTypes) != p.Depth || len(p.Nodes) != p.Depth+1 {
+				t.Errorf("depth %d: path %v has Depth %d and %d edge types", depth, p.Nodes, p.Depth, len(p.EdgeTypes))
+			}
+			if p.Depth > depth {
+				t.Errorf("depth %d: path %v walks %d edges", depth, p.Nodes, p.Depth)
+			}
+			crossed = crossed || len(p.Segments) > 1
+		}
+		if want := depth >= 2; crossed != want {
+			t.Errorf("depth %d: crossed pods = %v, want %v", depth, crossed, want)
+		}
+	}
+}
+
+func TestCrossWalkerSegments(t *testing.T) {
+	resp, err := testCrossWalker().WalkContext(context.Background(), &WalkRequest{Pod: "dharma", Phonemes: []string{"karma"}, Depth: 2, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := PathResult{
+		Nodes:     []string{"karma", "action", "duty"},
+		EdgeTypes: []string{EdgeTypeCrosswalk, "requires"},
+		Segments:  []Segment{{Pod: "dharma"}, {Pod: "english", Start: 1, End: 2}},
+		Score:     1,
+		Depth:     2,
+	}
+	for _, p := range resp.Paths {
+		if reflect.DeepEqual(p.Nodes, want.Nodes) {
+			if !reflect.DeepEqual(p, want) {
+				t.Errorf("path = %+v, want %+v", p, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no crossing from the seed in %+v", resp.Paths)
+}
+
+func TestCrossWalkerUnlinkedSeed(t *testing.T) {
+	resp, err := testCrossWalker().WalkContext(context.Background(), &WalkRequest{Pod: "dharma", Phonemes: []string{"Ahimsa"}, Depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, p := range resp.Paths {
+		got = append(got, p.Nodes)
+	}
+	// the crossing weight halves the score of the walk beyond it
+	want := [][]string{{"ahimsa", "nonviolence", "duty"}}
+	if !reflect.DeepEqual(got, want) || resp.Paths[0].Score != 0.5*0.5/0.8 {
+		t.Errorf("paths = %+v, want %v", resp.Paths, want)
+	}
+}
@@ -0,0 +1,280 @@
+// Package embed trains node embeddings from pod snapshots with random walks
+// and skip-gram, for similarity lookups that run locally without credits
+package embed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Options configures Train
+type Options struct {
+	// Dim is the vector size (default 64)
+	Dim int
+	// WalksPerNode is the number of walks started at each node (default 10)
+	WalksPerNode int
+	// WalkLength is the number of nodes per walk (default 40)
+	WalkLength int
+	// Window is how many nodes on each side of a node count as its context
+	// (default 5)
+	Window int
+	// Negative is the number of negative samples per context node
+	// (default 5)
+	Negative int
+	// LearningRate is the initial skip-gram learning rate, decayed
+	// linearly over training (default 0.025)
+	LearningRate float64
+	// Seed makes training reproducible
+	Seed int64
+}
+
+func (o *Options) setDefaults() {
+	if o.Dim <= 0 {
+		o.Dim = 64
+	}
+	if o.WalksPerNode <= 0 {
+		o.WalksPerNode = 10
+	}
+	if o.WalkLength <= 0 {
+		o.WalkLength = 40
+	}
+	if o.Window <= 0 {
+		o.Window = 5
+	}
+	if o.Negative <= 0 {
+		o.Negative = 5
+	}
+	if o.LearningRate <= 0 {
+		o.LearningRate = 0.025
+	}
+}
+
+// neighbors lists a node's neighbors with cumulative edge weights for
+// weighted sampling
+type neighbors struct {
+	to  []int
+	cum []float64
+}
+
+// Train learns a vector per connected node of snap, DeepWalk style: random
+// walks follow edges in either direction with probability proportional to
+// weight, and skip-gram with negative sampling places nodes that co-occur on
+// walks close together. Training is single-threaded and fully determined by
+// the snapshot and Options, so the same seed always yields the same vectors.
+// Nodes without edges get no vector.
+func Train(ctx context.Context, snap *mantr.Snapshot, opts Options) (*Vectors, error) {
+	opts.setDefaults()
+
+	ids := make([]string, 0, len(snap.Nodes))
+	index := make(map[string]int, len(snap.Nodes))
+	for _, n := range snap.Nodes {
+		if _, dup := index[n.ID]; !dup {
+			index[n.ID] = -1
+			ids = append(ids, n.ID)
+		}
+	}
+	sort.Strings(ids)
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	weights := make([]map[int]float64, len(ids))
+	for _, e := range snap.Edges {
+		a, okA := index[e.From]
+		b, okB := index[e.To]
+		if !okA || !okB || a == b || e.Weight <= 0 {
+			continue
+		}
+		for _, p := range [][2]int{{a, b}, {b, a}} {
+			if weights[p[0]] == nil {
+				weights[p[0]] = make(map[int]float64)
+			}
+			weights[p[0]][p[1]] += e.Weight
+		}
+	}
+
+	adj := make([]neighbors, len(ids))
+	degree := make([]float64, len(ids))
+	var nodes []int
+	for i, w := range weights {
+		if len(w) == 0 {
+			continue
+		}
+		nodes = append(nodes, i)
+		to := make([]int, 0, len(w))
+		for j := range w {
+			to = append(to, j)
+		}
+		sort.Ints(to)
+		sum := 0.0
+		for _, j := range to {
+			sum += w[j]
+			adj[i].to = append(adj[i].to, j)
+			adj[i].cum = append(adj[i].cum, sum)
+		}
+		degree[i] = sum
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("snapshot has no edges to train on")
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	in := make([][]float32, len(ids))
+	out := make([][]float32, len(ids))
+	for _, i := range nodes {
+		in[i] = make([]float32, opts.Dim)
+		out[i] = make([]float32, opts.Dim)
+		for d := range in[i] {
+			in[i][d] = (rng.Float32() - 0.5) / float32(opts.Dim)
+		}
+	}
+
+	t := &trainer{opts: opts, rng: rng, in: in, out: out, noise: noiseTable(nodes, degree), grad: make([]float32, opts.Dim)}
+	steps := float64(opts.WalksPerNode * len(nodes))
+	walk := make([]int, 0, opts.WalkLength)
+	order := append([]int(nil), nodes...)
+	done := 0
+	for pass := 0; pass < opts.WalksPerNode; pass++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		for _, start := range order {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			walk = append(walk[:0], start)
+			for len(walk) < opts.WalkLength {
+				nb := adj[walk[len(walk)-1]]
+				r := rng.Float64() * nb.cum[len(nb.cum)-1]
+				walk = append(walk, nb.to[sort.SearchFloat64s(nb.cum, r)])
+			}
+
+			t.lr = float32(opts.LearningRate * math.Max(1-float64(done)/steps, 1e-4))
+			t.train(walk)
+			done++
+		}
+	}
+
+	v := &Vectors{Dim: opts.Dim}
+	for _, i := range nodes {
+		normalize(in[i])
+		v.add(ids[i], in[i])
+	}
+	return v, nil
+}
+
+// trainer holds the skip-gram parameters: in are the node vectors and out
+// the context vectors
+type trainer struct {
+	opts  Options
+	rng   *rand.Rand
+	in    [][]float32
+	out   [][]float32
+	noise []int
+	grad  []float32
+	lr    float32
+}
+
+// train updates the vectors for every (node, context) pair of a walk
+func (t *trainer) train(walk []int) {
+	for i, center := range walk {
+		lo, hi := max(i-t.opts.Window, 0), min(i+t.opts.Window, len(walk)-1)
+		for j := lo; j <= hi; j++ {
+			if j == i {
+				continue
+			}
+			for d := range t.grad {
+				t.grad[d] = 0
+			}
+			t.update(center, walk[j], 1)
+			for k := 0; k < t.opts.Negative; k++ {
+				if neg := t.noise[t.rng.Intn(len(t.noise))]; neg != walk[j] {
+					t.update(center, neg, 0)
+				}
+			}
+			v := t.in[center]
+			for d := range v {
+				v[d] += t.grad[d]
+			}
+		}
+	}
+}
+
+// update applies one logistic regression step for a node and a context
+// node labeled 1 (observed) or 0 (negative sample)
+func (t *trainer) update(node, ctx int, label float32) {
+	v, c := t.in[node], t.out[ctx]
+	var dot float32
+	for d := range v {
+		dot += v[d] * c[d]
+	}
+	g := (label - sigmoid(dot)) * t.lr
+	for d := range v {
+		t.grad[d] += g * c[d]
+		c[d] += g * v[d]
+	}
+}
+
+// noiseTable lists nodes in proportion to their weighted degree raised to
+// 0.75, as in word2vec, so negatives are drawn in constant time
+func noiseTable(nodes []int, degree []float64) []int {
+	size := min(100*len(nodes), 1<<24)
+	total := 0.0
+	for _, i := range nodes {
+		total += math.Pow(degree[i], 0.75)
+	}
+
+	table := make([]int, 0, size+len(nodes))
+	cum := 0.0
+	for _, i := range nodes {
+		cum += math.Pow(degree[i], 0.75)
+		// every node gets at least one slot
+		for n := max(int(cum/total*float64(size)), len(table)+1); len(table) < n; {
+			table = append(table, i)
+		}
+	}
+	return table
+}
+
+const (
+	sigmoidBound = 6
+	sigmoidSteps = 1000
+)
+
+// sigmoidTable holds 1/(1+e^-x) for x in [-sigmoidBound, sigmoidBound]
+var sigmoidTable = func() [sigmoidSteps + 1]float32 {
+	var t [sigmoidSteps + 1]float32
+	for i := range t {
+		x := (float64(i)/sigmoidSteps*2 - 1) * sigmoidBound
+		t[i] = float32(1 / (1 + math.Exp(-x)))
+	}
+	return t
+}()
+
+func sigmoid(x float32) float32 {
+	switch {
+	case x >= sigmoidBound:
+		return 1
+	case x <= -sigmoidBound:
+		return 0
+	}
+	return sigmoidTable[int((x+sigmoidBound)/(2*sigmoidBound)*sigmoidSteps)]
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+}
@@ -0,0 +1,107 @@
+package embed
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// clusters has two triangles joined by one weak edge, and an isolated node
+func clusters() *mantr.Snapshot {
+	return &mantr.Snapshot{
+		Pod: "dharma",
+		Nodes: []mantr.Node{
+			{ID: "karma"}, {ID: "samsara"}, {ID: "rebirth"},
+			{ID: "asana"}, {ID: "prana"}, {ID: "breath"},
+			{ID: "ahimsa"},
+		},
+		Edges: []mantr.Edge{
+			{From: "karma", To: "samsara", Weight: 1},
+			{From: "samsara", To: "rebirth", Weight: 1},
+			{From: "rebirth", To: "karma", Weight: 1},
+			{From: "asana", To: "prana", Weight: 1},
+			{From: "prana", To: "breath", Weight: 1},
+			{From: "breath", To: "asana", Weight: 1},
+			{From: "karma", To: "asana", Weight: 0.05},
+		},
+	}
+}
+
+var testOptions = Options{Dim: 16, WalksPerNode: 20, WalkLength: 20, Seed: 7}
+
+func TestTrain(t *testing.T) {
+	v, err := Train(context.Background(), clusters(), testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"asana", "breath", "karma", "prana", "rebirth", "samsara"}; !reflect.DeepEqual(v.IDs(), want) {
+		t.Errorf("IDs = %v, want %v", v.IDs(), want)
+	}
+	for _, id := range v.IDs() {
+		vec, _ := v.Vector(id)
+		if n := math.Sqrt(dot(vec, vec)); math.Abs(n-1) > 1e-5 {
+			t.Errorf("%s has norm %v", id, n)
+		}
+	}
+
+	// each node's nearest neighbors are the rest of its triangle
+	for id, want := range map[string][]string{"samsara": {"karma", "rebirth"}, "prana": {"asana", "breath"}} {
+		var got []string
+		for _, n := range v.Nearest(id, 2) {
+			got = append(got, n.ID)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("nearest to %s = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestTrainDeterministic(t *testing.T) {
+	ctx := context.Background()
+	a, err := Train(ctx, clusters(), testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the order of nodes and edges in the snapshot does not matter
+	snap := clusters()
+	for i, j := 0, len(snap.Nodes)-1; i < j; i, j = i+1, j-1 {
+		snap.Nodes[i], snap.Nodes[j] = snap.Nodes[j], snap.Nodes[i]
+	}
+	for i, j := 0, len(snap.Edges)-1; i < j; i, j = i+1, j-1 {
+		snap.Edges[i], snap.Edges[j] = snap.Edges[j], snap.Edges[i]
+	}
+	b, err := Train(ctx, snap, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("training with the same seed yielded different vectors")
+	}
+
+	opts := testOptions
+	opts.Seed++
+	c, err := Train(ctx, clusters(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(a.vecs, c.vecs) {
+		t.Error("training with another seed yielded the same vectors")
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	if _, err := Train(context.Background(), &mantr.Snapshot{Nodes: []mantr.Node{{ID: "karma"}}}, Options{}); err == nil {
+		t.Error("training without edges succeeded")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Train(ctx, clusters(), testOptions); err != context.Canceled {
+		t.Errorf("canceled training = %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// EdgeTypeSimilar is the edge type of paths returned by a Searcher
+const EdgeTypeSimilar = "similar"
+
+// Searcher answers walk requests from embeddings, so vector lookups can be
+// used wherever a mantr.Walker is accepted: retrieval pipelines, pod
+// routing with mantr.PodWalkers, or next to API walks for hybrid retrieval.
+// Each result is a one-hop path from the seed closest to a similar node,
+// scored by cosine similarity to the mean of the seed vectors. Depth and the
+// walk mode are ignored.
+type Searcher struct {
+	vecs *Vectors
+	pod  string
+	// resolve maps a phoneme to node IDs
+	resolve func(string) []string
+}
+
+// NewSearcher creates a searcher. When snap is given, phonemes are resolved
+// through its labels and aliases as well as node IDs, and requests for
+// other pods are rejected.
+func NewSearcher(vecs *Vectors, snap *mantr.Snapshot) *Searcher {
+	s := &Searcher{vecs: vecs}
+	if snap != nil {
+		s.pod = snap.Pod
+		s.resolve = mantr.NewOfflineWalker(snap).Resolve
+		return s
+	}
+
+	byName := make(map[string][]string)
+	for _, id := range vecs.ids {
+		key := mantr.Normalize(id)
+		byName[key] = append(byName[key], id)
+	}
+	s.resolve = func(p string) []string { return byName[mantr.Normalize(p)] }
+	return s
+}
+
+// WalkContext returns the nodes nearest to the request's phonemes
+func (s *Searcher) WalkContext(ctx context.Context, req *mantr.WalkRequest) (*mantr.WalkResponse, error) {
+	if len(req.Phonemes) == 0 {
+		return nil, fmt.Errorf("phonemes cannot be empty")
+	}
+	if req.Pod != "" && s.pod != "" && req.Pod != s.pod {
+		return nil, fmt.Errorf("searcher serves pod %q, not %q", s.pod, req.Pod)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	limit := req.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	var seeds []string
+	query := make([]float32, s.vecs.Dim)
+	for _, p := range req.Phonemes {
+		for _, id := range s.resolve(p) {
+			vec, ok := s.vecs.Vector(id)
+			if !ok || contains(seeds, id) {
+				continue
+			}
+			seeds = append(seeds, id)
+			for d, x := range vec {
+				query[d] += x
+			}
+		}
+	}
+	resp := &mantr.WalkResponse{Paths: []mantr.PathResult{}}
+	if len(seeds) == 0 {
+		return resp, nil
+	}
+	normalize(query)
+
+	for _, n := range s.vecs.Search(query, limit, seeds...) {
+		from, best := seeds[0], -2.0
+		for _, id := range seeds {
+			if sim, _ := s.vecs.Similarity(id, n.ID); sim > best {
+				from, best = id, sim
+			}
+		}
+		resp.Paths = append(resp.Paths, mantr.PathResult{
+			Nodes:     []string{from, n.ID},
+			Score:     n.Score,
+			Depth:     1,
+			EdgeTypes: []string{EdgeTypeSimilar},
+		})
+	}
+	resp.LatencyUS = int(time.Since(start).Microseconds())
+	return resp, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package embed
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func TestSearcher(t *testing.T) {
+	ctx := context.Background()
+	s := NewSearcher(testVectors(), nil)
+
+	resp, err := s.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"Karma"}, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mantr.PathResult{{Nodes: []string{"karma", "samsara"}, Score: 0.800000011920929, Depth: 1, EdgeTypes: []string{EdgeTypeSimilar}}}
+	if !reflect.DeepEqual(resp.Paths, want) {
+		t.Errorf("paths = %+v, want %+v", resp.Paths, want)
+	}
+
+	// each result starts at the seed closest to it
+	resp, err = s.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"karma", "prana"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Paths) != 1 || !reflect.DeepEqual(resp.Paths[0].Nodes, []string{"karma", "samsara"}) {
+		t.Errorf("paths = %+v", resp.Paths)
+	}
+
+	resp, err = s.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"moksa"}})
+	if err != nil || len(resp.Paths) != 0 {
+		t.Errorf("walk from an unknown node = %+v, %v", resp, err)
+	}
+}
+
+func TestSearcherSnapshot(t *testing.T) {
+	snap := &mantr.Snapshot{Pod: "dharma", Nodes: []mantr.Node{{ID: "karma", Aliases: []string{"deed"}}}}
+	s := NewSearcher(testVectors(), snap)
+	ctx := context.Background()
+
+	resp, err := s.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"deed"}, Pod: "dharma", Limit: 1})
+	if err != nil || len(resp.Paths) != 1 || resp.Paths[0].Nodes[0] != "karma" {
+		t.Errorf("walk by alias = %+v, %v", resp, err)
+	}
+	if _, err := s.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"karma"}, Pod: "yoga"}); err == nil {
+		t.Error("walk on another pod succeeded")
+	}
+	if _, err := s.WalkContext(ctx, &mantr.WalkRequest{}); err == nil {
+		t.Error("walk without phonemes succeeded")
+	}
+}
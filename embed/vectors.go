@@ -0,0 +1,171 @@
+package embed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Vectors holds unit-length node embeddings
+type Vectors struct {
+	Dim   int
+	ids   []string
+	vecs  [][]float32
+	index map[string]int
+}
+
+// Neighbor is a node and its cosine similarity to a query
+type Neighbor struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+func (v *Vectors) add(id string, vec []float32) {
+	if v.index == nil {
+		v.index = make(map[string]int)
+	}
+	v.index[id] = len(v.ids)
+	v.ids = append(v.ids, id)
+	v.vecs = append(v.vecs, vec)
+}
+
+// Len returns the number of vectors
+func (v *Vectors) Len() int {
+	return len(v.ids)
+}
+
+// IDs returns the node IDs with a vector, in sorted order
+func (v *Vectors) IDs() []string {
+	return append([]string(nil), v.ids...)
+}
+
+// Vector returns the embedding of a node
+func (v *Vectors) Vector(id string) ([]float32, bool) {
+	i, ok := v.index[id]
+	if !ok {
+		return nil, false
+	}
+	return v.vecs[i], true
+}
+
+// Similarity returns the cosine similarity of two nodes
+func (v *Vectors) Similarity(a, b string) (float64, bool) {
+	va, okA := v.Vector(a)
+	vb, okB := v.Vector(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return dot(va, vb), true
+}
+
+// Nearest returns the k nodes most similar to id, excluding id itself
+func (v *Vectors) Nearest(id string, k int) []Neighbor {
+	vec, ok := v.Vector(id)
+	if !ok {
+		return nil
+	}
+	return v.Search(vec, k, id)
+}
+
+// Search returns the k nodes most similar to a query vector, skipping the
+// excluded IDs. The query need not be unit length; scores are then scaled
+// by its norm. Search compares against every vector.
+func (v *Vectors) Search(query []float32, k int, exclude ...string) []Neighbor {
+	if len(query) != v.Dim || k <= 0 {
+		return nil
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		skip[id] = true
+	}
+
+	var out []Neighbor
+	for i, vec := range v.vecs {
+		if skip[v.ids[i]] {
+			continue
+		}
+		out = append(out, Neighbor{ID: v.ids[i], Score: dot(query, vec)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+// vectorsFile is the JSON encoding of Vectors
+type vectorsFile struct {
+	Dim     int           `json:"dim"`
+	Vectors []vectorEntry `json:"vectors"`
+}
+
+type vectorEntry struct {
+	ID     string    `json:"id"`
+	Vector []float32 `json:"vector"`
+}
+
+// Write encodes the vectors as JSON
+func (v *Vectors) Write(w io.Writer) error {
+	f := vectorsFile{Dim: v.Dim, Vectors: make([]vectorEntry, len(v.ids))}
+	for i, id := range v.ids {
+		f.Vectors[i] = vectorEntry{ID: id, Vector: v.vecs[i]}
+	}
+	return json.NewEncoder(w).Encode(f)
+}
+
+// Save writes the vectors to a file
+func (v *Vectors) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := v.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadVectors decodes vectors written by Write
+func ReadVectors(r io.Reader) (*Vectors, error) {
+	var f vectorsFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode vectors: %w", err)
+	}
+
+	v := &Vectors{Dim: f.Dim}
+	for _, e := range f.Vectors {
+		if len(e.Vector) != f.Dim {
+			return nil, fmt.Errorf("vector for %q has %d dimensions, want %d", e.ID, len(e.Vector), f.Dim)
+		}
+		v.add(e.ID, e.Vector)
+	}
+	return v, nil
+}
+
+// LoadVectors reads vectors from a file
+func LoadVectors(path string) (*Vectors, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadVectors(f)
+}
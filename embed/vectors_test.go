@@ -0,0 +1,70 @@
+package embed
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testVectors places karma and samsara close together and prana apart
+func testVectors() *Vectors {
+	v := &Vectors{Dim: 2}
+	v.add("karma", []float32{1, 0})
+	v.add("samsara", []float32{0.8, 0.6})
+	v.add("prana", []float32{0, 1})
+	return v
+}
+
+func TestSearch(t *testing.T) {
+	v := testVectors()
+	tests := []struct {
+		name    string
+		query   []float32
+		k       int
+		exclude []string
+		want    []Neighbor
+	}{
+		{"all", []float32{1, 0}, 5, nil, []Neighbor{{"karma", 1}, {"samsara", 0.800000011920929}, {"prana", 0}}},
+		{"limited", []float32{1, 0}, 1, nil, []Neighbor{{"karma", 1}}},
+		{"excluded", []float32{1, 0}, 1, []string{"karma"}, []Neighbor{{"samsara", 0.800000011920929}}},
+		{"scaled", []float32{0, 2}, 1, nil, []Neighbor{{"prana", 2}}},
+		{"wrong size", []float32{1}, 1, nil, nil},
+		{"k zero", []float32{1, 0}, 0, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Search(tt.query, tt.k, tt.exclude...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := v.Nearest("prana", 1); len(got) != 1 || got[0].ID != "samsara" {
+		t.Errorf("Nearest = %v", got)
+	}
+	if got := v.Nearest("moksa", 1); got != nil {
+		t.Errorf("Nearest of an unknown node = %v", got)
+	}
+	if _, ok := v.Similarity("karma", "moksa"); ok {
+		t.Error("Similarity with an unknown node succeeded")
+	}
+}
+
+func TestVectorsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	if err := testVectors().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadVectors(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, testVectors()) {
+		t.Errorf("loaded %+v, want %+v", got, testVectors())
+	}
+
+	if _, err := ReadVectors(strings.NewReader(`{"dim": 2, "vectors": [{"id": "karma", "vector": [1]}]}`)); err == nil {
+		t.Error("vector of the wrong size accepted")
+	}
+}
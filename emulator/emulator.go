@@ -0,0 +1,174 @@
+// Package emulator serves the read-only parts of the Mantr API from local
+// snapshots, so agents and tests can use a real Client without network
+// access or credits. Walks, ranks and subgraphs are answered by
+// mantr.OfflineWalker; endpoints the emulator does not implement respond with
+// 501 Not Implemented.
+package emulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Emulator is an http.Handler serving snapshots as pods
+type Emulator struct {
+	pods    map[string]*pod
+	names   []string
+	Default string
+}
+
+type pod struct {
+	snap   *mantr.Snapshot
+	walker *mantr.OfflineWalker
+}
+
+// New creates an emulator serving each snapshot as the pod it names. The
+// first snapshot is the default pod for requests that do not name one.
+func New(snaps ...*mantr.Snapshot) *Emulator {
+	e := &Emulator{pods: make(map[string]*pod)}
+	for _, s := range snaps {
+		e.pods[s.Pod] = &pod{snap: s, walker: mantr.NewOfflineWalker(s)}
+		e.names = append(e.names, s.Pod)
+	}
+	if len(snaps) > 0 {
+		e.Default = snaps[0].Pod
+	}
+	return e
+}
+
+// NewServer starts an emulator on a local test server. Point a client at it
+// with mantr.WithBaseURL(server.URL) and close the server when done.
+func NewServer(snaps ...*mantr.Snapshot) *httptest.Server {
+	return httptest.NewServer(New(snaps...))
+}
+
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "walk" && r.Method == http.MethodPost:
+		var req mantr.WalkRequest
+		if p := e.decode(w, r, &req, &req.Pod); p != nil {
+			e.reply(w)(p.walker.WalkContext(r.Context(), &req))
+		}
+
+	case len(parts) == 1 && parts[0] == "rank" && r.Method == http.MethodPost:
+		var req mantr.RankRequest
+		if p := e.decode(w, r, &req, &req.Pod); p != nil {
+			e.reply(w)(p.walker.Rank(r.Context(), &req))
+		}
+
+	case len(parts) == 1 && parts[0] == "subgraph" && r.Method == http.MethodPost:
+		var req mantr.SubgraphRequest
+		if p := e.decode(w, r, &req, &req.Pod); p != nil {
+			e.subgraph(w, r, p, &req)
+		}
+
+	case len(parts) == 1 && parts[0] == "pods" && r.Method == http.MethodGet:
+		pods := make([]mantr.Pod, 0, len(e.names))
+		for _, name := range e.names {
+			pods = append(pods, e.pods[name].meta())
+		}
+		writeJSON(w, map[string]interface{}{"pods": pods})
+
+	case len(parts) == 2 && parts[0] == "pods" && r.Method == http.MethodGet:
+		if p := e.lookup(w, parts[1]); p != nil {
+			writeJSON(w, p.meta())
+		}
+
+	case len(parts) == 3 && parts[0] == "pods" && parts[2] == "snapshot" && r.Method == http.MethodGet:
+		if p := e.lookup(w, parts[1]); p != nil {
+			writeJSON(w, p.snap)
+		}
+
+	default:
+		http.Error(w, "not implemented by emulator", http.StatusNotImplemented)
+	}
+}
+
+// decode reads a JSON request body and returns the pod it names, or the
+// default pod. It writes an error response and returns nil on failure.
+func (e *Emulator) decode(w http.ResponseWriter, r *http.Request, v interface{}, name *string) *pod {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return nil
+	}
+	if *name == "" {
+		*name = e.Default
+	}
+	return e.lookup(w, *name)
+}
+
+func (e *Emulator) lookup(w http.ResponseWriter, name string) *pod {
+	p, ok := e.pods[name]
+	if !ok {
+		http.Error(w, "pod not found", http.StatusNotFound)
+		return nil
+	}
+	return p
+}
+
+// reply writes a walker result, mapping errors to 400 Bad Request
+func (e *Emulator) reply(w http.ResponseWriter) func(interface{}, error) {
+	return func(v interface{}, err error) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, v)
+	}
+}
+
+// subgraph serves one page of a subgraph. The cursor is the offset of the
+// page in the subgraph's nodes followed by its edges.
+func (e *Emulator) subgraph(w http.ResponseWriter, r *http.Request, p *pod, req *mantr.SubgraphRequest) {
+	sub, err := p.walker.Subgraph(r.Context(), req.Seeds, req.Radius, req.MaxNodes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if req.Cursor != "" {
+		if offset, err = strconv.Atoi(req.Cursor); err != nil || offset < 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	total := len(sub.Nodes) + len(sub.Edges)
+	end := total
+	if req.PageSize > 0 {
+		end = min(offset+req.PageSize, total)
+	}
+
+	page := &mantr.SubgraphPage{Nodes: []mantr.Node{}, Edges: []mantr.Edge{}, Version: sub.Version}
+	for i := offset; i < end; i++ {
+		if i < len(sub.Nodes) {
+			page.Nodes = append(page.Nodes, sub.Nodes[i])
+		} else {
+			page.Edges = append(page.Edges, sub.Edges[i-len(sub.Nodes)])
+		}
+	}
+	if end < total {
+		page.NextCursor = strconv.Itoa(end)
+	}
+	writeJSON(w, page)
+}
+
+func (p *pod) meta() mantr.Pod {
+	return mantr.Pod{Name: p.snap.Pod, Nodes: len(p.snap.Nodes), Edges: len(p.snap.Edges), Version: p.snap.Version}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,163 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func snapshots() []*mantr.Snapshot {
+	return []*mantr.Snapshot{
+		{
+			Pod:     "dharma",
+			Version: 2,
+			Nodes:   []mantr.Node{{ID: "karma"}, {ID: "dharma"}, {ID: "moksa"}, {ID: "samsara"}},
+			Edges: []mantr.Edge{
+				{From: "karma", To: "samsara", Weight: 0.9},
+				{From: "dharma", To: "karma", Weight: 0.7},
+				{From: "dharma", To: "moksa", Weight: 0.5},
+			},
+		},
+		{
+			Pod:   "yoga",
+			Nodes: []mantr.Node{{ID: "asana"}, {ID: "prana"}},
+			Edges: []mantr.Edge{{From: "asana", To: "prana", Weight: 1}},
+		},
+	}
+}
+
+func newTestClient(t *testing.T) *mantr.Client {
+	t.Helper()
+	server := NewServer(snapshots()...)
+	t.Cleanup(server.Close)
+	client, err := mantr.NewClient("vak_test", mantr.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestEmulatorMatchesOfflineWalker(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	local := mantr.NewOfflineWalker(snapshots()[0])
+
+	req := mantr.WalkRequest{Phonemes: []string{"karma"}, Depth: 2}
+	remote, err := client.WalkContext(ctx, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := local.WalkContext(ctx, &req)
+	if !reflect.DeepEqual(remote.Paths, want.Paths) {
+		t.Errorf("walk = %+v, want %+v", remote.Paths, want.Paths)
+	}
+
+	ranked, err := client.Rank(ctx, &mantr.RankRequest{Phonemes: []string{"dharma"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRank, _ := local.Rank(ctx, &mantr.RankRequest{Phonemes: []string{"dharma"}})
+	if !reflect.DeepEqual(ranked.Nodes, wantRank.Nodes) {
+		t.Errorf("rank = %+v, want %+v", ranked.Nodes, wantRank.Nodes)
+	}
+
+	// other pods are served by name
+	yoga, err := client.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"asana"}, Pod: "yoga"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(yoga.Paths) != 1 || !reflect.DeepEqual(yoga.Paths[0].Nodes, []string{"asana", "prana"}) {
+		t.Errorf("yoga walk = %+v", yoga.Paths)
+	}
+}
+
+func TestEmulatorSubgraphPages(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	req := &mantr.SubgraphRequest{Seeds: []string{"karma"}, Radius: 2, PageSize: 2}
+	first, err := client.SubgraphPage(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Nodes)+len(first.Edges) != 2 || first.NextCursor != "2" {
+		t.Errorf("first page = %+v", first)
+	}
+
+	sub, err := client.ExportSubgraph(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := mantr.NewOfflineWalker(snapshots()[0]).Subgraph(ctx, []string{"karma"}, 2, 0)
+	if !reflect.DeepEqual(sub.Nodes, want.Nodes) || !reflect.DeepEqual(sub.Edges, want.Edges) || sub.Version != 2 {
+		t.Errorf("subgraph = %+v, want %+v", sub, want)
+	}
+}
+
+func TestEmulatorPods(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	pods, err := client.ListPods(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 2 || pods[0].Name != "dharma" || pods[0].Nodes != 4 || pods[0].Edges != 3 || pods[1].Name != "yoga" {
+		t.Errorf("pods = %+v", pods)
+	}
+	pod, err := client.GetPod(ctx, "yoga")
+	if err != nil || pod.Nodes != 2 {
+		t.Errorf("GetPod = %+v, %v", pod, err)
+	}
+	snap, err := client.ExportSnapshot(ctx, "dharma")
+	if err != nil || snap.Digest() != snapshots()[0].Digest() {
+		t.Errorf("ExportSnapshot = %+v, %v", snap, err)
+	}
+}
+
+func TestEmulatorErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	if _, err := client.GetPod(ctx, "missing"); !errors.Is(err, mantr.ErrNotFound) {
+		t.Errorf("GetPod of a missing pod = %v", err)
+	}
+	if _, err := client.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"karma"}, Pod: "missing"}); !errors.Is(err, mantr.ErrNotFound) {
+		t.Errorf("walk on a missing pod = %v", err)
+	}
+	if _, err := client.Ingest(ctx, "dharma", &mantr.IngestRequest{Nodes: []mantr.Node{{ID: "ahimsa"}}}); !errors.Is(err, mantr.ErrNotSupported) {
+		t.Errorf("Ingest = %v, want ErrNotSupported", err)
+	}
+	if _, err := client.WalkContext(ctx, &mantr.WalkRequest{Phonemes: []string{"karma"}, Mode: "beam"}); err == nil {
+		t.Error("walk with an unknown mode succeeded")
+	}
+
+	tests := []struct {
+		name   string
+		auth   string
+		body   string
+		status int
+	}{
+		{"missing credentials", "", `{"phonemes": ["karma"]}`, http.StatusUnauthorized},
+		{"invalid body", "Bearer vak_test", `{"phonemes":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/walk", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			New(snapshots()...).ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
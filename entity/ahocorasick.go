@@ -0,0 +1,79 @@
+package entity
+
+// automaton is an Aho-Corasick automaton over word IDs. Names are matched
+// word by word, so a pattern never matches inside a longer word.
+type automaton struct {
+	next []map[int]int
+	fail []int
+	// out lists the patterns ending at each state, including those reached
+	// through failure links
+	out [][]int
+}
+
+func newAutomaton() *automaton {
+	return &automaton{next: []map[int]int{{}}, fail: []int{0}, out: [][]int{nil}}
+}
+
+// add inserts pattern id, spelled as a sequence of word IDs
+func (a *automaton) add(words []int, id int) {
+	s := 0
+	for _, w := range words {
+		t, ok := a.next[s][w]
+		if !ok {
+			t = len(a.next)
+			a.next = append(a.next, map[int]int{})
+			a.fail = append(a.fail, 0)
+			a.out = append(a.out, nil)
+			a.next[s][w] = t
+		}
+		s = t
+	}
+	a.out[s] = append(a.out[s], id)
+}
+
+// build computes failure links breadth first
+func (a *automaton) build() {
+	queue := make([]int, 0, len(a.next))
+	for _, t := range a.next[0] {
+		queue = append(queue, t)
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for w, t := range a.next[s] {
+			f := a.fail[s]
+			for f > 0 {
+				if _, ok := a.next[f][w]; ok {
+					break
+				}
+				f = a.fail[f]
+			}
+			if g, ok := a.next[f][w]; ok && g != t {
+				a.fail[t] = g
+			}
+			a.out[t] = append(a.out[t], a.out[a.fail[t]]...)
+			queue = append(queue, t)
+		}
+	}
+}
+
+// scan calls found for every pattern occurrence in words, with the index of
+// the occurrence's last word. Negative word IDs match nothing.
+func (a *automaton) scan(words []int, found func(id, end int)) {
+	s := 0
+	for i, w := range words {
+		for {
+			if t, ok := a.next[s][w]; ok {
+				s = t
+				break
+			}
+			if s == 0 {
+				break
+			}
+			s = a.fail[s]
+		}
+		for _, id := range a.out[s] {
+			found(id, i)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package entity links mentions of pod nodes in free text, so documents can
+// be tagged with the concepts they discuss
+package entity
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Options configures a Linker
+type Options struct {
+	// MaxEdits is the edit distance allowed for fuzzy word matches
+	// (default 1, negative disables fuzzy matching). Each dictionary word is
+	// indexed under every deletion of up to MaxEdits runes, so memory grows
+	// quickly beyond 2.
+	MaxEdits int
+	// MinFuzzyLength is the shortest word matched fuzzily (default 5)
+	MinFuzzyLength int
+	// Window is how many words on each side of an ambiguous mention are
+	// used to disambiguate it (default 50)
+	Window int
+	// MinConfidence drops spans linked with less confidence
+	MinConfidence float64
+}
+
+// Span is a mention of a node. Start and End are byte offsets into the text.
+// Alternatives lists the other nodes sharing the mentioned name.
+type Span struct {
+	Start        int      `json:"start"`
+	End          int      `json:"end"`
+	Text         string   `json:"text"`
+	Node         string   `json:"node"`
+	Confidence   float64  `json:"confidence"`
+	Match        string   `json:"match"`
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+// pattern is a node name spelled as word IDs, and the nodes it names
+type pattern struct {
+	words []int
+	nodes []string
+}
+
+// Linker finds mentions of the nodes of a snapshot by ID, label and alias.
+// A Linker is safe for concurrent use.
+type Linker struct {
+	opts     Options
+	words    map[string]int
+	translit map[string]int
+	fuzzy    map[string][]int
+	spelling []string
+	patterns []pattern
+	ac       *automaton
+	adj      map[string]map[string]float64
+	maxEdge  float64
+	prior    map[string]float64
+}
+
+// New builds a linker for the nodes of snap
+func New(snap *mantr.Snapshot, opts Options) *Linker {
+	if opts.MaxEdits == 0 {
+		opts.MaxEdits = 1
+	}
+	if opts.MinFuzzyLength <= 0 {
+		opts.MinFuzzyLength = 5
+	}
+	if opts.Window <= 0 {
+		opts.Window = 50
+	}
+
+	l := &Linker{
+		opts:     opts,
+		words:    make(map[string]int),
+		translit: make(map[string]int),
+		fuzzy:    make(map[string][]int),
+		ac:       newAutomaton(),
+		adj:      make(map[string]map[string]float64),
+		prior:    make(map[string]float64),
+	}
+
+	byName := make(map[string]int)
+	addName := func(name, id string) {
+		key := mantr.Normalize(name)
+		if key == "" || (!strings.Contains(key, " ") && mantr.IsStopword(key)) {
+			return
+		}
+		p, ok := byName[key]
+		if !ok {
+			p = len(l.patterns)
+			byName[key] = p
+			var words []int
+			for _, w := range strings.Split(key, " ") {
+				words = append(words, l.word(w))
+			}
+			l.patterns = append(l.patterns, pattern{words: words})
+			l.ac.add(words, p)
+		}
+		if !contains(l.patterns[p].nodes, id) {
+			l.patterns[p].nodes = append(l.patterns[p].nodes, id)
+		}
+	}
+	for _, n := range snap.Nodes {
+		addName(n.ID, n.ID)
+		if n.Label != "" {
+			addName(n.Label, n.ID)
+		}
+		for _, a := range n.Aliases {
+			addName(a, n.ID)
+		}
+	}
+	l.ac.build()
+
+	for _, e := range snap.Edges {
+		if e.Weight <= 0 || e.From == e.To {
+			continue
+		}
+		l.link(e.From, e.To, e.Weight)
+		l.link(e.To, e.From, e.Weight)
+		l.maxEdge = math.Max(l.maxEdge, e.Weight)
+	}
+	// better connected nodes are slightly preferred when context is silent
+	maxDegree := 0
+	for _, nbrs := range l.adj {
+		maxDegree = max(maxDegree, len(nbrs))
+	}
+	for id, nbrs := range l.adj {
+		l.prior[id] = 0.1 * float64(len(nbrs)) / float64(maxDegree)
+	}
+	return l
+}
+
+// word returns the ID of a dictionary word, adding it and its variants
+func (l *Linker) word(w string) int {
+	if id, ok := l.words[w]; ok {
+		return id
+	}
+	id := len(l.spelling)
+	l.words[w] = id
+	l.spelling = append(l.spelling, w)
+
+	if k := translitKey(w); k != "" {
+		if old, ok := l.translit[k]; !ok || w < l.spelling[old] {
+			l.translit[k] = id
+		}
+	}
+	if l.opts.MaxEdits > 0 && utf8.RuneCountInString(w) >= l.opts.MinFuzzyLength {
+		for _, d := range deletes(w, l.opts.MaxEdits) {
+			l.fuzzy[d] = append(l.fuzzy[d], id)
+		}
+	}
+	return id
+}
+
+func (l *Linker) link(a, b string, w float64) {
+	if l.adj[a] == nil {
+		l.adj[a] = make(map[string]float64)
+	}
+	l.adj[a][b] = math.Max(l.adj[a][b], w)
+}
+
+// lookup maps a text word to a dictionary word, trying exact, plural,
+// transliteration and fuzzy matches in that order. It returns -1 when the
+// word is unknown.
+func (l *Linker) lookup(w string) (int, string) {
+	if id, ok := l.words[w]; ok {
+		return id, MatchExact
+	}
+	if mantr.IsStopword(w) {
+		return -1, ""
+	}
+	if s := mantr.Lemma(w); s != w {
+		if id, ok := l.words[s]; ok {
+			return id, MatchPlural
+		}
+	}
+	if id, ok := l.translit[translitKey(w)]; ok {
+		return id, MatchTranslit
+	}
+	if s := mantr.Lemma(w); s != w {
+		if id, ok := l.translit[translitKey(s)]; ok {
+			return id, MatchTranslit
+		}
+	}
+
+	if l.opts.MaxEdits < 0 || utf8.RuneCountInString(w) < l.opts.MinFuzzyLength {
+		return -1, ""
+	}
+	best, bestDist := -1, l.opts.MaxEdits+1
+	for _, d := range deletes(w, l.opts.MaxEdits) {
+		for _, id := range l.fuzzy[d] {
+			dist := editDistance(w, l.spelling[id])
+			if dist < bestDist || (dist == bestDist && best >= 0 && l.spelling[id] < l.spelling[best]) {
+				best, bestDist = id, dist
+			}
+		}
+	}
+	if best < 0 {
+		return -1, ""
+	}
+	return best, MatchFuzzy
+}
+
+// candidate is a pattern occurrence over tokens [start, end]
+type candidate struct {
+	pattern    int
+	start, end int
+	confidence float64
+	match      string
+}
+
+// Link finds the node mentions in text. Overlapping mentions are resolved in
+// favor of the longest, then the most confident. When a name belongs to
+// several nodes, the node best connected to the other nodes mentioned
+// nearby wins, and the confidence reflects how clear the choice was.
+func (l *Linker) Link(text string) []Span {
+	tokens := mantr.Tokenize(text)
+	ids := make([]int, len(tokens))
+	kinds := make([]string, len(tokens))
+	for i, t := range tokens {
+		ids[i], kinds[i] = l.lookup(t.Text)
+	}
+
+	var cands []candidate
+	l.ac.scan(ids, func(p, end int) {
+		c := candidate{pattern: p, start: end - len(l.patterns[p].words) + 1, end: end, confidence: 1, match: MatchExact}
+		for i := c.start; i <= c.end; i++ {
+			if conf := matchConfidence[kinds[i]]; conf < c.confidence {
+				c.confidence, c.match = conf, kinds[i]
+			}
+		}
+		cands = append(cands, c)
+	})
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if la, lb := a.end-a.start, b.end-b.start; la != lb {
+			return la > lb
+		}
+		if a.confidence != b.confidence {
+			return a.confidence > b.confidence
+		}
+		return a.start < b.start
+	})
+	used := make([]bool, len(tokens))
+	var chosen []candidate
+	for _, c := range cands {
+		free := true
+		for i := c.start; i <= c.end; i++ {
+			free = free && !used[i]
+		}
+		if !free {
+			continue
+		}
+		for i := c.start; i <= c.end; i++ {
+			used[i] = true
+		}
+		chosen = append(chosen, c)
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].start < chosen[j].start })
+
+	spans := make([]Span, 0, len(chosen))
+	for _, c := range chosen {
+		node, share, alts := l.disambiguate(c, chosen)
+		conf := c.confidence * share
+		if conf < l.opts.MinConfidence {
+			continue
+		}
+		start := tokens[c.start].Offset
+		end := wordEnd(text, tokens[c.end].Offset)
+		spans = append(spans, Span{
+			Start:        start,
+			End:          end,
+			Text:         text[start:end],
+			Node:         node,
+			Confidence:   math.Round(conf*1e4) / 1e4,
+			Match:        c.match,
+			Alternatives: alts,
+		})
+	}
+	return spans
+}
+
+// disambiguate picks the node a candidate refers to. Each node sharing the
+// name is scored by its edge weights to the unambiguous mentions within the
+// window, plus a small degree prior; the winner's share of the total score
+// is returned with the other nodes.
+func (l *Linker) disambiguate(c candidate, all []candidate) (string, float64, []string) {
+	nodes := l.patterns[c.pattern].nodes
+	if len(nodes) == 1 {
+		return nodes[0], 1, nil
+	}
+
+	scores := make([]float64, len(nodes))
+	total := 0.0
+	for i, id := range nodes {
+		s := l.prior[id]
+		for _, o := range all {
+			other := l.patterns[o.pattern].nodes
+			if o == c || len(other) != 1 || o.end < c.start-l.opts.Window || o.start > c.end+l.opts.Window {
+				continue
+			}
+			if w, ok := l.adj[id][other[0]]; ok {
+				s += w / l.maxEdge
+			}
+		}
+		scores[i] = s
+		total += s
+	}
+
+	best := 0
+	for i := range nodes {
+		if scores[i] > scores[best] || (scores[i] == scores[best] && nodes[i] < nodes[best]) {
+			best = i
+		}
+	}
+	share := 1 / float64(len(nodes))
+	if total > 0 {
+		share = scores[best] / total
+	}
+
+	var alts []string
+	for i, id := range nodes {
+		if i != best {
+			alts = append(alts, id)
+		}
+	}
+	sort.Strings(alts)
+	return nodes[best], share, alts
+}
+
+// wordEnd returns the byte offset just past the word starting at off
+func wordEnd(text string, off int) int {
+	for off < len(text) {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) {
+			break
+		}
+		off += size
+	}
+	return off
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
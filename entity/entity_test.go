@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func testSnapshot() *mantr.Snapshot {
+	return &mantr.Snapshot{
+		Pod: "dharma",
+		Nodes: []mantr.Node{
+			{ID: "karma"},
+			{ID: "karmayoga", Label: "karma yoga"},
+			{ID: "mokṣa"},
+			{ID: "kṛṣṇa"},
+			{ID: "samsara"},
+			{ID: "shore", Aliases: []string{"bank"}},
+			{ID: "lender", Aliases: []string{"bank"}},
+			{ID: "river"},
+			{ID: "money"},
+		},
+		Edges: []mantr.Edge{
+			{From: "karma", To: "samsara", Weight: 1},
+			{From: "shore", To: "river", Weight: 1},
+			{From: "lender", To: "money", Weight: 1},
+		},
+	}
+}
+
+func TestLinkMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		node  string
+		match string
+	}{
+		{"exact", "Karma", "karma", MatchExact},
+		{"plural", "karmas", "karma", MatchPlural},
+		{"translit", "moksha", "mokṣa", MatchTranslit},
+		{"translit vowel", "Krishna", "kṛṣṇa", MatchTranslit},
+		{"fuzzy", "samsra", "samsara", MatchFuzzy},
+		{"longest", "karma yoga", "karmayoga", MatchExact},
+	}
+	l := New(testSnapshot(), Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := l.Link(tt.text)
+			if len(spans) != 1 || spans[0].Node != tt.node || spans[0].Match != tt.match || spans[0].Text != tt.text {
+				t.Errorf("spans = %+v, want %s by %s", spans, tt.node, tt.match)
+			}
+		})
+	}
+}
+
+func TestLinkMaxEdits(t *testing.T) {
+	tests := []struct {
+		maxEdits int
+		text     string
+		want     bool
+	}{
+		{0, "samsra", true},
+		{-1, "samsra", false},
+		{1, "smsra", false},
+		{2, "smsra", true},
+		{2, "samsaar", true},
+		{2, "sxmsxrx", false},
+	}
+	for _, tt := range tests {
+		spans := New(testSnapshot(), Options{MaxEdits: tt.maxEdits}).Link(tt.text)
+		if got := len(spans) == 1 && spans[0].Node == "samsara"; got != tt.want {
+			t.Errorf("MaxEdits %d: %q linked = %v, want %v", tt.maxEdits, tt.text, spans, tt.want)
+		}
+	}
+}
+
+func TestLinkDisambiguate(t *testing.T) {
+	l := New(testSnapshot(), Options{})
+
+	spans := l.Link("We walked along the bank of the river.")
+	want := Span{Start: 20, End: 24, Text: "bank", Node: "shore", Confidence: 0.9167, Match: MatchExact, Alternatives: []string{"lender"}}
+	if len(spans) != 2 || !reflect.DeepEqual(spans[0], want) {
+		t.Errorf("spans = %+v, want %+v first", spans, want)
+	}
+	if spans := l.Link("She took money out of the bank."); spans[1].Node != "lender" {
+		t.Errorf("spans = %+v", spans)
+	}
+	// with no context the choice is a coin toss, reported as such
+	if spans := l.Link("bank"); spans[0].Node != "lender" || spans[0].Confidence != 0.5 {
+		t.Errorf("spans = %+v", spans)
+	}
+	if spans := New(testSnapshot(), Options{MinConfidence: 0.6}).Link("bank"); len(spans) != 0 {
+		t.Errorf("spans below MinConfidence = %+v", spans)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	ix := NewIndex(New(testSnapshot(), Options{}))
+	ix.Add("a", "Karma binds us to samsara. Karma again.")
+	ix.Add("b", "On moksha")
+	ix.Add("c", "Nothing relevant here")
+
+	if hits := ix.Documents("karma", "mokṣa"); len(hits) != 2 || hits[0].ID != "a" || hits[0].Mentions != 2 || hits[1].Score != 0.9 {
+		t.Errorf("Documents = %+v", hits)
+	}
+
+	hits := ix.ForWalk(&mantr.WalkResponse{Paths: []mantr.PathResult{
+		{Nodes: []string{"mokṣa"}, Score: 1},
+		{Nodes: []string{"karma", "samsara"}, Score: 0.2},
+	}})
+	var ids []string
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+		if !sort.StringsAreSorted(h.Nodes) {
+			t.Errorf("hit nodes %v are not sorted", h.Nodes)
+		}
+	}
+	// b's one transliterated mention on the best path outweighs a's three
+	if !reflect.DeepEqual(ids, []string{"b", "a"}) || hits[1].Mentions != 3 {
+		t.Errorf("ForWalk = %+v", hits)
+	}
+}
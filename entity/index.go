@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"sort"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// DocumentHit is a document that mentions the nodes looked up. Score sums
+// the confidence of the matching mentions, weighted by path score when
+// looking up walk results.
+type DocumentHit struct {
+	ID       string   `json:"id"`
+	Score    float64  `json:"score"`
+	Mentions int      `json:"mentions"`
+	Nodes    []string `json:"nodes"`
+}
+
+// Index maps nodes to the documents that mention them, so walk results can
+// lead to documents
+type Index struct {
+	linker *Linker
+	// docs maps a node to the summed mention confidence per document
+	docs   map[string]map[string]float64
+	counts map[string]map[string]int
+}
+
+// NewIndex creates an empty index that links documents with l
+func NewIndex(l *Linker) *Index {
+	return &Index{
+		linker: l,
+		docs:   make(map[string]map[string]float64),
+		counts: make(map[string]map[string]int),
+	}
+}
+
+// Add links a document and indexes its mentions under id
+func (ix *Index) Add(id, text string) []Span {
+	spans := ix.linker.Link(text)
+	for _, s := range spans {
+		if ix.docs[s.Node] == nil {
+			ix.docs[s.Node] = make(map[string]float64)
+			ix.counts[s.Node] = make(map[string]int)
+		}
+		ix.docs[s.Node][id] += s.Confidence
+		ix.counts[s.Node][id]++
+	}
+	return spans
+}
+
+// Documents returns the documents mentioning any of nodes, best first
+func (ix *Index) Documents(nodes ...string) []DocumentHit {
+	weights := make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		weights[n] = 1
+	}
+	return ix.rank(weights)
+}
+
+// ForWalk returns the documents mentioning nodes on the paths of a walk.
+// Each node counts with the score of the best path it appears on.
+func (ix *Index) ForWalk(resp *mantr.WalkResponse) []DocumentHit {
+	weights := make(map[string]float64)
+	for _, p := range resp.Paths {
+		for _, n := range p.Nodes {
+			if p.Score > weights[n] {
+				weights[n] = p.Score
+			}
+		}
+	}
+	return ix.rank(weights)
+}
+
+func (ix *Index) rank(weights map[string]float64) []DocumentHit {
+	hits := make(map[string]*DocumentHit)
+	for node, w := range weights {
+		for doc, conf := range ix.docs[node] {
+			h, ok := hits[doc]
+			if !ok {
+				h = &DocumentHit{ID: doc}
+				hits[doc] = h
+			}
+			h.Score += w * conf
+			h.Mentions += ix.counts[node][doc]
+			h.Nodes = append(h.Nodes, node)
+		}
+	}
+
+	out := make([]DocumentHit, 0, len(hits))
+	for _, h := range hits {
+		sort.Strings(h.Nodes)
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
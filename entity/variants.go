@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"strings"
+)
+
+// Match kinds, from the most to the least reliable
+const (
+	MatchExact    = "exact"
+	MatchPlural   = "plural"
+	MatchTranslit = "translit"
+	MatchFuzzy    = "fuzzy"
+)
+
+// matchConfidence is the confidence of a word matched each way
+var matchConfidence = map[string]float64{
+	MatchExact:    1,
+	MatchPlural:   0.95,
+	MatchTranslit: 0.9,
+	MatchFuzzy:    0.75,
+}
+
+// translitRules rewrite the spellings popular romanizations use for Sanskrit
+// and similar scripts to the letters Normalize leaves after folding IAST, so
+// "moksha", "mokṣa" and "moksa" share a key. Order matters: "sh" must be
+// rewritten before "ri" so "krishna" becomes "krsna".
+var translitRules = strings.NewReplacer(
+	"sh", "s",
+	"aa", "a",
+	"ee", "i",
+	"ii", "i",
+	"oo", "u",
+	"uu", "u",
+	"w", "v",
+)
+
+// translitKey folds a normalized word to a spelling-insensitive key
+func translitKey(word string) string {
+	k := translitRules.Replace(word)
+	k = strings.ReplaceAll(k, "ri", "r")
+	// aspirates such as "bh" and "dh" are often written without the h
+	var b strings.Builder
+	for i := 0; i < len(k); i++ {
+		if k[i] == 'h' && i > 0 && strings.IndexByte("bcdgjkpt", k[i-1]) >= 0 {
+			continue
+		}
+		b.WriteByte(k[i])
+	}
+	return b.String()
+}
+
+// deletes returns word and every distinct string formed by deleting up to n
+// of its runes. Two words within n edits of each other share at least one
+// entry.
+func deletes(word string, n int) []string {
+	seen := map[string]bool{word: true}
+	out := []string{word}
+	level := []string{word}
+	for ; n > 0; n-- {
+		var next []string
+		for _, w := range level {
+			runes := []rune(w)
+			for i := range runes {
+				d := string(runes[:i]) + string(runes[i+1:])
+				if !seen[d] {
+					seen[d] = true
+					next = append(next, d)
+				}
+			}
+		}
+		out = append(out, next...)
+		level = next
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
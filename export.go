@@ -0,0 +1,121 @@
+package mantr
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT encodes the snapshot as a Graphviz digraph. Edge weights are
+// written as mantr_weight, since Graphviz's own weight attribute must be an
+// integer.
+func (s *Snapshot) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	name := s.Pod
+	if name == "" {
+		name = "pod"
+	}
+
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(name))
+	for _, n := range s.Nodes {
+		label := n.Label
+		if label == "" {
+			label = n.ID
+		}
+		fmt.Fprintf(bw, "  %s [label=%s];\n", dotQuote(n.ID), dotQuote(label))
+	}
+	for _, e := range s.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [mantr_weight=%g", dotQuote(e.From), dotQuote(e.To), e.Weight)
+		if e.Type != "" {
+			fmt.Fprintf(bw, ", label=%s", dotQuote(e.Type))
+		}
+		fmt.Fprintln(bw, "];")
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML encodes the snapshot as GraphML with node labels and edge
+// weights and types, for tools such as NetworkX and Gephi
+func (s *Snapshot) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+			{ID: "type", For: "edge", Name: "type", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: s.Pod, EdgeDefault: "directed"},
+	}
+	for _, n := range s.Nodes {
+		gn := graphMLNode{ID: n.ID}
+		if n.Label != "" {
+			gn.Data = append(gn.Data, graphMLData{Key: "label", Value: n.Label})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+	for _, e := range s.Edges {
+		ge := graphMLEdge{Source: e.From, Target: e.To, Data: []graphMLData{{Key: "weight", Value: fmt.Sprint(e.Weight)}}}
+		if e.Type != "" {
+			ge.Data = append(ge.Data, graphMLData{Key: "type", Value: e.Type})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, ge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,58 @@
+package mantr
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	snap := &Snapshot{
+		Pod:   "dharma",
+		Nodes: []Node{{ID: "karma", Label: `the "deed"`}, {ID: "samsara"}},
+		Edges: []Edge{{From: "karma", To: "samsara", Weight: 0.9, Type: "binds"}, {From: "samsara", To: "karma", Weight: 2}},
+	}
+	var b strings.Builder
+	if err := snap.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph "dharma" {
+  "karma" [label="the \"deed\""];
+  "samsara" [label="samsara"];
+  "karma" -> "samsara" [mantr_weight=0.9, label="binds"];
+  "samsara" -> "karma" [mantr_weight=2];
+}
+`
+	if b.String() != want {
+		t.Errorf("DOT =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	snap := testSnapshot()
+	snap.Nodes[0].Label = "Karma"
+	var b strings.Builder
+	if err := snap.WriteGraphML(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header) {
+		t.Errorf("GraphML lacks the XML header:\n%s", b.String())
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Graph.ID != "dharma" || doc.Graph.EdgeDefault != "directed" || len(doc.Graph.Nodes) != 4 {
+		t.Errorf("graph = %+v", doc.Graph)
+	}
+	if want := []graphMLData{{Key: "label", Value: "Karma"}}; !reflect.DeepEqual(doc.Graph.Nodes[0].Data, want) {
+		t.Errorf("karma data = %+v", doc.Graph.Nodes[0].Data)
+	}
+	edge := graphMLEdge{Source: "karma", Target: "samsara", Data: []graphMLData{{Key: "weight", Value: "0.9"}, {Key: "type", Value: "binds"}}}
+	if !reflect.DeepEqual(doc.Graph.Edges[0], edge) {
+		t.Errorf("edge = %+v, want %+v", doc.Graph.Edges[0], edge)
+	}
+}
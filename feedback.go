@@ -0,0 +1,406 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrFeedbackBufferFull indicates the feedback batcher dropped a judgment
+// because its local buffer is full
+var ErrFeedbackBufferFull = errors.New("mantr: feedback buffer full")
+
+// ErrFeedbackClosed indicates the feedback batcher was closed
+var ErrFeedbackClosed = errors.New("mantr: feedback batcher closed")
+
+// Feedback signals
+const (
+	SignalClick      = "click"
+	SignalThumbsUp   = "thumbs_up"
+	SignalThumbsDown = "thumbs_down"
+)
+
+// Feedback is a relevance judgment for one path of a walk response
+type Feedback struct {
+	RequestID string   `json:"request_id"`
+	PathIndex int      `json:"path_index"`
+	Nodes     []string `json:"nodes,omitempty"`
+	Phonemes  []string `json:"phonemes,omitempty"`
+	Signal    string   `json:"signal,omitempty"`
+	// Relevance is the graded judgment; when zero it is derived from Signal
+	// (click 1, thumbs_up 2, thumbs_down -1)
+	Relevance int       `json:"relevance"`
+	Time      time.Time `json:"time"`
+}
+
+// NewFeedback creates a judgment for path i of resp
+func NewFeedback(resp *WalkResponse, i int, signal string) Feedback {
+	fb := Feedback{RequestID: resp.RequestID, PathIndex: i, Signal: signal}
+	if i >= 0 && i < len(resp.Paths) {
+		fb.Nodes = resp.Paths[i].Nodes
+	}
+	return fb
+}
+
+func (fb *Feedback) normalize() error {
+	if fb.RequestID == "" {
+		return fmt.Errorf("feedback request ID cannot be empty")
+	}
+	if fb.Relevance == 0 {
+		switch fb.Signal {
+		case SignalClick:
+			fb.Relevance = 1
+		case SignalThumbsUp:
+			fb.Relevance = 2
+		case SignalThumbsDown:
+			fb.Relevance = -1
+		}
+	}
+	if fb.Time.IsZero() {
+		fb.Time = time.Now()
+	}
+	return nil
+}
+
+// SubmitFeedback sends relevance judgments to the API
+func (c *Client) SubmitFeedback(ctx context.Context, feedback []Feedback) error {
+	for i := range feedback {
+		if err := feedback[i].normalize(); err != nil {
+			return err
+		}
+	}
+
+	body := struct {
+		Feedback []Feedback `json:"feedback"`
+	}{feedback}
+	return c.do(ctx, "POST", "/v1/feedback", body, nil)
+}
+
+// EvalJudgment is one line of the local evaluation harness format: a graded
+// relevance judgment (0 not relevant, higher is better) for a path
+type EvalJudgment struct {
+	RequestID string   `json:"request_id"`
+	Phonemes  []string `json:"phonemes,omitempty"`
+	Nodes     []string `json:"nodes"`
+	Relevance int      `json:"relevance"`
+}
+
+// EvalJudgment converts the feedback into the evaluation harness format
+func (fb Feedback) EvalJudgment() EvalJudgment {
+	return EvalJudgment{
+		RequestID: fb.RequestID,
+		Phonemes:  fb.Phonemes,
+		Nodes:     fb.Nodes,
+		Relevance: max(fb.Relevance, 0),
+	}
+}
+
+// ReadEvalJudgments decodes JSON Lines written by a feedback batcher's eval log
+func ReadEvalJudgments(r io.Reader) ([]EvalJudgment, error) {
+	var out []EvalJudgment
+	dec := json.NewDecoder(r)
+	for {
+		var j EvalJudgment
+		if err := dec.Decode(&j); err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return out, fmt.Errorf("failed to decode judgment: %w", err)
+		}
+		out = append(out, j)
+	}
+}
+
+// FeedbackOptions configures a FeedbackBatcher
+type FeedbackOptions struct {
+	// BatchSize is the number of judgments sent per call (default 50)
+	BatchSize int
+	// FlushInterval is how often buffered judgments are sent (default 5s)
+	FlushInterval time.Duration
+	// BufferSize caps buffered judgments; Add fails beyond it (default 10000)
+	BufferSize int
+	// MaxRetries is how often a failed batch is retried before it is
+	// dropped (default 5). Only rate limiting, server errors and transport
+	// failures are retried.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on each
+	// further attempt (default 1s)
+	RetryBackoff time.Duration
+	// EvalLog, when set, receives every judgment as an EvalJudgment JSON line
+	EvalLog io.Writer
+	// OnDrop is called with batches dropped after exhausting retries
+	OnDrop func(batch []Feedback, err error)
+}
+
+// FeedbackBatcher buffers judgments locally and submits them in the
+// background in batches, retrying failed batches with backoff
+type FeedbackBatcher struct {
+	client *Client
+	opts   FeedbackOptions
+
+	mu      sync.Mutex
+	pending []Feedback
+	closed  bool
+	logMu   sync.Mutex
+
+	// ctx bounds background sends and is cancelled on Close
+	ctx    context.Context
+	cancel context.CancelFunc
+	flush  chan flushRequest
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// flushRequest asks the loop to send everything buffered within ctx
+type flushRequest struct {
+	ctx  context.Context
+	errc chan error
+}
+
+// NewFeedbackBatcher starts a feedback batcher. Call Close to flush and stop it.
+func NewFeedbackBatcher(client *Client, opts FeedbackOptions) *FeedbackBatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 10000
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 5
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = time.Second
+	}
+
+	b := &FeedbackBatcher{
+		client: client,
+		opts:   opts,
+		flush:  make(chan flushRequest),
+		done:   make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	b.wg.Add(1)
+	go b.loop()
+	return b
+}
+
+// Add buffers a judgment for submission. It returns ErrFeedbackClosed once
+// Close has been called.
+func (b *FeedbackBatcher) Add(fb Feedback) error {
+	if err := fb.normalize(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrFeedbackClosed
+	}
+	if len(b.pending) >= b.opts.BufferSize {
+		b.mu.Unlock()
+		return ErrFeedbackBufferFull
+	}
+	b.pending = append(b.pending, fb)
+	b.mu.Unlock()
+
+	if b.opts.EvalLog != nil {
+		line, err := json.Marshal(fb.EvalJudgment())
+		if err != nil {
+			return err
+		}
+		b.logMu.Lock()
+		defer b.logMu.Unlock()
+		if _, err := b.opts.EvalLog.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write eval log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Flush submits everything buffered so far. Cancelling ctx stops retries;
+// judgments not yet sent stay buffered.
+func (b *FeedbackBatcher) Flush(ctx context.Context) error {
+	errc := make(chan error, 1)
+	select {
+	case b.flush <- flushRequest{ctx: ctx, errc: errc}:
+	case <-b.done:
+		return ErrFeedbackClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting judgments, flushes buffered ones within ctx and
+// stops the batcher. Judgments still unsent when ctx ends are discarded.
+func (b *FeedbackBatcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	err := b.Flush(ctx)
+	b.once.Do(func() {
+		close(b.done)
+		b.cancel()
+	})
+	b.wg.Wait()
+	return err
+}
+
+func (b *FeedbackBatcher) loop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.send(b.ctx)
+		case req := <-b.flush:
+			ctx, cancel := context.WithCancel(req.ctx)
+			stop := context.AfterFunc(b.ctx, cancel)
+			req.errc <- b.send(ctx)
+			stop()
+			cancel()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// send submits all pending judgments batch by batch. When ctx ends, the
+// unsent judgments are kept for a later send.
+func (b *FeedbackBatcher) send(ctx context.Context) error {
+	var firstErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b.mu.Lock()
+		n := min(len(b.pending), b.opts.BatchSize)
+		batch := append([]Feedback(nil), b.pending[:n]...)
+		b.pending = b.pending[n:]
+		b.mu.Unlock()
+
+		if len(batch) == 0 {
+			return firstErr
+		}
+		err := b.submit(ctx, batch)
+		if err != nil && ctx.Err() != nil {
+			b.mu.Lock()
+			b.pending = append(batch, b.pending...)
+			b.mu.Unlock()
+			return ctx.Err()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+func (b *FeedbackBatcher) submit(ctx context.Context, batch []Feedback) error {
+	backoff := b.opts.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= b.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		if err = b.client.SubmitFeedback(ctx, batch); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(err) {
+			break
+		}
+	}
+
+	if b.opts.OnDrop != nil {
+		b.opts.OnDrop(batch, err)
+	}
+	return err
+}
+
+// retryable reports whether a failed call may succeed when repeated: it was
+// rate limited, failed on the server or never got a response
+func retryable(err error) bool {
+	var status *statusError
+	var transport *url.Error
+	switch {
+	case errors.Is(err, ErrRateLimit):
+		return true
+	case errors.As(err, &status):
+		return status.code >= 500
+	case errors.As(err, &transport):
+		return true
+	}
+	return false
+}
+
+type feedbackKey struct{}
+
+// Middleware makes the batcher available to handlers through RecordFeedback
+func (b *FeedbackBatcher) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), feedbackKey{}, b)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RecordFeedback buffers a judgment with the batcher installed by Middleware
+func RecordFeedback(r *http.Request, fb Feedback) error {
+	b, ok := r.Context().Value(feedbackKey{}).(*FeedbackBatcher)
+	if !ok {
+		return fmt.Errorf("no feedback batcher in request context")
+	}
+	return b.Add(fb)
+}
+
+// Handler returns an endpoint that accepts a Feedback JSON object, e.g. from
+// a browser's click or thumbs up/down handler, and buffers it
+func (b *FeedbackBatcher) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var fb Feedback
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&fb); err != nil {
+			http.Error(w, "invalid feedback: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch err := b.Add(fb); {
+		case errors.Is(err, ErrFeedbackBufferFull), errors.Is(err, ErrFeedbackClosed):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFeedbackBatcherRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int32
+	}{
+		{"rate limited", http.StatusTooManyRequests, 3},
+		{"server error", http.StatusServiceUnavailable, 3},
+		{"insufficient credits", http.StatusPaymentRequired, 1},
+		{"not found", http.StatusNotFound, 1},
+		{"bad request", http.StatusBadRequest, 1},
+		{"not supported", http.StatusNotImplemented, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := NewClient("vak_test", WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dropped := make(chan error, 1)
+			b := NewFeedbackBatcher(client, FeedbackOptions{
+				FlushInterval: time.Hour,
+				MaxRetries:    2,
+				RetryBackoff:  time.Millisecond,
+				OnDrop:        func(batch []Feedback, err error) { dropped <- err },
+			})
+			defer b.Close(context.Background())
+
+			if err := b.Add(Feedback{RequestID: "r1", Signal: SignalClick}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Flush(context.Background()); err == nil {
+				t.Fatal("Flush succeeded against a failing server")
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+			select {
+			case <-dropped:
+			default:
+				t.Error("OnDrop was not called")
+			}
+		})
+	}
+}
+
+func TestFeedbackBatcherCloseCancelsRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("vak_test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewFeedbackBatcher(client, FeedbackOptions{
+		FlushInterval: time.Hour,
+		MaxRetries:    10,
+		RetryBackoff:  time.Minute,
+	})
+	if err := b.Add(Feedback{RequestID: "r1", Signal: SignalThumbsUp}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %v, want it bounded by its context", elapsed)
+	}
+
+	if err := b.Add(Feedback{RequestID: "r2", Signal: SignalClick}); !errors.Is(err, ErrFeedbackClosed) {
+		t.Errorf("Add after Close = %v, want %v", err, ErrFeedbackClosed)
+	}
+	if err := b.Flush(context.Background()); !errors.Is(err, ErrFeedbackClosed) {
+		t.Errorf("Flush after Close = %v, want %v", err, ErrFeedbackClosed)
+	}
+}
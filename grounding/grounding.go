@@ -0,0 +1,463 @@
+// Package grounding checks generated answers against the walk results they
+// were given, flagging relationships the context does not support
+package grounding
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Support levels
+const (
+	// Supported means both concepts lie on one walk path
+	Supported = "supported"
+	// Indirect means the concepts are linked only by chaining several paths
+	Indirect = "indirect"
+	// Unsupported means the context does not link the concepts
+	Unsupported = "unsupported"
+)
+
+// Options configures Check
+type Options struct {
+	// MaxHops bounds the length of an indirect chain (default 4)
+	MaxHops int
+	// IndirectPenalty scales the score of indirect support (default 0.5)
+	IndirectPenalty float64
+}
+
+// Mention is a concept found in the text. Start and End are byte offsets.
+type Mention struct {
+	Node      string `json:"node"`
+	Text      string `json:"text"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	InContext bool   `json:"in_context"`
+}
+
+// Claim is a pair of concepts mentioned in one sentence, taken as an
+// asserted connection. Evidence is the chain of nodes linking them in the
+// context, if any.
+type Claim struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Sentence string   `json:"sentence"`
+	Support  string   `json:"support"`
+	Score    float64  `json:"score"`
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// Report is the result of a check. Score is the mean claim score and
+// Grounded the share of claims that are supported directly or indirectly;
+// both are 1 when the text makes no claims.
+type Report struct {
+	Mentions    []Mention `json:"mentions"`
+	Claims      []Claim   `json:"claims"`
+	Score       float64   `json:"score"`
+	Grounded    float64   `json:"grounded"`
+	Unsupported int       `json:"unsupported"`
+}
+
+// Checker finds concept mentions and scores them against walk context
+type Checker struct {
+	lex *lexicon
+}
+
+// lexicon maps normalized names to node IDs. maxLen is the most words in a
+// name.
+type lexicon struct {
+	names  map[string][]string
+	maxLen int
+}
+
+// New creates a checker that recognizes the nodes of snap by ID, label and
+// alias. With a nil snapshot only node IDs appearing in the checked walk
+// response are recognized, so concepts the answer introduces from outside
+// the context go unnoticed.
+func New(snap *mantr.Snapshot) *Checker {
+	lex := &lexicon{names: make(map[string][]string)}
+	if snap != nil {
+		for _, n := range snap.Nodes {
+			lex.add(n.ID, n.ID)
+			if n.Label != "" {
+				lex.add(n.Label, n.ID)
+			}
+			for _, a := range n.Aliases {
+				lex.add(a, n.ID)
+			}
+		}
+	}
+	return &Checker{lex: lex}
+}
+
+func (lex *lexicon) add(name, id string) {
+	key := mantr.Normalize(name)
+	if key == "" {
+		return
+	}
+	for _, existing := range lex.names[key] {
+		if existing == id {
+			return
+		}
+	}
+	lex.names[key] = append(lex.names[key], id)
+	lex.maxLen = max(lex.maxLen, strings.Count(key, " ")+1)
+}
+
+// scope looks names up among the node IDs of a walk response first and then
+// in the shared lexicon, which is never modified
+type scope struct {
+	local, shared *lexicon
+}
+
+func newScope(shared *lexicon, ids map[string]bool) scope {
+	local := &lexicon{names: make(map[string][]string, len(ids))}
+	for id := range ids {
+		local.add(id, id)
+	}
+	return scope{local: local, shared: shared}
+}
+
+// lookup returns the nodes named key in either lexicon, allocating only when
+// both know the name
+func (s scope) lookup(key string) ([]string, bool) {
+	local, shared := s.local.names[key], s.shared.names[key]
+	switch {
+	case local == nil:
+		return shared, shared != nil
+	case shared == nil:
+		return local, true
+	}
+	return append(local[:len(local):len(local)], shared...), true
+}
+
+// Check finds the concepts text mentions and classifies every pair mentioned
+// in the same sentence by how well resp supports a connection between them
+func (c *Checker) Check(text string, resp *mantr.WalkResponse, opts Options) *Report {
+	if opts.MaxHops <= 0 {
+		opts.MaxHops = 4
+	}
+	if opts.IndirectPenalty <= 0 {
+		opts.IndirectPenalty = 0.5
+	}
+
+	g := newGraph(resp)
+	// node IDs in the response are recognized even without a snapshot
+	sc := newScope(c.lex, g.nodes)
+
+	r := &Report{Mentions: sc.mentions(text, g), Claims: []Claim{}}
+	if r.Mentions == nil {
+		r.Mentions = []Mention{}
+	}
+
+	seen := make(map[[2]string]bool)
+	for _, s := range sentences(text) {
+		var ids []string
+		for _, m := range r.Mentions {
+			if m.Start >= s[0] && m.End <= s[1] && !contains(ids, m.Node) {
+				ids = append(ids, m.Node)
+			}
+		}
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				a, b := ids[i], ids[j]
+				if b < a {
+					a, b = b, a
+				}
+				if seen[[2]string{a, b}] {
+					continue
+				}
+				seen[[2]string{a, b}] = true
+
+				claim := g.classify(a, b, opts)
+				claim.Sentence = strings.TrimSpace(text[s[0]:s[1]])
+				r.Claims = append(r.Claims, claim)
+			}
+		}
+	}
+
+	r.Score, r.Grounded = 1, 1
+	if len(r.Claims) > 0 {
+		total, grounded := 0.0, 0
+		for _, cl := range r.Claims {
+			total += cl.Score
+			if cl.Support == Unsupported {
+				r.Unsupported++
+			} else {
+				grounded++
+			}
+		}
+		r.Score = total / float64(len(r.Claims))
+		r.Grounded = float64(grounded) / float64(len(r.Claims))
+	}
+	return r
+}
+
+// mentions matches the longest known name at each token, trying a singular
+// form of the last word when the name itself is unknown
+func (s scope) mentions(text string, g *graph) []Mention {
+	tokens := mantr.Tokenize(text)
+	var out []Mention
+	for i := 0; i < len(tokens); {
+		n, ids := s.match(tokens[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+
+		start := tokens[i].Offset
+		end := wordEnd(text, tokens[i+n-1].Offset)
+		id := g.pick(ids)
+		out = append(out, Mention{Node: id, Text: text[start:end], Start: start, End: end, InContext: g.nodes[id]})
+		i += n
+	}
+	return out
+}
+
+func (s scope) match(tokens []mantr.Token) (int, []string) {
+	for n := min(max(s.local.maxLen, s.shared.maxLen), len(tokens)); n > 0; n-- {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = tokens[i].Text
+		}
+		if n == 1 && mantr.IsStopword(words[0]) {
+			return 0, nil
+		}
+
+		key := strings.Join(words, " ")
+		if ids, ok := s.lookup(key); ok {
+			return n, ids
+		}
+		if one := mantr.Lemma(words[n-1]); one != words[n-1] {
+			words[n-1] = one
+			if ids, ok := s.lookup(strings.Join(words, " ")); ok {
+				return n, ids
+			}
+		}
+	}
+	return 0, nil
+}
+
+// wordEnd returns the byte offset just past the word starting at off
+func wordEnd(text string, off int) int {
+	for off < len(text) {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) {
+			break
+		}
+		off += size
+	}
+	return off
+}
+
+// sentences splits text into [start, end) byte ranges at sentence
+// punctuation and line breaks
+func sentences(text string) [][2]int {
+	var out [][2]int
+	start := 0
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' || r == ';' || r == '\n' {
+			out = append(out, [2]int{start, i})
+			start = i + 1
+		}
+	}
+	return append(out, [2]int{start, len(text)})
+}
+
+// graph is the graph formed by the paths of a walk response. Each link's
+// strength is the best normalized score of a path that follows it.
+type graph struct {
+	paths []mantr.PathResult
+	top   float64
+	nodes map[string]bool
+	links map[string]map[string]float64
+}
+
+func newGraph(resp *mantr.WalkResponse) *graph {
+	g := &graph{nodes: make(map[string]bool), links: make(map[string]map[string]float64)}
+	if resp == nil {
+		return g
+	}
+
+	g.paths = resp.Paths
+	for _, p := range resp.Paths {
+		g.top = math.Max(g.top, p.Score)
+	}
+	for _, p := range resp.Paths {
+		s := g.norm(p.Score)
+		for i, id := range p.Nodes {
+			g.nodes[id] = true
+			if i > 0 {
+				g.link(p.Nodes[i-1], id, s)
+				g.link(id, p.Nodes[i-1], s)
+			}
+		}
+	}
+	return g
+}
+
+func (g *graph) norm(score float64) float64 {
+	if g.top <= 0 {
+		return 1
+	}
+	return score / g.top
+}
+
+func (g *graph) link(a, b string, s float64) {
+	if g.links[a] == nil {
+		g.links[a] = make(map[string]float64)
+	}
+	g.links[a][b] = math.Max(g.links[a][b], s)
+}
+
+// pick chooses among nodes sharing a name, preferring one in the context
+func (g *graph) pick(ids []string) string {
+	best := ""
+	for _, id := range ids {
+		switch {
+		case best == "",
+			g.nodes[id] && !g.nodes[best],
+			g.nodes[id] == g.nodes[best] && id < best:
+			best = id
+		}
+	}
+	return best
+}
+
+func (g *graph) classify(a, b string, opts Options) Claim {
+	claim := Claim{From: a, To: b, Support: Unsupported}
+	if !g.nodes[a] || !g.nodes[b] {
+		return claim
+	}
+
+	for _, p := range g.paths {
+		i, j := indexOf(p.Nodes, a), indexOf(p.Nodes, b)
+		if i < 0 || j < 0 {
+			continue
+		}
+		if s := g.norm(p.Score); claim.Support != Supported || s > claim.Score {
+			claim.Support, claim.Score = Supported, s
+			claim.Evidence = span(p.Nodes, i, j)
+		}
+	}
+	if claim.Support == Supported {
+		return claim
+	}
+
+	if chain, s := g.chain(a, b, opts.MaxHops); chain != nil {
+		claim.Support, claim.Score, claim.Evidence = Indirect, s*opts.IndirectPenalty, chain
+	}
+	return claim
+}
+
+// chain finds the strongest chain of at most maxHops links from a to b,
+// where a chain's strength is the product of its link strengths
+func (g *graph) chain(a, b string, maxHops int) ([]string, float64) {
+	type state struct {
+		node string
+		hops int
+	}
+	best := map[state]float64{{a, 0}: 1}
+	prev := make(map[state]state)
+	pq := &chainQueue{{node: a, strength: 1}}
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(chainItem)
+		cur := state{it.node, it.hops}
+		if it.strength < best[cur] {
+			continue
+		}
+		if it.node == b {
+			var nodes []string
+			for s := cur; ; s = prev[s] {
+				nodes = append(nodes, s.node)
+				if s.hops == 0 {
+					break
+				}
+			}
+			for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+				nodes[i], nodes[j] = nodes[j], nodes[i]
+			}
+			return nodes, it.strength
+		}
+		if it.hops == maxHops {
+			continue
+		}
+		for _, next := range sortedKeys(g.links[it.node]) {
+			s := it.strength * g.links[it.node][next]
+			ns := state{next, it.hops + 1}
+			if s > best[ns] {
+				best[ns], prev[ns] = s, cur
+				heap.Push(pq, chainItem{node: next, hops: it.hops + 1, strength: s})
+			}
+		}
+	}
+	return nil, 0
+}
+
+type chainItem struct {
+	node     string
+	hops     int
+	strength float64
+}
+
+// chainQueue is a max-heap of partial chains by strength
+type chainQueue []chainItem
+
+func (q chainQueue) Len() int { return len(q) }
+
+func (q chainQueue) Less(i, j int) bool {
+	if q[i].strength != q[j].strength {
+		return q[i].strength > q[j].strength
+	}
+	return q[i].node < q[j].node
+}
+
+func (q chainQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *chainQueue) Push(x interface{}) { *q = append(*q, x.(chainItem)) }
+
+func (q *chainQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// span returns the nodes from index i to j inclusive, in that order
+func span(nodes []string, i, j int) []string {
+	var out []string
+	if i <= j {
+		return append(out, nodes[i:j+1]...)
+	}
+	for k := i; k >= j; k-- {
+		out = append(out, nodes[k])
+	}
+	return out
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(list []string, s string) bool {
+	return indexOf(list, s) >= 0
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package grounding
+
+import (
+	"reflect"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func testSnapshot() *mantr.Snapshot {
+	return &mantr.Snapshot{
+		Pod: "dharma",
+		Nodes: []mantr.Node{
+			{ID: "karma", Label: "Karma"},
+			{ID: "dharma"},
+			{ID: "samsara"},
+			{ID: "moksa", Aliases: []string{"moksha", "liberation"}},
+		},
+	}
+}
+
+func testResponse() *mantr.WalkResponse {
+	return &mantr.WalkResponse{Paths: []mantr.PathResult{
+		{Nodes: []string{"karma", "samsara"}, Score: 0.8},
+		{Nodes: []string{"dharma", "karma"}, Score: 0.4},
+	}}
+}
+
+const testAnswer = "Karma binds beings to samsara. Dharma leads to samsara. Dharma leads to moksha."
+
+func TestCheck(t *testing.T) {
+	r := New(testSnapshot()).Check(testAnswer, testResponse(), Options{})
+
+	want := []Claim{
+		{From: "dharma", To: "moksa", Sentence: "Dharma leads to moksha", Support: Unsupported},
+		// dharma-karma is half as strong as karma-samsara, and the chain is
+		// penalized by half again
+		{From: "dharma", To: "samsara", Sentence: "Dharma leads to samsara", Support: Indirect, Score: 0.25, Evidence: []string{"dharma", "karma", "samsara"}},
+		{From: "karma", To: "samsara", Sentence: "Karma binds beings to samsara", Support: Supported, Score: 1, Evidence: []string{"karma", "samsara"}},
+	}
+	byPair := make(map[string]Claim)
+	for _, c := range r.Claims {
+		byPair[c.From+"-"+c.To] = c
+	}
+	for _, w := range want {
+		if got := byPair[w.From+"-"+w.To]; !reflect.DeepEqual(got, w) {
+			t.Errorf("claim %s-%s = %+v, want %+v", w.From, w.To, got, w)
+		}
+	}
+	if len(r.Claims) != len(want) {
+		t.Errorf("%d claims, want %d: %+v", len(r.Claims), len(want), r.Claims)
+	}
+	if r.Score != 1.25/3 || r.Grounded != 2.0/3 || r.Unsupported != 1 {
+		t.Errorf("score %v, grounded %v, unsupported %d", r.Score, r.Grounded, r.Unsupported)
+	}
+
+	last := r.Mentions[len(r.Mentions)-1]
+	if want := (Mention{Node: "moksa", Text: "moksha", Start: 72, End: 78}); last != want {
+		t.Errorf("alias mention = %+v, want %+v", last, want)
+	}
+}
+
+func TestCheckMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"label", "KARMA", []string{"karma"}},
+		{"alias", "liberation", []string{"moksa"}},
+		{"plural", "many samsaras", []string{"samsara"}},
+		{"stopwords", "the and of", nil},
+	}
+	c := New(testSnapshot())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range c.Check(tt.text, testResponse(), Options{}).Mentions {
+				got = append(got, m.Node)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mentions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWithoutSnapshot(t *testing.T) {
+	c := New(nil)
+	r := c.Check(testAnswer, testResponse(), Options{})
+	// moksha is only known from the snapshot
+	if len(r.Claims) != 2 || r.Unsupported != 0 || r.Grounded != 1 {
+		t.Errorf("claims = %+v", r.Claims)
+	}
+
+	// the response's nodes are not remembered by later checks
+	if len(c.lex.names) != 0 {
+		t.Errorf("shared lexicon = %v", c.lex.names)
+	}
+	r = c.Check(testAnswer, nil, Options{})
+	if len(r.Mentions) != 0 || len(r.Claims) != 0 || r.Score != 1 || r.Grounded != 1 {
+		t.Errorf("report without context = %+v", r)
+	}
+}
+
+func TestCheckMaxHops(t *testing.T) {
+	r := New(nil).Check("Dharma leads to samsara.", testResponse(), Options{MaxHops: 1})
+	if len(r.Claims) != 1 || r.Claims[0].Support != Unsupported {
+		t.Errorf("claims = %+v", r.Claims)
+	}
+}
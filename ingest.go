@@ -0,0 +1,106 @@
+package mantr
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DefaultIngestBatchSize is the number of nodes or edges sent per ingestion call
+const DefaultIngestBatchSize = 500
+
+// EdgeKey identifies an edge for deletion
+type EdgeKey struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type,omitempty"`
+}
+
+// IngestRequest is a batch of graph changes for a pod
+type IngestRequest struct {
+	Nodes       []Node    `json:"nodes,omitempty"`
+	Edges       []Edge    `json:"edges,omitempty"`
+	DeleteNodes []string  `json:"delete_nodes,omitempty"`
+	DeleteEdges []EdgeKey `json:"delete_edges,omitempty"`
+}
+
+// IngestResponse represents an ingestion API response
+type IngestResponse struct {
+	NodesUpserted int   `json:"nodes_upserted"`
+	EdgesUpserted int   `json:"edges_upserted"`
+	NodesDeleted  int   `json:"nodes_deleted"`
+	EdgesDeleted  int   `json:"edges_deleted"`
+	Version       int64 `json:"version"`
+	CreditsUsed   int   `json:"credits_used"`
+}
+
+// Ingest upserts and deletes nodes and edges in a pod
+func (c *Client) Ingest(ctx context.Context, pod string, req *IngestRequest) (*IngestResponse, error) {
+	if pod == "" {
+		return nil, fmt.Errorf("pod cannot be empty")
+	}
+
+	var resp IngestResponse
+	if err := c.do(ctx, "POST", "/v1/pods/"+url.PathEscape(pod)+"/ingest", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// IngestSnapshot pushes every node and then every edge of a snapshot into a
+// pod, batchSize items per call
+func (c *Client) IngestSnapshot(ctx context.Context, pod string, snap *Snapshot, batchSize int) (*IngestResponse, error) {
+	return c.IngestBatches(ctx, pod, &IngestRequest{Nodes: snap.Nodes, Edges: snap.Edges}, batchSize)
+}
+
+// IngestBatches applies a large ingestion request as a sequence of calls of
+// at most batchSize items, ordered so that edges never reference missing
+// nodes: edge deletions, node upserts, edge upserts, then node deletions
+func (c *Client) IngestBatches(ctx context.Context, pod string, req *IngestRequest, batchSize int) (*IngestResponse, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultIngestBatchSize
+	}
+
+	total := &IngestResponse{}
+	send := func(what string, i, end int, batch *IngestRequest) error {
+		resp, err := c.Ingest(ctx, pod, batch)
+		if err != nil {
+			return fmt.Errorf("failed to ingest %s %d-%d: %w", what, i, end, err)
+		}
+		total.NodesUpserted += resp.NodesUpserted
+		total.EdgesUpserted += resp.EdgesUpserted
+		total.NodesDeleted += resp.NodesDeleted
+		total.EdgesDeleted += resp.EdgesDeleted
+		total.CreditsUsed += resp.CreditsUsed
+		total.Version = resp.Version
+		return nil
+	}
+
+	for i := 0; i < len(req.DeleteEdges); i += batchSize {
+		end := min(i+batchSize, len(req.DeleteEdges))
+		if err := send("edge deletions", i, end, &IngestRequest{DeleteEdges: req.DeleteEdges[i:end]}); err != nil {
+			return total, err
+		}
+	}
+	for i := 0; i < len(req.Nodes); i += batchSize {
+		end := min(i+batchSize, len(req.Nodes))
+		if err := send("nodes", i, end, &IngestRequest{Nodes: req.Nodes[i:end]}); err != nil {
+			return total, err
+		}
+	}
+	for i := 0; i < len(req.Edges); i += batchSize {
+		end := min(i+batchSize, len(req.Edges))
+		if err := send("edges", i, end, &IngestRequest{Edges: req.Edges[i:end]}); err != nil {
+			return total, err
+		}
+	}
+	for i := 0; i < len(req.DeleteNodes); i += batchSize {
+		end := min(i+batchSize, len(req.DeleteNodes))
+		if err := send("node deletions", i, end, &IngestRequest{DeleteNodes: req.DeleteNodes[i:end]}); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,295 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCreditLimit indicates a call would exceed a credit ceiling
+var ErrCreditLimit = errors.New("mantr: credit limit exceeded")
+
+// CreditLimits configures a CreditLimiter. Ceilings are credits per Window;
+// zero means unlimited.
+type CreditLimits struct {
+	// Window is the metering window (default one minute)
+	Window time.Duration
+	// Global caps all metered calls
+	Global int
+	// PerPod caps calls per pod
+	PerPod map[string]int
+	// PerLabel caps calls per label, set on the context with
+	// WithCreditLabel
+	PerLabel map[string]int
+	// Block makes calls wait for credits to free up instead of failing with
+	// ErrCreditLimit
+	Block bool
+	// Estimate predicts the credits a walk will use (default
+	// EstimateCredits). Other metered calls reserve one credit.
+	Estimate func(req *WalkRequest) int
+}
+
+// CreditLimiter meters credits per time window. Each call reserves its
+// estimated cost before it is sent and the reservation is reconciled with
+// the CreditsUsed the API reports. Calls that cost more than estimated can
+// push usage past a ceiling; later calls then wait or fail until the excess
+// ages out of the window.
+type CreditLimiter struct {
+	limits CreditLimits
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	changed chan struct{}
+	now     func() time.Time
+}
+
+type bucket struct {
+	limit   int
+	entries []*usage
+}
+
+type usage struct {
+	at      time.Time
+	credits int
+}
+
+// NewCreditLimiter creates a credit limiter
+func NewCreditLimiter(limits CreditLimits) *CreditLimiter {
+	if limits.Window <= 0 {
+		limits.Window = time.Minute
+	}
+	if limits.Estimate == nil {
+		limits.Estimate = EstimateCredits
+	}
+	return &CreditLimiter{
+		limits:  limits,
+		buckets: make(map[string]*bucket),
+		changed: make(chan struct{}),
+		now:     time.Now,
+	}
+}
+
+// WithCreditLimiter meters the client's walks, ranks and subgraph pages
+func WithCreditLimiter(l *CreditLimiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+type creditLabelKey struct{}
+
+// WithCreditLabel tags calls made with ctx for per-label credit ceilings
+func WithCreditLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, creditLabelKey{}, label)
+}
+
+// CreditLabel returns the credit label of ctx, if any
+func CreditLabel(ctx context.Context) string {
+	label, _ := ctx.Value(creditLabelKey{}).(string)
+	return label
+}
+
+// EstimateCredits is a rough estimate of a walk's cost. It grows with the
+// search space: the square of the depth times the path limit for top paths,
+// samples times depth for sampling and seeds times depth for connections.
+// Reconciliation corrects the metered usage once the actual cost is known.
+func EstimateCredits(req *WalkRequest) int {
+	depth, limit := req.Depth, req.Limit
+	if depth == 0 {
+		depth = 3
+	}
+	if limit == 0 {
+		limit = 100
+	}
+
+	var n int
+	switch req.Mode {
+	case ModeSample:
+		samples := req.Samples
+		if samples == 0 {
+			samples = 1000
+		}
+		n = samples * depth / 1000
+	case ModeConnect:
+		n = len(req.Phonemes) * depth
+	default:
+		n = depth * depth * limit / 100
+	}
+	return max(n, 1)
+}
+
+// Reservation is credit reserved for one call
+type Reservation struct {
+	l *CreditLimiter
+	u *usage
+}
+
+// Reserve reserves credits for a call on pod with the given label. If a
+// ceiling would be exceeded it fails with ErrCreditLimit or, when Block is
+// set, waits until enough credits have aged out of the window.
+func (l *CreditLimiter) Reserve(ctx context.Context, pod, label string, credits int) (*Reservation, error) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		scopes := l.scopes(pod, label)
+		var (
+			full  string
+			limit int
+			wait  time.Duration
+		)
+		for _, scope := range scopes {
+			b := l.buckets[scope]
+			if credits > b.limit {
+				l.mu.Unlock()
+				return nil, fmt.Errorf("%w: call estimated at %d credits exceeds the %s ceiling of %d", ErrCreditLimit, credits, scope, b.limit)
+			}
+			if d := l.waitFor(b, credits, now); d > wait {
+				full, limit, wait = scope, b.limit, d
+			}
+		}
+
+		if full == "" {
+			u := &usage{at: now, credits: credits}
+			for _, scope := range scopes {
+				b := l.buckets[scope]
+				b.entries = append(b.entries, u)
+			}
+			l.mu.Unlock()
+			return &Reservation{l: l, u: u}, nil
+		}
+
+		changed := l.changed
+		l.mu.Unlock()
+		if !l.limits.Block {
+			return nil, fmt.Errorf("%w: %s ceiling of %d credits per %s reached", ErrCreditLimit, full, limit, l.limits.Window)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// scopes returns the buckets that apply to a call, creating them as needed.
+// l.mu must be held.
+func (l *CreditLimiter) scopes(pod, label string) []string {
+	var scopes []string
+	add := func(scope string, limit int) {
+		if limit <= 0 {
+			return
+		}
+		if _, ok := l.buckets[scope]; !ok {
+			l.buckets[scope] = &bucket{limit: limit}
+		}
+		scopes = append(scopes, scope)
+	}
+	add("global", l.limits.Global)
+	add("pod "+pod, l.limits.PerPod[pod])
+	if label != "" {
+		add("label "+label, l.limits.PerLabel[label])
+	}
+	return scopes
+}
+
+// waitFor drops expired entries and returns how long until credits more
+// fit in the bucket, or zero if they fit now. l.mu must be held.
+func (l *CreditLimiter) waitFor(b *bucket, credits int, now time.Time) time.Duration {
+	cutoff := now.Add(-l.limits.Window)
+	i := 0
+	for i < len(b.entries) && !b.entries[i].at.After(cutoff) {
+		i++
+	}
+	b.entries = b.entries[i:]
+
+	used := 0
+	for _, u := range b.entries {
+		used += u.credits
+	}
+	if used+credits <= b.limit {
+		return 0
+	}
+	// entries are in time order; find the one whose expiry frees enough
+	for _, u := range b.entries {
+		used -= u.credits
+		if used+credits <= b.limit {
+			return u.at.Sub(cutoff)
+		}
+	}
+	return l.limits.Window
+}
+
+// Reconcile replaces the reserved estimate with the credits actually used
+func (r *Reservation) Reconcile(credits int) {
+	r.l.mu.Lock()
+	defer r.l.mu.Unlock()
+
+	if credits == r.u.credits {
+		return
+	}
+	r.u.credits = credits
+	// wake blocked callers that may now fit
+	close(r.l.changed)
+	r.l.changed = make(chan struct{})
+}
+
+// Cancel releases a reservation for a call that used no credits
+func (r *Reservation) Cancel() {
+	r.Reconcile(0)
+}
+
+// Usage returns the credits used in the current window per scope: "global",
+// "pod <name>" and "label <name>"
+func (l *CreditLimiter) Usage() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := l.now().Add(-l.limits.Window)
+	out := make(map[string]int, len(l.buckets))
+	for scope, b := range l.buckets {
+		for _, u := range b.entries {
+			if u.at.After(cutoff) {
+				out[scope] += u.credits
+			}
+		}
+	}
+	return out
+}
+
+// meter runs call under the client's credit limiter, if any. call returns
+// the credits it used.
+func (c *Client) meter(ctx context.Context, pod string, estimate int, call func() (int, error)) error {
+	if c.limiter == nil {
+		_, err := call()
+		return err
+	}
+
+	r, err := c.limiter.Reserve(ctx, pod, CreditLabel(ctx), estimate)
+	if err != nil {
+		return err
+	}
+	credits, err := call()
+	if err != nil {
+		// failed calls are not charged
+		r.Cancel()
+		return err
+	}
+	r.Reconcile(credits)
+	return nil
+}
+
+// estimate returns the credits reserved for a walk: the client limiter's
+// estimate, or EstimateCredits when the client has no limiter
+func (c *Client) estimate(req *WalkRequest) int {
+	if c.limiter == nil {
+		return EstimateCredits(req)
+	}
+	return c.limiter.limits.Estimate(req)
+}
@@ -0,0 +1,219 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for the credit limiter
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCreditLimiterAccounting(t *testing.T) {
+	limits := CreditLimits{
+		Window:   time.Minute,
+		Global:   100,
+		PerPod:   map[string]int{"dharma": 30},
+		PerLabel: map[string]int{"batch": 10},
+	}
+
+	// step reserves credits and reconciles the reservation with used, or
+	// advances the clock when advance is set
+	type step struct {
+		pod, label string
+		credits    int
+		used       int
+		advance    time.Duration
+		wantErr    bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		usage map[string]int
+	}{
+		{
+			name:  "reservations count against every scope",
+			steps: []step{{pod: "dharma", label: "batch", credits: 5, used: 5}},
+			usage: map[string]int{"global": 5, "pod dharma": 5, "label batch": 5},
+		},
+		{
+			name: "reconciliation replaces the estimate",
+			steps: []step{
+				{pod: "dharma", credits: 20, used: 4},
+				{pod: "dharma", credits: 20, used: 20},
+			},
+			usage: map[string]int{"global": 24, "pod dharma": 24},
+		},
+		{
+			name: "pod ceiling",
+			steps: []step{
+				{pod: "dharma", credits: 25, used: 25},
+				{pod: "dharma", credits: 10, wantErr: true},
+				{pod: "other", credits: 10, used: 10},
+			},
+			usage: map[string]int{"global": 35, "pod dharma": 25},
+		},
+		{
+			name: "label ceiling",
+			steps: []step{
+				{pod: "other", label: "batch", credits: 8, used: 8},
+				{pod: "other", label: "batch", credits: 3, wantErr: true},
+				{pod: "other", label: "live", credits: 3, used: 3},
+			},
+			usage: map[string]int{"global": 11, "label batch": 8},
+		},
+		{
+			name: "estimate above a ceiling",
+			steps: []step{
+				{pod: "dharma", credits: 31, wantErr: true},
+				{pod: "other", credits: 101, wantErr: true},
+			},
+			usage: map[string]int{},
+		},
+		{
+			name: "overspend blocks until it ages out",
+			steps: []step{
+				{pod: "dharma", credits: 5, used: 40},
+				{pod: "dharma", credits: 1, wantErr: true},
+				{advance: 30 * time.Second},
+				{pod: "dharma", credits: 1, wantErr: true},
+				{advance: 31 * time.Second},
+				{pod: "dharma", credits: 30, used: 30},
+			},
+			usage: map[string]int{"global": 30, "pod dharma": 30},
+		},
+		{
+			name: "cancelled reservations are free",
+			steps: []step{
+				{pod: "dharma", credits: 30, used: 0},
+				{pod: "dharma", credits: 30, used: 30},
+			},
+			usage: map[string]int{"global": 30, "pod dharma": 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			l := NewCreditLimiter(limits)
+			l.now = clock.Now
+
+			for i, s := range tt.steps {
+				if s.advance > 0 {
+					clock.Advance(s.advance)
+					continue
+				}
+				r, err := l.Reserve(context.Background(), s.pod, s.label, s.credits)
+				if s.wantErr {
+					if !errors.Is(err, ErrCreditLimit) {
+						t.Fatalf("step %d: Reserve = %v, want %v", i, err, ErrCreditLimit)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("step %d: Reserve: %v", i, err)
+				}
+				r.Reconcile(s.used)
+			}
+
+			usage := l.Usage()
+			for scope, n := range usage {
+				if n == 0 {
+					delete(usage, scope)
+				}
+			}
+			if !reflect.DeepEqual(usage, tt.usage) {
+				t.Errorf("usage = %v, want %v", usage, tt.usage)
+			}
+		})
+	}
+}
+
+func TestCreditLimiterBlock(t *testing.T) {
+	l := NewCreditLimiter(CreditLimits{Window: time.Minute, Global: 10, Block: true})
+	r, err := l.Reserve(context.Background(), "dharma", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Reserve(ctx, "dharma", "", 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Reserve on a full limiter = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Reserve(context.Background(), "dharma", "", 5)
+		done <- err
+	}()
+	// reconciling below the estimate wakes the blocked caller
+	r.Reconcile(3)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked Reserve was not woken by Reconcile")
+	}
+}
+
+// TestCreditLimiterConcurrent is meant for go test -race: every call
+// creates a label bucket while calls over the global ceiling fail and
+// others reconcile
+func TestCreditLimiterConcurrent(t *testing.T) {
+	const goroutines, calls = 8, 200
+	perLabel := make(map[string]int)
+	for i := 0; i < goroutines*calls; i++ {
+		perLabel[fmt.Sprintf("label%d", i)] = 5
+	}
+	l := NewCreditLimiter(CreditLimits{Global: 100, PerPod: map[string]int{"dharma": 60}, PerLabel: perLabel})
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				label := fmt.Sprintf("label%d", g*calls+i)
+				r, err := l.Reserve(context.Background(), "dharma", label, 2)
+				if err != nil {
+					if !errors.Is(err, ErrCreditLimit) {
+						t.Error(err)
+					}
+					continue
+				}
+				if i%3 == 0 {
+					r.Cancel()
+				} else {
+					r.Reconcile(1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	usage := l.Usage()
+	if usage["pod dharma"] > 60 || usage["global"] != usage["pod dharma"] {
+		t.Errorf("usage = global %d, pod %d", usage["global"], usage["pod dharma"])
+	}
+}
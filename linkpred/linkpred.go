@@ -0,0 +1,228 @@
+// Package linkpred suggests likely missing edges in a pod from accumulated
+// walk results or a pod snapshot
+package linkpred
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Weights sets how much each signal contributes to a suggestion's score.
+// Each signal is scaled to [0, 1] across candidates before weighting.
+type Weights struct {
+	CommonNeighbors  float64
+	AdamicAdar       float64
+	PathCooccurrence float64
+}
+
+// DefaultWeights weighs all signals equally
+var DefaultWeights = Weights{CommonNeighbors: 1, AdamicAdar: 1, PathCooccurrence: 1}
+
+// Options configures Suggest
+type Options struct {
+	// Limit caps the number of suggestions (default 100)
+	Limit int
+	// MinScore drops suggestions scoring below this
+	MinScore float64
+	// Weights combines the signals (default DefaultWeights)
+	Weights *Weights
+}
+
+// Suggestion is a likely missing edge with the evidence behind it
+type Suggestion struct {
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	Score            float64  `json:"score"`
+	CommonNeighbors  []string `json:"common_neighbors,omitempty"`
+	AdamicAdar       float64  `json:"adamic_adar"`
+	PathCooccurrence int      `json:"path_cooccurrence"`
+}
+
+type pair struct {
+	a, b string
+}
+
+func newPair(a, b string) pair {
+	if b < a {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// Predictor accumulates graph evidence and scores candidate edges
+type Predictor struct {
+	adj  map[string]map[string]bool
+	cooc map[pair]int
+}
+
+// New creates an empty predictor
+func New() *Predictor {
+	return &Predictor{
+		adj:  make(map[string]map[string]bool),
+		cooc: make(map[pair]int),
+	}
+}
+
+// AddSnapshot adds every edge of a snapshot
+func (p *Predictor) AddSnapshot(snap *mantr.Snapshot) {
+	for _, e := range snap.Edges {
+		p.link(e.From, e.To)
+	}
+}
+
+// AddWalk adds a walk response: consecutive nodes of each path are treated as
+// edges and every other pair of nodes on a path counts as a co-occurrence
+func (p *Predictor) AddWalk(resp *mantr.WalkResponse) {
+	for _, path := range resp.Paths {
+		for i := 1; i < len(path.Nodes); i++ {
+			p.link(path.Nodes[i-1], path.Nodes[i])
+		}
+		for i := 0; i < len(path.Nodes); i++ {
+			for j := i + 2; j < len(path.Nodes); j++ {
+				if path.Nodes[i] != path.Nodes[j] {
+					p.cooc[newPair(path.Nodes[i], path.Nodes[j])]++
+				}
+			}
+		}
+	}
+}
+
+func (p *Predictor) link(a, b string) {
+	if a == b {
+		return
+	}
+	if p.adj[a] == nil {
+		p.adj[a] = make(map[string]bool)
+	}
+	if p.adj[b] == nil {
+		p.adj[b] = make(map[string]bool)
+	}
+	p.adj[a][b] = true
+	p.adj[b][a] = true
+}
+
+// Suggest ranks node pairs that are not linked yet but share neighbors or
+// appear together on walk paths
+func (p *Predictor) Suggest(opts Options) []Suggestion {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	w := DefaultWeights
+	if opts.Weights != nil {
+		w = *opts.Weights
+	}
+
+	candidates := make(map[pair]*Suggestion)
+	get := func(k pair) *Suggestion {
+		s, ok := candidates[k]
+		if !ok {
+			s = &Suggestion{From: k.a, To: k.b}
+			candidates[k] = s
+		}
+		return s
+	}
+
+	// every pair of neighbors of z has z as a common neighbor
+	for z, nbrs := range p.adj {
+		if len(nbrs) < 2 {
+			continue
+		}
+		ids := sortedKeys(nbrs)
+		aa := 1 / math.Log(float64(len(nbrs)))
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				if p.adj[ids[i]][ids[j]] {
+					continue
+				}
+				s := get(pair{ids[i], ids[j]})
+				s.CommonNeighbors = append(s.CommonNeighbors, z)
+				s.AdamicAdar += aa
+			}
+		}
+	}
+	for k, n := range p.cooc {
+		if p.adj[k.a][k.b] {
+			continue
+		}
+		get(k).PathCooccurrence = n
+	}
+
+	var maxCN, maxAA, maxCO float64
+	for _, s := range candidates {
+		maxCN = math.Max(maxCN, float64(len(s.CommonNeighbors)))
+		maxAA = math.Max(maxAA, s.AdamicAdar)
+		maxCO = math.Max(maxCO, float64(s.PathCooccurrence))
+	}
+	total := w.CommonNeighbors + w.AdamicAdar + w.PathCooccurrence
+	if total == 0 {
+		total = 1
+	}
+
+	out := make([]Suggestion, 0, len(candidates))
+	for _, s := range candidates {
+		score := w.CommonNeighbors*scale(float64(len(s.CommonNeighbors)), maxCN) +
+			w.AdamicAdar*scale(s.AdamicAdar, maxAA) +
+			w.PathCooccurrence*scale(float64(s.PathCooccurrence), maxCO)
+		s.Score = math.Round(score/total*1e4) / 1e4
+		if s.Score < opts.MinScore {
+			continue
+		}
+		sort.Strings(s.CommonNeighbors)
+		out = append(out, *s)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	if len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out
+}
+
+// IngestRequest converts suggestions into an ingestion batch. Edges are
+// weighted by score, typed as edgeType and carry their evidence as attrs.
+func IngestRequest(suggestions []Suggestion, edgeType string) *mantr.IngestRequest {
+	req := &mantr.IngestRequest{}
+	for _, s := range suggestions {
+		req.Edges = append(req.Edges, mantr.Edge{
+			From:   s.From,
+			To:     s.To,
+			Weight: s.Score,
+			Type:   edgeType,
+			Attrs: map[string]string{
+				"suggested":         "linkpred",
+				"common_neighbors":  strings.Join(s.CommonNeighbors, ","),
+				"adamic_adar":       strconv.FormatFloat(s.AdamicAdar, 'f', 4, 64),
+				"path_cooccurrence": strconv.Itoa(s.PathCooccurrence),
+			},
+		})
+	}
+	return req
+}
+
+func scale(v, max float64) float64 {
+	if max == 0 {
+		return 0
+	}
+	return v / max
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package linkpred
+
+import (
+	"reflect"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// square is the cycle a-b-d-c-a with a pendant e on b
+func square() *mantr.Snapshot {
+	return &mantr.Snapshot{Edges: []mantr.Edge{
+		{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"},
+		{From: "c", To: "d"}, {From: "e", To: "b"},
+	}}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		walks []mantr.PathResult
+		want  []Suggestion
+	}{
+		{
+			name: "common neighbors",
+			opts: Options{Limit: 2},
+			want: []Suggestion{
+				{From: "b", To: "c", Score: 0.6667, CommonNeighbors: []string{"a", "d"}, AdamicAdar: 2.8854},
+				{From: "a", To: "d", Score: 0.6052, CommonNeighbors: []string{"b", "c"}, AdamicAdar: 2.3529},
+			},
+		},
+		{
+			name: "minimum score",
+			opts: Options{MinScore: 0.3},
+			want: []Suggestion{
+				{From: "b", To: "c", Score: 0.6667, CommonNeighbors: []string{"a", "d"}, AdamicAdar: 2.8854},
+				{From: "a", To: "d", Score: 0.6052, CommonNeighbors: []string{"b", "c"}, AdamicAdar: 2.3529},
+			},
+		},
+		{
+			name:  "path co-occurrence",
+			opts:  Options{Weights: &Weights{PathCooccurrence: 1}, MinScore: 0.1},
+			walks: []mantr.PathResult{{Nodes: []string{"e", "b", "a", "c"}}, {Nodes: []string{"e", "b", "d"}}},
+			want: []Suggestion{
+				{From: "a", To: "e", Score: 1, CommonNeighbors: []string{"b"}, AdamicAdar: 0.9102, PathCooccurrence: 1},
+				{From: "b", To: "c", Score: 1, CommonNeighbors: []string{"a", "d"}, AdamicAdar: 2.8854, PathCooccurrence: 1},
+				{From: "c", To: "e", Score: 1, PathCooccurrence: 1},
+				{From: "d", To: "e", Score: 1, CommonNeighbors: []string{"b"}, AdamicAdar: 0.9102, PathCooccurrence: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			p.AddSnapshot(square())
+			p.AddWalk(&mantr.WalkResponse{Paths: tt.walks})
+
+			got := p.Suggest(tt.opts)
+			for i := range got {
+				got[i].AdamicAdar = round(got[i].AdamicAdar)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestSkipsLinkedPairs(t *testing.T) {
+	p := New()
+	p.AddSnapshot(square())
+	// the walk links b and c, so they are no longer a candidate
+	p.AddWalk(&mantr.WalkResponse{Paths: []mantr.PathResult{{Nodes: []string{"b", "c"}}}})
+	for _, s := range p.Suggest(Options{}) {
+		if s.From == "b" && s.To == "c" {
+			t.Errorf("linked pair suggested: %+v", s)
+		}
+	}
+}
+
+func TestIngestRequest(t *testing.T) {
+	req := IngestRequest([]Suggestion{
+		{From: "a", To: "d", Score: 0.6, CommonNeighbors: []string{"b", "c"}, AdamicAdar: 2.39572, PathCooccurrence: 2},
+	}, "related")
+	want := []mantr.Edge{{
+		From: "a", To: "d", Weight: 0.6, Type: "related",
+		Attrs: map[string]string{
+			"suggested":         "linkpred",
+			"common_neighbors":  "b,c",
+			"adamic_adar":       "2.3957",
+			"path_cooccurrence": "2",
+		},
+	}}
+	if !reflect.DeepEqual(req.Edges, want) {
+		t.Errorf("edges = %+v, want %+v", req.Edges, want)
+	}
+}
+
+func round(v float64) float64 {
+	return float64(int(v*1e4+0.5)) / 1e4
+}
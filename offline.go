@@ -0,0 +1,365 @@
+package mantr
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OfflineWalker answers walk requests locally from a pod snapshot. It
+// emulates the API's walk modes so agents can run without network access or
+// credits.
+type OfflineWalker struct {
+	snap    *Snapshot
+	adj     map[string][]arc
+	index   map[string][]string
+	maxEdge float64
+}
+
+// arc is an edge as seen from one of its endpoints. reverse is set when the
+// arc runs against the edge's direction.
+type arc struct {
+	to      string
+	weight  float64
+	typ     string
+	reverse bool
+}
+
+// NewOfflineWalker indexes a snapshot for walking. Edges are followed in both
+// directions unless a request restricts the direction.
+func NewOfflineWalker(snap *Snapshot) *OfflineWalker {
+	w := &OfflineWalker{
+		snap:  snap,
+		adj:   make(map[string][]arc),
+		index: make(map[string][]string),
+	}
+
+	for _, n := range snap.Nodes {
+		w.addName(n.ID, n.ID)
+		if n.Label != "" {
+			w.addName(n.Label, n.ID)
+		}
+		for _, a := range n.Aliases {
+			w.addName(a, n.ID)
+		}
+	}
+	for _, e := range snap.Edges {
+		if e.Weight <= 0 || e.From == e.To {
+			continue
+		}
+		w.adj[e.From] = append(w.adj[e.From], arc{to: e.To, weight: e.Weight, typ: e.Type})
+		w.adj[e.To] = append(w.adj[e.To], arc{to: e.From, weight: e.Weight, typ: e.Type, reverse: true})
+		w.maxEdge = max(w.maxEdge, e.Weight)
+	}
+	for id := range w.adj {
+		arcs := w.adj[id]
+		sort.Slice(arcs, func(i, j int) bool {
+			if arcs[i].to != arcs[j].to {
+				return arcs[i].to < arcs[j].to
+			}
+			if arcs[i].typ != arcs[j].typ {
+				return arcs[i].typ < arcs[j].typ
+			}
+			return !arcs[i].reverse && arcs[j].reverse
+		})
+	}
+
+	return w
+}
+
+func (w *OfflineWalker) addName(name, id string) {
+	key := Normalize(name)
+	for _, existing := range w.index[key] {
+		if existing == id {
+			return
+		}
+	}
+	w.index[key] = append(w.index[key], id)
+}
+
+// Resolve maps a phoneme to the IDs of nodes whose ID, label or alias
+// normalizes to the same form
+func (w *OfflineWalker) Resolve(phoneme string) []string {
+	return w.index[Normalize(phoneme)]
+}
+
+// seeds resolves all phonemes of a request, in order and without duplicates
+func (w *OfflineWalker) seeds(phonemes []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, p := range phonemes {
+		for _, id := range w.Resolve(p) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// checkPod rejects requests for another pod when the snapshot names its pod
+func (w *OfflineWalker) checkPod(pod string) error {
+	if pod != "" && w.snap.Pod != "" && pod != w.snap.Pod {
+		return fmt.Errorf("offline walker serves pod %q, not %q", w.snap.Pod, pod)
+	}
+	return nil
+}
+
+// WalkContext walks the snapshot. Requests for another pod are rejected when
+// the snapshot names its pod.
+func (w *OfflineWalker) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	if len(req.Phonemes) == 0 {
+		return nil, fmt.Errorf("phonemes cannot be empty")
+	}
+	if err := w.checkPod(req.Pod); err != nil {
+		return nil, err
+	}
+	req.setDefaults()
+	t, err := newTraversal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var paths []PathResult
+	switch req.Mode {
+	case "", ModeTopPaths:
+		paths, err = w.topPaths(ctx, req, t)
+	case ModeSample:
+		paths, err = w.sample(ctx, req, t)
+	case ModeConnect:
+		paths, err = w.connect(ctx, req, t)
+	default:
+		return nil, fmt.Errorf("unsupported walk mode %q", req.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalkResponse{
+		Paths:     paths,
+		LatencyUS: int(time.Since(start).Microseconds()),
+	}, nil
+}
+
+// topPaths returns the Limit best simple paths of up to Depth edges from the
+// seeds. A path's score is the product of its edge weights scaled by the
+// heaviest edge, so scores never increase along a path and a best-first
+// search yields paths in score order.
+func (w *OfflineWalker) topPaths(ctx context.Context, req *WalkRequest, t *traversal) ([]PathResult, error) {
+	pq := &pathQueue{}
+	for _, id := range w.seeds(req.Phonemes) {
+		heap.Push(pq, &PathResult{Nodes: []string{id}, Score: 1})
+	}
+
+	var out []PathResult
+	for pq.Len() > 0 && len(out) < req.Limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		p := heap.Pop(pq).(*PathResult)
+		if p.Depth > 0 {
+			out = append(out, *p)
+		}
+		if p.Depth == req.Depth {
+			continue
+		}
+
+		last := p.Nodes[len(p.Nodes)-1]
+		for _, a := range w.adj[last] {
+			if !t.follows(a) || contains(p.Nodes, a.to) {
+				continue
+			}
+			heap.Push(pq, &PathResult{
+				Nodes:     appendCopy(p.Nodes, a.to),
+				EdgeTypes: appendCopy(p.EdgeTypes, a.typ),
+				Score:     p.Score * a.weight / w.maxEdge,
+				Depth:     p.Depth + 1,
+			})
+		}
+	}
+	return out, nil
+}
+
+// sample draws Samples random walks. Each walk starts at a seed, picks
+// neighbors with probability proportional to edge weight and ends after
+// Depth steps or, at each step, with RestartProbability. Identical walks are
+// merged and ranked by how often they were drawn.
+func (w *OfflineWalker) sample(ctx context.Context, req *WalkRequest, t *traversal) ([]PathResult, error) {
+	if req.RestartProbability < 0 || req.RestartProbability >= 1 {
+		return nil, fmt.Errorf("restart probability must be in [0, 1)")
+	}
+	seeds := w.seeds(req.Phonemes)
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+
+	rng := rand.New(rand.NewSource(req.Seed))
+	counts := make(map[string]*PathResult)
+	drawn := 0
+	for i := 0; i < req.Samples; i++ {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		nodes := []string{seeds[rng.Intn(len(seeds))]}
+		var types []string
+		for step := 0; step < req.Depth; step++ {
+			if rng.Float64() < req.RestartProbability {
+				break
+			}
+			next, ok := w.step(rng, nodes[len(nodes)-1], t)
+			if !ok {
+				break
+			}
+			nodes = append(nodes, next.to)
+			types = append(types, next.typ)
+		}
+		if len(nodes) < 2 {
+			continue
+		}
+
+		drawn++
+		key := strings.Join(nodes, "\x00") + "\x01" + strings.Join(types, "\x00")
+		if p, ok := counts[key]; ok {
+			p.Visits++
+		} else {
+			counts[key] = &PathResult{Nodes: nodes, EdgeTypes: types, Depth: len(nodes) - 1, Visits: 1}
+		}
+	}
+
+	out := make([]PathResult, 0, len(counts))
+	for _, p := range counts {
+		p.Frequency = float64(p.Visits) / float64(drawn)
+		p.Score = p.Frequency
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Visits != out[j].Visits {
+			return out[i].Visits > out[j].Visits
+		}
+		return pathKey(out[i]) < pathKey(out[j])
+	})
+	if len(out) > req.Limit {
+		out = out[:req.Limit]
+	}
+	return out, nil
+}
+
+// step follows an arc from id with probability proportional to edge weight
+func (w *OfflineWalker) step(rng *rand.Rand, id string, t *traversal) (arc, bool) {
+	var arcs []arc
+	total := 0.0
+	for _, a := range w.adj[id] {
+		if t.follows(a) {
+			arcs = append(arcs, a)
+			total += a.weight
+		}
+	}
+	if len(arcs) == 0 {
+		return arc{}, false
+	}
+
+	r := rng.Float64() * total
+	for _, a := range arcs {
+		if r < a.weight {
+			return a, true
+		}
+		r -= a.weight
+	}
+	return arcs[len(arcs)-1], true
+}
+
+// traversal restricts which arcs a walk may follow
+type traversal struct {
+	direction string
+	allow     map[string]bool
+	deny      map[string]bool
+}
+
+func newTraversal(req *WalkRequest) (*traversal, error) {
+	switch req.Direction {
+	case "", DirectionBoth, DirectionOut, DirectionIn:
+	default:
+		return nil, fmt.Errorf("unsupported direction %q", req.Direction)
+	}
+
+	t := &traversal{direction: req.Direction}
+	if len(req.EdgeTypes) > 0 {
+		t.allow = make(map[string]bool, len(req.EdgeTypes))
+		for _, typ := range req.EdgeTypes {
+			t.allow[typ] = true
+		}
+	}
+	if len(req.ExcludeEdgeTypes) > 0 {
+		t.deny = make(map[string]bool, len(req.ExcludeEdgeTypes))
+		for _, typ := range req.ExcludeEdgeTypes {
+			t.deny[typ] = true
+		}
+	}
+	return t, nil
+}
+
+func (t *traversal) follows(a arc) bool {
+	switch {
+	case t.direction == DirectionOut && a.reverse,
+		t.direction == DirectionIn && !a.reverse:
+		return false
+	case t.allow != nil && !t.allow[a.typ]:
+		return false
+	}
+	return !t.deny[a.typ]
+}
+
+func appendCopy(list []string, s string) []string {
+	out := make([]string, len(list)+1)
+	copy(out, list)
+	out[len(list)] = s
+	return out
+}
+
+// pathKey identifies a path by its nodes and edge types
+func pathKey(p PathResult) string {
+	return strings.Join(p.Nodes, "\x00") + "\x01" + strings.Join(p.EdgeTypes, "\x00")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pathQueue is a max-heap of paths by score, ties broken by path key
+// so results are deterministic
+type pathQueue []*PathResult
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].Score != q[j].Score {
+		return q[i].Score > q[j].Score
+	}
+	return pathKey(*q[i]) < pathKey(*q[j])
+}
+
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(*PathResult)) }
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	p := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return p
+}
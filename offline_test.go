@@ -0,0 +1,238 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Pod: "dharma",
+		Nodes: []Node{
+			{ID: "karma"}, {ID: "dharma"}, {ID: "moksa"}, {ID: "samsara"},
+		},
+		Edges: []Edge{
+			{From: "karma", To: "samsara", Type: "binds", Weight: 0.9},
+			{From: "dharma", To: "karma", Type: "shapes", Weight: 0.7},
+			{From: "dharma", To: "moksa", Type: "leads", Weight: 0.5},
+		},
+	}
+}
+
+func TestOfflineResolve(t *testing.T) {
+	w := NewOfflineWalker(&Snapshot{Nodes: []Node{
+		{ID: "moksa", Label: "Mokṣa", Aliases: []string{"liberation"}},
+		{ID: "moksha"},
+	}})
+	tests := []struct {
+		phoneme string
+		want    []string
+	}{
+		{"moksa", []string{"moksa"}},
+		{"MOKṢA", []string{"moksa"}},
+		{"Liberation", []string{"moksa"}},
+		{"moksha", []string{"moksha"}},
+		{"nirvana", nil},
+	}
+	for _, tt := range tests {
+		if got := w.Resolve(tt.phoneme); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Resolve(%q) = %v, want %v", tt.phoneme, got, tt.want)
+		}
+	}
+}
+
+func TestOfflineTopPaths(t *testing.T) {
+	w := NewOfflineWalker(testSnapshot())
+	resp, err := w.WalkContext(context.Background(), &WalkRequest{Phonemes: []string{"karma"}, Depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// scores are products of edge weights over the heaviest edge, 0.9
+	want := []struct {
+		path  string
+		score float64
+	}{
+		{"karma samsara", 1},
+		{"karma dharma", 0.7778},
+		{"karma dharma moksa", 0.4321},
+	}
+	if len(resp.Paths) != len(want) {
+		t.Fatalf("paths = %+v", resp.Paths)
+	}
+	for i, p := range resp.Paths {
+		if strings.Join(p.Nodes, " ") != want[i].path || round4(p.Score) != want[i].score || p.Depth != len(p.Nodes)-1 {
+			t.Errorf("path %d = %+v, want %s (%v)", i, p, want[i].path, want[i].score)
+		}
+	}
+}
+
+func TestOfflineSample(t *testing.T) {
+	w := NewOfflineWalker(testSnapshot())
+	walk := func(seed int64) []PathResult {
+		t.Helper()
+		resp, err := w.WalkContext(context.Background(), &WalkRequest{
+			Phonemes: []string{"karma"}, Depth: 2, Mode: ModeSample, Samples: 500, Seed: seed,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Paths
+	}
+
+	paths := walk(7)
+	if !reflect.DeepEqual(walk(7), paths) {
+		t.Error("the same seed sampled different walks")
+	}
+	if reflect.DeepEqual(walk(8), paths) {
+		t.Error("different seeds sampled identical walks")
+	}
+
+	// without restarts every walk from karma takes two steps
+	visits, frequency := 0, 0.0
+	for i, p := range paths {
+		if p.Nodes[0] != "karma" || p.Depth != 2 || len(p.Nodes) != 3 {
+			t.Errorf("path %+v", p)
+		}
+		if i > 0 && p.Visits > paths[i-1].Visits {
+			t.Errorf("path %d drawn %d times, more than the one before it", i, p.Visits)
+		}
+		if p.Score != p.Frequency {
+			t.Errorf("score %v differs from frequency %v", p.Score, p.Frequency)
+		}
+		visits += p.Visits
+		frequency += p.Frequency
+	}
+	if visits != 500 || round4(frequency) != 1 {
+		t.Errorf("%d visits with total frequency %v", visits, frequency)
+	}
+}
+
+func TestOfflineTraversal(t *testing.T) {
+	tests := []struct {
+		name string
+		req  WalkRequest
+		want []string
+	}{
+		{
+			name: "both directions",
+			req:  WalkRequest{Phonemes: []string{"karma"}, Depth: 1},
+			want: []string{"karma samsara (binds)", "karma dharma (shapes)"},
+		},
+		{
+			name: "outgoing",
+			req:  WalkRequest{Phonemes: []string{"dharma"}, Direction: DirectionOut},
+			want: []string{"dharma karma (shapes)", "dharma karma samsara (shapes binds)", "dharma moksa (leads)"},
+		},
+		{
+			name: "incoming",
+			req:  WalkRequest{Phonemes: []string{"samsara"}, Direction: DirectionIn},
+			want: []string{"samsara karma (binds)", "samsara karma dharma (binds shapes)"},
+		},
+		{
+			name: "edge types",
+			req:  WalkRequest{Phonemes: []string{"karma"}, EdgeTypes: []string{"shapes", "leads"}},
+			want: []string{"karma dharma (shapes)", "karma dharma moksa (shapes leads)"},
+		},
+		{
+			name: "excluded edge types",
+			req:  WalkRequest{Phonemes: []string{"karma"}, ExcludeEdgeTypes: []string{"leads"}},
+			want: []string{"karma samsara (binds)", "karma dharma (shapes)"},
+		},
+	}
+	w := NewOfflineWalker(testSnapshot())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := w.WalkContext(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range resp.Paths {
+				got = append(got, strings.Join(p.Nodes, " ")+" ("+strings.Join(p.EdgeTypes, " ")+")")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOfflineWalkErrors(t *testing.T) {
+	w := NewOfflineWalker(testSnapshot())
+	tests := []struct {
+		name string
+		req  WalkRequest
+		want string
+	}{
+		{"no phonemes", WalkRequest{}, "phonemes cannot be empty"},
+		{"other pod", WalkRequest{Phonemes: []string{"karma"}, Pod: "typo"}, `serves pod "dharma"`},
+		{"unknown mode", WalkRequest{Phonemes: []string{"karma"}, Mode: "beam"}, "unsupported walk mode"},
+		{"direction", WalkRequest{Phonemes: []string{"karma"}, Direction: "up"}, "unsupported direction"},
+		{"restart probability", WalkRequest{Phonemes: []string{"karma"}, Mode: ModeSample, RestartProbability: 1}, "restart probability"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := w.WalkContext(context.Background(), &tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// testLocalFallback checks that call, which returns how many results it
+// got, falls back to the local snapshot only when the server does not
+// support the request, and only for the snapshot's pod
+func testLocalFallback(t *testing.T, call func(c *Client, pod string) (int, error)) {
+	t.Helper()
+	tests := []struct {
+		name     string
+		status   int
+		pod      string
+		fallback bool
+		wantErr  error
+	}{
+		{"not supported falls back", http.StatusNotImplemented, "dharma", true, nil},
+		{"missing pod is reported", http.StatusNotFound, "dharma", false, ErrNotFound},
+		{"other pod is rejected offline", http.StatusNotImplemented, "typo", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := NewClient("vak_test", WithBaseURL(server.URL), WithLocalFallback(NewOfflineWalker(testSnapshot())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := call(client, tt.pod)
+			if tt.fallback {
+				if err != nil {
+					t.Fatalf("fallback failed: %v", err)
+				}
+				if n == 0 {
+					t.Error("fallback returned no results")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("call succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func round4(v float64) float64 {
+	return float64(int(v*1e4+0.5)) / 1e4
+}
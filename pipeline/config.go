@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a pipeline definition, usually loaded from YAML or JSON
+type Config struct {
+	Name   string        `json:"name" yaml:"name"`
+	Budget BudgetConfig  `json:"budget" yaml:"budget"`
+	Stages []StageConfig `json:"stages" yaml:"stages"`
+}
+
+// BudgetConfig limits the resources a single run may consume
+type BudgetConfig struct {
+	// Timeout bounds the whole run, e.g. "2s"
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Credits bounds the credits spent by all stages; zero means unlimited
+	Credits int `json:"credits,omitempty" yaml:"credits,omitempty"`
+}
+
+// StageConfig configures one stage of a pipeline
+type StageConfig struct {
+	// Name identifies the stage in results and traces (default Type)
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Type is the registered component that implements the stage
+	Type string `json:"type" yaml:"type"`
+	// Timeout bounds this stage, e.g. "500ms"
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Params are passed to the component factory
+	Params Params `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// ParseConfig decodes a pipeline definition. YAML is a superset of JSON, so
+// both formats are accepted.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// LoadConfig reads a pipeline definition from a .yaml, .yml or .json file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		var cfg Config
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse pipeline config: %w", err)
+		}
+		return &cfg, nil
+	}
+	return ParseConfig(data)
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Params holds stage parameters decoded from a config file
+type Params map[string]interface{}
+
+// String returns a string parameter or def
+func (p Params) String(key, def string) string {
+	if v, ok := p[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns an integer parameter or def
+func (p Params) Int(key string, def int) int {
+	switch v := p[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// Float returns a float parameter or def
+func (p Params) Float(key string, def float64) float64 {
+	switch v := p[key].(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return def
+}
+
+// Strings returns a list parameter, given as a list or a comma-separated
+// string, or nil
+func (p Params) Strings(key string) []string {
+	switch v := p[key].(type) {
+	case string:
+		var out []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			out = append(out, fmt.Sprint(s))
+		}
+		return out
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// Bool returns a boolean parameter or def
+func (p Params) Bool(key string, def bool) bool {
+	if v, ok := p[key].(bool); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,294 @@
+// Package pipeline runs declarative retrieval pipelines built from registered
+// stages such as extract, walk, dedupe, rerank, cluster and format
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// ErrBudgetExceeded indicates a run spent more credits than its budget allows
+var ErrBudgetExceeded = errors.New("pipeline: credit budget exceeded")
+
+// Stage is one step of a pipeline. Stages read and update the shared State.
+type Stage interface {
+	Run(ctx context.Context, st *State) error
+}
+
+// StageFunc adapts a function to the Stage interface
+type StageFunc func(ctx context.Context, st *State) error
+
+// Run calls f(ctx, st)
+func (f StageFunc) Run(ctx context.Context, st *State) error {
+	return f(ctx, st)
+}
+
+// Factory creates a stage from its configured parameters
+type Factory func(params Params) (Stage, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a stage type available to pipeline configs. It panics if
+// the name is registered twice.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[name]; dup {
+		panic("pipeline: Register called twice for stage " + name)
+	}
+	registry[name] = f
+}
+
+// Stages returns the names of the registered stage types
+func Stages() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// State is the context shared by all stages of a run
+type State struct {
+	// Query is the raw input text
+	Query string
+	// Concepts are the phonemes walked by the walk stage
+	Concepts []string
+	// Paths are the current candidate paths
+	Paths []mantr.PathResult
+	// Clusters groups Paths when a cluster stage has run
+	Clusters [][]mantr.PathResult
+	// Output is the formatted result
+	Output string
+	// Values holds arbitrary data exchanged between custom stages
+	Values map[string]interface{}
+
+	// Walker answers walk requests for the walk stage
+	Walker mantr.Walker
+
+	credits int
+	budget  int
+	trace   Tracer
+	stage   string
+}
+
+// Spend charges credits against the run's budget
+func (st *State) Spend(credits int) error {
+	st.credits += credits
+	if st.budget > 0 && st.credits > st.budget {
+		return fmt.Errorf("%w: spent %d of %d", ErrBudgetExceeded, st.credits, st.budget)
+	}
+	return nil
+}
+
+// Remaining returns the credits left in the budget, or -1 when unlimited
+func (st *State) Remaining() int {
+	if st.budget <= 0 {
+		return -1
+	}
+	return max(st.budget-st.credits, 0)
+}
+
+// Tracef records a trace message for the current stage
+func (st *State) Tracef(format string, args ...interface{}) {
+	if st.trace != nil {
+		st.trace(TraceEvent{Stage: st.stage, Kind: "log", Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// TraceEvent is emitted as a pipeline runs
+type TraceEvent struct {
+	Stage    string
+	Kind     string // "start", "end" or "log"
+	Message  string
+	Duration time.Duration
+	Err      error
+}
+
+// Tracer receives trace events
+type Tracer func(TraceEvent)
+
+// StageResult reports how one stage ran
+type StageResult struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Duration    time.Duration `json:"duration_ns"`
+	CreditsUsed int           `json:"credits_used"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Result is the outcome of a pipeline run
+type Result struct {
+	Concepts    []string             `json:"concepts"`
+	Paths       []mantr.PathResult   `json:"paths"`
+	Clusters    [][]mantr.PathResult `json:"clusters,omitempty"`
+	Output      string               `json:"output"`
+	Stages      []StageResult        `json:"stages"`
+	Duration    time.Duration        `json:"duration_ns"`
+	CreditsUsed int                  `json:"credits_used"`
+
+	State *State `json:"-"`
+}
+
+type stage struct {
+	name    string
+	typ     string
+	timeout time.Duration
+	impl    Stage
+}
+
+// Pipeline is a compiled pipeline ready to run
+type Pipeline struct {
+	name    string
+	timeout time.Duration
+	credits int
+	stages  []stage
+	trace   Tracer
+}
+
+// Option configures a Pipeline
+type Option func(*Pipeline)
+
+// WithTracer sets a tracer that receives stage events
+func WithTracer(t Tracer) Option {
+	return func(p *Pipeline) {
+		p.trace = t
+	}
+}
+
+// New compiles a config into a pipeline, resolving each stage type against
+// the registry
+func New(cfg *Config, options ...Option) (*Pipeline, error) {
+	if len(cfg.Stages) == 0 {
+		return nil, fmt.Errorf("pipeline has no stages")
+	}
+
+	timeout, err := parseDuration(cfg.Budget.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid budget timeout: %w", err)
+	}
+
+	p := &Pipeline{name: cfg.Name, timeout: timeout, credits: cfg.Budget.Credits}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for i, sc := range cfg.Stages {
+		f, ok := registry[sc.Type]
+		if !ok {
+			return nil, fmt.Errorf("stage %d: unknown type %q", i, sc.Type)
+		}
+		impl, err := f(sc.Params)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d (%s): %w", i, sc.Type, err)
+		}
+		t, err := parseDuration(sc.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d (%s): invalid timeout: %w", i, sc.Type, err)
+		}
+		name := sc.Name
+		if name == "" {
+			name = sc.Type
+		}
+		p.stages = append(p.stages, stage{name: name, typ: sc.Type, timeout: t, impl: impl})
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+// Load reads and compiles a pipeline definition file
+func Load(path string, options ...Option) (*Pipeline, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg, options...)
+}
+
+// Run executes the stages in order. On error the partial result is returned
+// along with the error.
+func (p *Pipeline) Run(ctx context.Context, w mantr.Walker, query string) (*Result, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	st := &State{
+		Query:  query,
+		Values: make(map[string]interface{}),
+		Walker: w,
+		budget: p.credits,
+		trace:  p.trace,
+	}
+	res := &Result{State: st}
+	start := time.Now()
+
+	var runErr error
+	for _, s := range p.stages {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+
+		sr, err := p.runStage(ctx, s, st)
+		res.Stages = append(res.Stages, sr)
+		if err != nil {
+			runErr = fmt.Errorf("stage %s: %w", s.name, err)
+			break
+		}
+	}
+
+	res.Concepts = st.Concepts
+	res.Paths = st.Paths
+	res.Clusters = st.Clusters
+	res.Output = st.Output
+	res.Duration = time.Since(start)
+	res.CreditsUsed = st.credits
+
+	return res, runErr
+}
+
+func (p *Pipeline) runStage(ctx context.Context, s stage, st *State) (StageResult, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	st.stage = s.name
+	if p.trace != nil {
+		p.trace(TraceEvent{Stage: s.name, Kind: "start"})
+	}
+
+	credits := st.credits
+	start := time.Now()
+	err := s.impl.Run(ctx, st)
+	sr := StageResult{Name: s.name, Type: s.typ, Duration: time.Since(start), CreditsUsed: st.credits - credits}
+	if err != nil {
+		sr.Error = err.Error()
+	}
+
+	if p.trace != nil {
+		p.trace(TraceEvent{Stage: s.name, Kind: "end", Duration: sr.Duration, Err: err})
+	}
+	return sr, err
+}
@@ -0,0 +1,237 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// fakeWalker returns fixed paths and charges credits per walk
+type fakeWalker struct {
+	paths   []mantr.PathResult
+	credits int
+	reqs    []*mantr.WalkRequest
+}
+
+func (w *fakeWalker) WalkContext(ctx context.Context, req *mantr.WalkRequest) (*mantr.WalkResponse, error) {
+	w.reqs = append(w.reqs, req)
+	paths := append([]mantr.PathResult(nil), w.paths...)
+	return &mantr.WalkResponse{Paths: paths, CreditsUsed: w.credits}, nil
+}
+
+func init() {
+	// wait blocks until its context ends
+	Register("test-wait", func(p Params) (Stage, error) {
+		return StageFunc(func(ctx context.Context, st *State) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), nil
+	})
+}
+
+const definition = `
+name: test
+budget:
+  timeout: 2s
+  credits: 10
+stages:
+  - type: extract
+  - type: walk
+    params:
+      pod: dharma
+      depth: 2
+      limit: 5
+      direction: out
+      edge_types: [causes, binds]
+      exclude_edge_types: "related, part_of"
+  - type: dedupe
+    params: {by: set}
+  - name: score
+    type: rerank
+    params: {length_penalty: 0, coverage_boost: 1}
+  - type: format
+    params: {top: 2}
+`
+
+func testPaths() []mantr.PathResult {
+	return []mantr.PathResult{
+		{Nodes: []string{"moksa", "samsara"}, Score: 0.9},
+		{Nodes: []string{"karma", "samsara"}, Score: 0.5},
+		{Nodes: []string{"samsara", "karma"}, Score: 0.4},
+		{Nodes: []string{"karma", "dharma"}, Score: 0.6},
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "test" || cfg.Budget.Timeout != "2s" || cfg.Budget.Credits != 10 || len(cfg.Stages) != 5 {
+		t.Fatalf("config = %+v", cfg)
+	}
+	walk := cfg.Stages[1].Params
+	if walk.String("pod", "") != "dharma" || walk.Int("depth", 0) != 2 || walk.Float("depth", 0) != 2 || !walk.Bool("depth", true) {
+		t.Errorf("walk params = %v", walk)
+	}
+
+	json, err := ParseConfig([]byte(`{"name": "test", "stages": [{"type": "extract", "params": {"max": 3}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json.Stages[0].Params.Int("max", 8) != 3 {
+		t.Errorf("JSON params = %v", json.Stages[0].Params)
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	cfg, err := ParseConfig([]byte(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	p, err := New(cfg, WithTracer(func(e TraceEvent) {
+		if e.Kind != "log" {
+			events = append(events, e.Kind+" "+e.Stage)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &fakeWalker{paths: testPaths(), credits: 4}
+	res, err := p.Run(context.Background(), w, "How does karma relate to samsara? Karma!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(res.Concepts, []string{"karma", "relate", "samsara"}) {
+		t.Errorf("concepts = %v", res.Concepts)
+	}
+	want := &mantr.WalkRequest{
+		Phonemes:         []string{"karma", "relate", "samsara"},
+		Pod:              "dharma",
+		Depth:            2,
+		Limit:            5,
+		Direction:        mantr.DirectionOut,
+		EdgeTypes:        []string{"causes", "binds"},
+		ExcludeEdgeTypes: []string{"related", "part_of"},
+	}
+	if !reflect.DeepEqual(w.reqs[0], want) {
+		t.Errorf("walk request = %+v, want %+v", w.reqs[0], want)
+	}
+	// the reversed duplicate is dropped and coverage of two of three
+	// concepts lifts karma-samsara above the higher raw score of
+	// karma-dharma
+	output := "moksa -> samsara (1.200)\nkarma -> samsara (0.833)\n"
+	if res.Output != output {
+		t.Errorf("output = %q, want %q", res.Output, output)
+	}
+	if len(res.Paths) != 3 || res.CreditsUsed != 4 || res.Stages[1].CreditsUsed != 4 || res.Stages[3].Name != "score" {
+		t.Errorf("paths %v, credits %d, stages %+v", res.Paths, res.CreditsUsed, res.Stages)
+	}
+	wantEvents := []string{"start extract", "end extract", "start walk", "end walk", "start dedupe", "end dedupe", "start score", "end score", "start format", "end format"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("events = %v", events)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     string
+		credits int
+		wantErr error
+		stages  int
+	}{
+		{
+			name:    "budget exceeded",
+			cfg:     "budget: {credits: 3}\nstages: [{type: extract}, {type: walk}, {type: format}]",
+			credits: 4,
+			wantErr: ErrBudgetExceeded,
+			stages:  2,
+		},
+		{
+			name:    "stage timeout",
+			cfg:     "stages: [{type: extract}, {type: test-wait, timeout: 10ms}, {type: format}]",
+			wantErr: context.DeadlineExceeded,
+			stages:  2,
+		},
+		{
+			name:    "run timeout",
+			cfg:     "budget: {timeout: 10ms}\nstages: [{type: test-wait}, {type: format}]",
+			wantErr: context.DeadlineExceeded,
+			stages:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(tt.cfg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := p.Run(context.Background(), &fakeWalker{paths: testPaths(), credits: tt.credits}, "karma and samsara")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run = %v, want %v", err, tt.wantErr)
+			}
+			if len(res.Stages) != tt.stages || res.Stages[tt.stages-1].Error == "" {
+				t.Errorf("stages = %+v, want %d ending in the failure", res.Stages, tt.stages)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"no stages", "name: empty", "no stages"},
+		{"unknown type", "stages: [{type: summarize}]", `unknown type "summarize"`},
+		{"bad params", "stages: [{type: dedupe, params: {by: hash}}]", `unknown key "hash"`},
+		{"bad timeout", "stages: [{type: extract, timeout: soon}]", "invalid timeout"},
+		{"bad budget", "budget: {timeout: soon}\nstages: [{type: extract}]", "invalid budget timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(tt.cfg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterStage(t *testing.T) {
+	stage, err := newCluster(Params{"threshold": 0.3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &State{Paths: testPaths()}
+	if err := stage.Run(context.Background(), st); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, c := range st.Clusters {
+		sizes = append(sizes, len(c))
+	}
+	// both karma-samsara paths join the moksa-samsara cluster; karma-dharma
+	// shares a third of its nodes with karma-samsara but is compared with
+	// the first path of each cluster
+	if !reflect.DeepEqual(sizes, []int{3, 1}) {
+		t.Errorf("cluster sizes = %v", sizes)
+	}
+}
@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func init() {
+	Register("extract", newExtract)
+	Register("walk", newWalk)
+	Register("dedupe", newDedupe)
+	Register("rerank", newRerank)
+	Register("cluster", newCluster)
+	Register("format", newFormat)
+}
+
+// newExtract extracts concepts from the query.
+//
+// params: max (int, default 8)
+func newExtract(p Params) (Stage, error) {
+	limit := p.Int("max", 8)
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		seen := make(map[string]bool)
+		st.Concepts = st.Concepts[:0]
+		for _, t := range mantr.ExtractConcepts(st.Query) {
+			if seen[t.Text] {
+				continue
+			}
+			seen[t.Text] = true
+			st.Concepts = append(st.Concepts, t.Text)
+			if len(st.Concepts) == limit {
+				break
+			}
+		}
+		st.Tracef("extracted %d concepts", len(st.Concepts))
+		return nil
+	}), nil
+}
+
+// newWalk walks the graph from the extracted concepts.
+//
+// params: pod (string), depth (int), limit (int), direction ("out", "in" or
+// "both"), edge_types (list), exclude_edge_types (list)
+func newWalk(p Params) (Stage, error) {
+	pod := p.String("pod", "")
+	depth := p.Int("depth", 0)
+	limit := p.Int("limit", 0)
+	direction := p.String("direction", "")
+	edgeTypes := p.Strings("edge_types")
+	excludeEdgeTypes := p.Strings("exclude_edge_types")
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		if st.Walker == nil {
+			return fmt.Errorf("no walker configured")
+		}
+		if len(st.Concepts) == 0 {
+			return fmt.Errorf("no concepts to walk")
+		}
+		if st.Remaining() == 0 {
+			return ErrBudgetExceeded
+		}
+
+		req := mantr.NewWalkBuilder(st.Concepts...).
+			Pod(pod).
+			Depth(depth).
+			Limit(limit).
+			Direction(direction).
+			EdgeTypes(edgeTypes...).
+			ExcludeEdgeTypes(excludeEdgeTypes...).
+			Build()
+		resp, err := st.Walker.WalkContext(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		st.Paths = append(st.Paths, resp.Paths...)
+		st.Tracef("walk returned %d paths for %d credits", len(resp.Paths), resp.CreditsUsed)
+		return st.Spend(resp.CreditsUsed)
+	}), nil
+}
+
+// newDedupe drops duplicate paths, keeping the highest-scored copy.
+//
+// params: by ("sequence" or "set", default "sequence")
+func newDedupe(p Params) (Stage, error) {
+	by := p.String("by", "sequence")
+	if by != "sequence" && by != "set" {
+		return nil, fmt.Errorf("dedupe: unknown key %q", by)
+	}
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		best := make(map[string]int)
+		var out []mantr.PathResult
+		for _, path := range st.Paths {
+			nodes := path.Nodes
+			if by == "set" {
+				nodes = append([]string(nil), nodes...)
+				sort.Strings(nodes)
+			}
+			key := strings.Join(nodes, "\x00")
+			if i, ok := best[key]; ok {
+				if path.Score > out[i].Score {
+					out[i] = path
+				}
+				continue
+			}
+			best[key] = len(out)
+			out = append(out, path)
+		}
+		st.Tracef("removed %d duplicates", len(st.Paths)-len(out))
+		st.Paths = out
+		return nil
+	}), nil
+}
+
+// newRerank rescores paths by query-concept coverage and length, then sorts
+// and truncates them.
+//
+// params: coverage_boost (float, default 0.5), length_penalty (float,
+// default 0.1), top (int, default all)
+func newRerank(p Params) (Stage, error) {
+	boost := p.Float("coverage_boost", 0.5)
+	penalty := p.Float("length_penalty", 0.1)
+	top := p.Int("top", 0)
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		concepts := make(map[string]bool, len(st.Concepts))
+		for _, c := range st.Concepts {
+			concepts[c] = true
+		}
+
+		for i := range st.Paths {
+			path := &st.Paths[i]
+			covered := 0
+			for _, n := range path.Nodes {
+				if concepts[mantr.Normalize(n)] {
+					covered++
+				}
+			}
+			coverage := 0.0
+			if len(concepts) > 0 {
+				coverage = float64(covered) / float64(len(concepts))
+			}
+			length := float64(max(len(path.Nodes)-1, 0))
+			path.Score = path.Score * (1 + boost*coverage) / (1 + penalty*length)
+		}
+
+		sort.SliceStable(st.Paths, func(i, j int) bool { return st.Paths[i].Score > st.Paths[j].Score })
+		if top > 0 && len(st.Paths) > top {
+			st.Paths = st.Paths[:top]
+		}
+		return nil
+	}), nil
+}
+
+// newCluster groups paths whose node sets overlap.
+//
+// params: threshold (float Jaccard similarity, default 0.5)
+func newCluster(p Params) (Stage, error) {
+	threshold := p.Float("threshold", 0.5)
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		var (
+			clusters [][]mantr.PathResult
+			centers  []map[string]bool
+		)
+		for _, path := range st.Paths {
+			set := make(map[string]bool, len(path.Nodes))
+			for _, n := range path.Nodes {
+				set[n] = true
+			}
+
+			placed := false
+			for i, c := range centers {
+				if jaccard(set, c) >= threshold {
+					clusters[i] = append(clusters[i], path)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				clusters = append(clusters, []mantr.PathResult{path})
+				centers = append(centers, set)
+			}
+		}
+		st.Clusters = clusters
+		st.Tracef("formed %d clusters", len(clusters))
+		return nil
+	}), nil
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	inter := 0
+	for k := range a {
+		if b[k] {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// newFormat renders the paths into State.Output.
+//
+// params: style ("text" or "json", default "text"), top (int, default all)
+func newFormat(p Params) (Stage, error) {
+	style := p.String("style", "text")
+	if style != "text" && style != "json" {
+		return nil, fmt.Errorf("format: unknown style %q", style)
+	}
+	top := p.Int("top", 0)
+
+	return StageFunc(func(ctx context.Context, st *State) error {
+		if style == "json" {
+			var v interface{} = st.Paths
+			if st.Clusters != nil {
+				v = st.Clusters
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			st.Output = string(b)
+			return nil
+		}
+
+		var b strings.Builder
+		groups := st.Clusters
+		if groups == nil {
+			groups = [][]mantr.PathResult{st.Paths}
+		}
+		for i, group := range groups {
+			if len(st.Clusters) > 0 {
+				fmt.Fprintf(&b, "## Cluster %d\n", i+1)
+			}
+			for j, path := range group {
+				if top > 0 && j == top {
+					break
+				}
+				fmt.Fprintf(&b, "%s (%.3f)\n", strings.Join(path.Nodes, " -> "), path.Score)
+			}
+		}
+		st.Output = b.String()
+		return nil
+	}), nil
+}
@@ -0,0 +1,152 @@
+package mantr
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Pod describes a pod
+type Pod struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Nodes       int       `json:"nodes"`
+	Edges       int       `json:"edges"`
+	Version     int64     `json:"version"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Labels   map[string]string      `json:"labels,omitempty"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+// PodStats describes the shape of a pod graph
+type PodStats struct {
+	Pod
+	AvgDegree float64        `json:"avg_degree"`
+	MaxDegree int            `json:"max_degree"`
+	EdgeTypes map[string]int `json:"edge_types,omitempty"`
+}
+
+// ListPods returns the pods visible to the API key
+func (c *Client) ListPods(ctx context.Context) ([]Pod, error) {
+	var resp struct {
+		Pods []Pod `json:"pods"`
+	}
+	if err := c.do(ctx, "GET", "/v1/pods", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Pods, nil
+}
+
+// GetPod returns a pod's metadata and settings
+func (c *Client) GetPod(ctx context.Context, pod string) (*Pod, error) {
+	if pod == "" {
+		return nil, fmt.Errorf("pod cannot be empty")
+	}
+
+	var p Pod
+	if err := c.do(ctx, "GET", "/v1/pods/"+url.PathEscape(pod), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePod creates an empty pod with the given name, description, labels
+// and settings
+func (c *Client) CreatePod(ctx context.Context, pod *Pod) (*Pod, error) {
+	if pod.Name == "" {
+		return nil, fmt.Errorf("pod name cannot be empty")
+	}
+
+	var created Pod
+	if err := c.do(ctx, "POST", "/v1/pods", pod, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePod replaces a pod's description, labels and settings
+func (c *Client) UpdatePod(ctx context.Context, pod *Pod) (*Pod, error) {
+	if pod.Name == "" {
+		return nil, fmt.Errorf("pod name cannot be empty")
+	}
+
+	body := struct {
+		Description string                 `json:"description"`
+		Labels      map[string]string      `json:"labels"`
+		Settings    map[string]interface{} `json:"settings"`
+	}{pod.Description, pod.Labels, pod.Settings}
+
+	var updated Pod
+	if err := c.do(ctx, "PATCH", "/v1/pods/"+url.PathEscape(pod.Name), body, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ClonePod copies a pod's metadata, settings and graph into a new pod
+func (c *Client) ClonePod(ctx context.Context, pod, name string) (*Pod, error) {
+	if pod == "" || name == "" {
+		return nil, fmt.Errorf("pod and clone name cannot be empty")
+	}
+
+	body := struct {
+		Name string `json:"name"`
+	}{name}
+
+	var clone Pod
+	if err := c.do(ctx, "POST", "/v1/pods/"+url.PathEscape(pod)+"/clone", body, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// ExportSnapshot downloads the full graph of a pod
+func (c *Client) ExportSnapshot(ctx context.Context, pod string) (*Snapshot, error) {
+	if pod == "" {
+		return nil, fmt.Errorf("pod cannot be empty")
+	}
+
+	var snap Snapshot
+	if err := c.do(ctx, "GET", "/v1/pods/"+url.PathEscape(pod)+"/snapshot", nil, &snap); err != nil {
+		return nil, err
+	}
+	if snap.Pod == "" {
+		snap.Pod = pod
+	}
+	return &snap, nil
+}
+
+// PodStats returns statistics for a pod
+func (c *Client) PodStats(ctx context.Context, pod string) (*PodStats, error) {
+	if pod == "" {
+		return nil, fmt.Errorf("pod cannot be empty")
+	}
+
+	var stats PodStats
+	if err := c.do(ctx, "GET", "/v1/pods/"+url.PathEscape(pod)+"/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// SearchNodes finds nodes in a pod whose ID, label or aliases match query
+func (c *Client) SearchNodes(ctx context.Context, pod, query string, limit int) ([]Node, error) {
+	if pod == "" {
+		return nil, fmt.Errorf("pod cannot be empty")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	q := url.Values{"q": {query}, "limit": {strconv.Itoa(limit)}}
+	var resp struct {
+		Nodes []Node `json:"nodes"`
+	}
+	if err := c.do(ctx, "GET", "/v1/pods/"+url.PathEscape(pod)+"/nodes?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Nodes, nil
+}
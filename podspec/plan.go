@@ -0,0 +1,322 @@
+package podspec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// ErrDrift indicates the live pod changed after the plan was computed
+var ErrDrift = errors.New("podspec: live pod changed since plan was computed")
+
+// FieldChange is a change to pod metadata or a setting
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// Plan is the set of changes that brings a live pod in line with a spec
+type Plan struct {
+	Pod    string `json:"pod"`
+	Create bool   `json:"create"`
+	// BaseVersion and BaseDigest describe the live pod the plan was
+	// computed against; Apply refuses to run if it has since changed
+	BaseVersion int64  `json:"base_version"`
+	BaseDigest  string `json:"base_digest"`
+
+	Metadata    []FieldChange   `json:"metadata,omitempty"`
+	AddNodes    []mantr.Node    `json:"add_nodes,omitempty"`
+	UpdateNodes []mantr.Node    `json:"update_nodes,omitempty"`
+	DeleteNodes []string        `json:"delete_nodes,omitempty"`
+	AddEdges    []mantr.Edge    `json:"add_edges,omitempty"`
+	UpdateEdges []mantr.Edge    `json:"update_edges,omitempty"`
+	DeleteEdges []mantr.EdgeKey `json:"delete_edges,omitempty"`
+
+	// Desired is the pod metadata and settings to apply
+	Desired *mantr.Pod `json:"desired"`
+}
+
+// ComputePlan diffs a spec against the live pod. live and liveGraph are nil
+// when the pod does not exist yet.
+func ComputePlan(spec *Spec, live *mantr.Pod, liveGraph *mantr.Snapshot) (*Plan, error) {
+	desired, err := spec.Graph()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Pod: spec.Name, Desired: spec.Pod()}
+	if live == nil {
+		plan.Create = true
+		live = &mantr.Pod{Name: spec.Name}
+		liveGraph = &mantr.Snapshot{}
+	} else {
+		plan.BaseVersion = live.Version
+		plan.BaseDigest = liveGraph.Digest()
+	}
+
+	plan.diffMetadata(live, plan.Desired)
+	plan.diffNodes(liveGraph.Nodes, desired.Nodes)
+	plan.diffEdges(liveGraph.Edges, desired.Edges)
+	return plan, nil
+}
+
+// Plan fetches the live pod and diffs the spec against it
+func (s *Spec) Plan(ctx context.Context, client *mantr.Client) (*Plan, error) {
+	live, err := client.GetPod(ctx, s.Name)
+	if errors.Is(err, mantr.ErrNotFound) {
+		return ComputePlan(s, nil, nil)
+	} else if err != nil {
+		return nil, err
+	}
+
+	graph, err := client.ExportSnapshot(ctx, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	return ComputePlan(s, live, graph)
+}
+
+func (p *Plan) diffMetadata(live, desired *mantr.Pod) {
+	if live.Description != desired.Description {
+		p.Metadata = append(p.Metadata, FieldChange{Field: "description", Old: live.Description, New: desired.Description})
+	}
+	for _, k := range unionKeys(live.Labels, desired.Labels) {
+		old, ok1 := live.Labels[k]
+		cur, ok2 := desired.Labels[k]
+		if ok1 != ok2 || old != cur {
+			p.Metadata = append(p.Metadata, FieldChange{Field: "labels." + k, Old: nilIfAbsent(old, ok1), New: nilIfAbsent(cur, ok2)})
+		}
+	}
+
+	// compare settings through JSON so YAML ints equal API floats
+	oldSettings, newSettings := jsonRoundTrip(live.Settings), jsonRoundTrip(desired.Settings)
+	for _, k := range unionKeys(oldSettings, newSettings) {
+		if !reflect.DeepEqual(oldSettings[k], newSettings[k]) {
+			p.Metadata = append(p.Metadata, FieldChange{Field: "settings." + k, Old: oldSettings[k], New: newSettings[k]})
+		}
+	}
+}
+
+func (p *Plan) diffNodes(live, desired []mantr.Node) {
+	have := make(map[string]mantr.Node, len(live))
+	for _, n := range live {
+		have[n.ID] = n
+	}
+	want := make(map[string]bool, len(desired))
+	for _, n := range desired {
+		want[n.ID] = true
+		old, ok := have[n.ID]
+		switch {
+		case !ok:
+			p.AddNodes = append(p.AddNodes, n)
+		case !sameNode(old, n):
+			p.UpdateNodes = append(p.UpdateNodes, n)
+		}
+	}
+	for _, n := range live {
+		if !want[n.ID] {
+			p.DeleteNodes = append(p.DeleteNodes, n.ID)
+		}
+	}
+	sort.Strings(p.DeleteNodes)
+}
+
+func (p *Plan) diffEdges(live, desired []mantr.Edge) {
+	have := make(map[mantr.EdgeKey]mantr.Edge, len(live))
+	for _, e := range live {
+		have[e.Key()] = e
+	}
+	want := make(map[mantr.EdgeKey]bool, len(desired))
+	for _, e := range desired {
+		want[e.Key()] = true
+		old, ok := have[e.Key()]
+		switch {
+		case !ok:
+			p.AddEdges = append(p.AddEdges, e)
+		case old.Weight != e.Weight || !sameAttrs(old.Attrs, e.Attrs):
+			p.UpdateEdges = append(p.UpdateEdges, e)
+		}
+	}
+	for _, e := range live {
+		if !want[e.Key()] {
+			p.DeleteEdges = append(p.DeleteEdges, e.Key())
+		}
+	}
+}
+
+// Empty reports whether the plan changes nothing
+func (p *Plan) Empty() bool {
+	return !p.Create && len(p.Metadata) == 0 &&
+		len(p.AddNodes)+len(p.UpdateNodes)+len(p.DeleteNodes) == 0 &&
+		len(p.AddEdges)+len(p.UpdateEdges)+len(p.DeleteEdges) == 0
+}
+
+// Summary returns a one-line count of the planned changes
+func (p *Plan) Summary() string {
+	return fmt.Sprintf("nodes: %d to add, %d to change, %d to delete; edges: %d to add, %d to change, %d to delete; %d metadata changes",
+		len(p.AddNodes), len(p.UpdateNodes), len(p.DeleteNodes),
+		len(p.AddEdges), len(p.UpdateEdges), len(p.DeleteEdges), len(p.Metadata))
+}
+
+// WriteText renders the plan for human review
+func (p *Plan) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if p.Create {
+		fmt.Fprintf(bw, "+ pod %s (new)\n", p.Pod)
+	} else {
+		fmt.Fprintf(bw, "  pod %s (version %d)\n", p.Pod, p.BaseVersion)
+	}
+	for _, c := range p.Metadata {
+		fmt.Fprintf(bw, "~ %s: %v -> %v\n", c.Field, c.Old, c.New)
+	}
+	for _, n := range p.AddNodes {
+		fmt.Fprintf(bw, "+ node %s\n", n.ID)
+	}
+	for _, n := range p.UpdateNodes {
+		fmt.Fprintf(bw, "~ node %s\n", n.ID)
+	}
+	for _, id := range p.DeleteNodes {
+		fmt.Fprintf(bw, "- node %s\n", id)
+	}
+	for _, e := range p.AddEdges {
+		fmt.Fprintf(bw, "+ edge %s\n", edgeString(e.Key()))
+	}
+	for _, e := range p.UpdateEdges {
+		fmt.Fprintf(bw, "~ edge %s (weight %g)\n", edgeString(e.Key()), e.Weight)
+	}
+	for _, k := range p.DeleteEdges {
+		fmt.Fprintf(bw, "- edge %s\n", edgeString(k))
+	}
+	fmt.Fprintln(bw, p.Summary())
+	return bw.Flush()
+}
+
+// ReadPlan decodes a plan written as JSON
+func ReadPlan(r io.Reader) (*Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+	return &p, nil
+}
+
+// Apply executes a plan. Unless the plan creates the pod, it first checks
+// that the live pod still matches the plan's base and returns ErrDrift if not.
+func (p *Plan) Apply(ctx context.Context, client *mantr.Client, batchSize int) error {
+	if p.Create {
+		if _, err := client.CreatePod(ctx, p.Desired); err != nil {
+			return fmt.Errorf("failed to create pod: %w", err)
+		}
+	} else {
+		if err := p.checkDrift(ctx, client); err != nil {
+			return err
+		}
+		if len(p.Metadata) > 0 {
+			if _, err := client.UpdatePod(ctx, p.Desired); err != nil {
+				return fmt.Errorf("failed to update pod: %w", err)
+			}
+		}
+	}
+
+	req := &mantr.IngestRequest{
+		Nodes:       append(append([]mantr.Node(nil), p.AddNodes...), p.UpdateNodes...),
+		Edges:       append(append([]mantr.Edge(nil), p.AddEdges...), p.UpdateEdges...),
+		DeleteNodes: p.DeleteNodes,
+		DeleteEdges: p.DeleteEdges,
+	}
+	_, err := client.IngestBatches(ctx, p.Pod, req, batchSize)
+	return err
+}
+
+// checkDrift compares the live pod's version, or its graph digest when the
+// server does not report versions, with the plan's base
+func (p *Plan) checkDrift(ctx context.Context, client *mantr.Client) error {
+	live, err := client.GetPod(ctx, p.Pod)
+	if err != nil {
+		return err
+	}
+	if live.Version != 0 || p.BaseVersion != 0 {
+		if live.Version != p.BaseVersion {
+			return fmt.Errorf("%w: version %d, planned against %d", ErrDrift, live.Version, p.BaseVersion)
+		}
+		return nil
+	}
+
+	graph, err := client.ExportSnapshot(ctx, p.Pod)
+	if err != nil {
+		return err
+	}
+	if graph.Digest() != p.BaseDigest {
+		return fmt.Errorf("%w: graph digest differs", ErrDrift)
+	}
+	return nil
+}
+
+func sameNode(a, b mantr.Node) bool {
+	return a.Label == b.Label &&
+		strings.Join(a.Aliases, "\x00") == strings.Join(b.Aliases, "\x00") &&
+		sameAttrs(a.Attrs, b.Attrs)
+}
+
+func sameAttrs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func edgeString(k mantr.EdgeKey) string {
+	if k.Type == "" {
+		return k.From + " -> " + k.To
+	}
+	return k.From + " -[" + k.Type + "]-> " + k.To
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func nilIfAbsent(v string, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func jsonRoundTrip(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	var out map[string]interface{}
+	json.Unmarshal(b, &out)
+	return out
+}
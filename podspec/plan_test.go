@@ -0,0 +1,243 @@
+package podspec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+func testSpec(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := writeSpec(t, map[string]string{
+		"pod.yaml":     specYAML,
+		"nodes/a.yaml": "- id: karma\n  label: Karma\n- id: dharma\n",
+		"edges.json":   `[{"from": "karma", "to": "dharma", "weight": 0.7, "type": "shapes"}]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestComputePlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		live  *mantr.Pod
+		graph *mantr.Snapshot
+		want  *Plan
+	}{
+		{
+			name: "new pod",
+			want: &Plan{
+				Create: true,
+				Metadata: []FieldChange{
+					{Field: "description", Old: "", New: "Indian philosophy"},
+					{Field: "labels.team", New: "research"},
+					{Field: "settings.max_depth", New: float64(4)},
+				},
+				AddNodes: []mantr.Node{{ID: "dharma"}, {ID: "karma", Label: "Karma"}},
+				AddEdges: []mantr.Edge{{From: "karma", To: "dharma", Weight: 0.7, Type: "shapes"}},
+			},
+		},
+		{
+			name: "unchanged pod",
+			live: &mantr.Pod{Name: "dharma", Version: 3, Description: "Indian philosophy", Labels: map[string]string{"team": "research"},
+				Settings: map[string]interface{}{"max_depth": 4.0}},
+			graph: &mantr.Snapshot{
+				Nodes: []mantr.Node{{ID: "karma", Label: "Karma"}, {ID: "dharma"}},
+				Edges: []mantr.Edge{{From: "karma", To: "dharma", Weight: 0.7, Type: "shapes"}},
+			},
+			want: &Plan{BaseVersion: 3},
+		},
+		{
+			name: "changed pod",
+			live: &mantr.Pod{Name: "dharma", Version: 3, Description: "Indian philosophy", Labels: map[string]string{"team": "ops"},
+				Settings: map[string]interface{}{"max_depth": 4.0}},
+			graph: &mantr.Snapshot{
+				Nodes: []mantr.Node{{ID: "karma"}, {ID: "dharma"}, {ID: "samsara"}},
+				Edges: []mantr.Edge{
+					{From: "karma", To: "dharma", Weight: 0.5, Type: "shapes"},
+					{From: "karma", To: "samsara", Weight: 0.9},
+				},
+			},
+			want: &Plan{
+				BaseVersion: 3,
+				Metadata:    []FieldChange{{Field: "labels.team", Old: "ops", New: "research"}},
+				UpdateNodes: []mantr.Node{{ID: "karma", Label: "Karma"}},
+				DeleteNodes: []string{"samsara"},
+				UpdateEdges: []mantr.Edge{{From: "karma", To: "dharma", Weight: 0.7, Type: "shapes"}},
+				DeleteEdges: []mantr.EdgeKey{{From: "karma", To: "samsara"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := testSpec(t)
+			plan, err := ComputePlan(spec, tt.live, tt.graph)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Pod = "dharma"
+			tt.want.Desired = spec.Pod()
+			if tt.graph != nil {
+				tt.want.BaseDigest = tt.graph.Digest()
+			}
+			if !reflect.DeepEqual(plan, tt.want) {
+				t.Errorf("plan = %+v\nwant %+v", plan, tt.want)
+			}
+			if plan.Empty() != (tt.name == "unchanged pod") {
+				t.Errorf("Empty() = %v", plan.Empty())
+			}
+		})
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	plan, err := ComputePlan(testSpec(t), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text bytes.Buffer
+	if err := plan.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"+ pod dharma (new)", "+ node karma", "+ edge karma -[shapes]-> dharma", "nodes: 2 to add"} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("plan text lacks %q:\n%s", line, text.String())
+		}
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadPlan(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Summary() != plan.Summary() || !read.Create {
+		t.Errorf("read plan %+v", read)
+	}
+}
+
+// podServer fakes the pod and ingestion endpoints of one pod
+type podServer struct {
+	mu      sync.Mutex
+	version int64
+	calls   []string
+	ingests []mantr.IngestRequest
+}
+
+func (s *podServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v1/pods/dharma":
+		json.NewEncoder(w).Encode(mantr.Pod{Name: "dharma", Version: s.version})
+	case r.URL.Path == "/v1/pods/dharma/ingest":
+		var req mantr.IngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.ingests = append(s.ingests, req)
+		s.version++
+		json.NewEncoder(w).Encode(mantr.IngestResponse{Version: s.version})
+	case r.URL.Path == "/v1/pods/dharma" || r.URL.Path == "/v1/pods":
+		json.NewEncoder(w).Encode(mantr.Pod{Name: "dharma"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	plan := &Plan{
+		Pod:         "dharma",
+		BaseVersion: 3,
+		Desired:     &mantr.Pod{Name: "dharma"},
+		Metadata:    []FieldChange{{Field: "description", New: "Indian philosophy"}},
+		AddNodes:    []mantr.Node{{ID: "ahimsa"}},
+		UpdateNodes: []mantr.Node{{ID: "karma", Label: "Karma"}},
+		DeleteNodes: []string{"samsara"},
+		AddEdges:    []mantr.Edge{{From: "ahimsa", To: "dharma", Weight: 1}},
+		DeleteEdges: []mantr.EdgeKey{{From: "karma", To: "samsara"}},
+	}
+
+	tests := []struct {
+		name    string
+		version int64
+		create  bool
+		wantErr error
+		calls   []string
+		ingests int
+	}{
+		{
+			name:    "applied in dependency order",
+			version: 3,
+			calls:   []string{"GET /v1/pods/dharma", "PATCH /v1/pods/dharma"},
+			ingests: 5,
+		},
+		{
+			name:    "drift",
+			version: 4,
+			wantErr: ErrDrift,
+			calls:   []string{"GET /v1/pods/dharma"},
+		},
+		{
+			name:    "created",
+			create:  true,
+			calls:   []string{"POST /v1/pods"},
+			ingests: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &podServer{version: tt.version}
+			server := httptest.NewServer(s)
+			defer server.Close()
+			client, err := mantr.NewClient("vak_test", mantr.WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := *plan
+			p.Create = tt.create
+			err = p.Apply(context.Background(), client, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply = %v, want %v", err, tt.wantErr)
+			}
+
+			var calls []string
+			for _, c := range s.calls {
+				if !strings.HasSuffix(c, "/ingest") {
+					calls = append(calls, c)
+				}
+			}
+			if !reflect.DeepEqual(calls, tt.calls) || len(s.ingests) != tt.ingests {
+				t.Fatalf("calls = %v with %d ingestion calls", s.calls, len(s.ingests))
+			}
+			if tt.ingests == 0 {
+				return
+			}
+			// edges are removed before nodes and added after them
+			want := []mantr.IngestRequest{
+				{DeleteEdges: plan.DeleteEdges},
+				{Nodes: plan.AddNodes},
+				{Nodes: plan.UpdateNodes},
+				{Edges: plan.AddEdges},
+				{DeleteNodes: plan.DeleteNodes},
+			}
+			if !reflect.DeepEqual(s.ingests, want) {
+				t.Errorf("ingestion calls = %+v", s.ingests)
+			}
+		})
+	}
+}
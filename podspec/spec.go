@@ -0,0 +1,143 @@
+// Package podspec manages pods as code: declarative specs that are diffed
+// against the live pod and applied through the ingestion API
+package podspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec declares a pod's metadata, settings and graph. Node and edge files
+// are paths or glob patterns relative to the spec file, each holding a YAML
+// or JSON list of nodes or edges.
+type Spec struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Settings    map[string]interface{} `json:"settings,omitempty" yaml:"settings,omitempty"`
+	NodeFiles   []string               `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	EdgeFiles   []string               `json:"edges,omitempty" yaml:"edges,omitempty"`
+
+	dir string
+}
+
+// Load reads a spec file
+func Load(path string) (*Spec, error) {
+	var spec Spec
+	if err := decodeFile(path, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Name == "" {
+		return nil, fmt.Errorf("%s: pod name cannot be empty", path)
+	}
+	spec.dir = filepath.Dir(path)
+	return &spec, nil
+}
+
+// Pod returns the pod metadata and settings declared by the spec
+func (s *Spec) Pod() *mantr.Pod {
+	return &mantr.Pod{
+		Name:        s.Name,
+		Description: s.Description,
+		Labels:      s.Labels,
+		Settings:    s.Settings,
+	}
+}
+
+// Graph reads the node and edge files into a snapshot
+func (s *Spec) Graph() (*mantr.Snapshot, error) {
+	snap := &mantr.Snapshot{Pod: s.Name}
+
+	nodeFiles, err := s.expand(s.NodeFiles)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]string)
+	for _, path := range nodeFiles {
+		var nodes []mantr.Node
+		if err := decodeFile(path, &nodes); err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			if n.ID == "" {
+				return nil, fmt.Errorf("%s: node without id", path)
+			}
+			if prev, dup := seen[n.ID]; dup {
+				return nil, fmt.Errorf("%s: node %q already declared in %s", path, n.ID, prev)
+			}
+			seen[n.ID] = path
+			snap.Nodes = append(snap.Nodes, n)
+		}
+	}
+
+	edgeFiles, err := s.expand(s.EdgeFiles)
+	if err != nil {
+		return nil, err
+	}
+	edges := make(map[mantr.EdgeKey]string)
+	for _, path := range edgeFiles {
+		var list []mantr.Edge
+		if err := decodeFile(path, &list); err != nil {
+			return nil, err
+		}
+		for _, e := range list {
+			if _, ok := seen[e.From]; !ok {
+				return nil, fmt.Errorf("%s: edge %s -> %s references unknown node %q", path, e.From, e.To, e.From)
+			}
+			if _, ok := seen[e.To]; !ok {
+				return nil, fmt.Errorf("%s: edge %s -> %s references unknown node %q", path, e.From, e.To, e.To)
+			}
+			if prev, dup := edges[e.Key()]; dup {
+				return nil, fmt.Errorf("%s: edge %s -> %s already declared in %s", path, e.From, e.To, prev)
+			}
+			edges[e.Key()] = path
+			snap.Edges = append(snap.Edges, e)
+		}
+	}
+
+	snap.Sort()
+	return snap, nil
+}
+
+// expand resolves file patterns relative to the spec directory
+func (s *Spec) expand(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(s.dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// decodeFile decodes a JSON or YAML file according to its extension
+func decodeFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, v)
+	} else {
+		err = yaml.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package podspec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSpec writes files into a temporary directory and loads its
+// pod.yaml
+func writeSpec(t *testing.T, files map[string]string) (*Spec, error) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Load(filepath.Join(dir, "pod.yaml"))
+}
+
+const specYAML = `name: dharma
+description: Indian philosophy
+labels: {team: research}
+settings: {max_depth: 4}
+nodes: [nodes/*.yaml]
+edges: [edges.json]
+`
+
+func TestSpecGraph(t *testing.T) {
+	spec, err := writeSpec(t, map[string]string{
+		"pod.yaml":          specYAML,
+		"nodes/core.yaml":   "- id: karma\n  label: Karma\n- id: dharma\n",
+		"nodes/extra.yaml":  "- id: moksa\n  aliases: [moksha]\n",
+		"edges.json":        `[{"from": "karma", "to": "dharma", "weight": 0.7, "type": "shapes"}]`,
+		"nodes/ignored.txt": "- id: ignored\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := spec.Pod()
+	if pod.Name != "dharma" || pod.Labels["team"] != "research" || pod.Settings["max_depth"] != 4 {
+		t.Errorf("pod = %+v", pod)
+	}
+	snap, err := spec.Graph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range snap.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if strings.Join(ids, ",") != "dharma,karma,moksa" || len(snap.Edges) != 1 || snap.Edges[0].Weight != 0.7 {
+		t.Errorf("graph = %+v", snap)
+	}
+}
+
+func TestSpecErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "unnamed pod",
+			files: map[string]string{"pod.yaml": "description: nameless\n"},
+			want:  "pod name cannot be empty",
+		},
+		{
+			name:  "missing files",
+			files: map[string]string{"pod.yaml": "name: dharma\nnodes: [nodes/*.yaml]\n"},
+			want:  "no files match",
+		},
+		{
+			name: "node without id",
+			files: map[string]string{
+				"pod.yaml":   "name: dharma\nnodes: [nodes.yaml]\n",
+				"nodes.yaml": "- label: Karma\n",
+			},
+			want: "node without id",
+		},
+		{
+			name: "duplicate node",
+			files: map[string]string{
+				"pod.yaml": "name: dharma\nnodes: [a.yaml, b.yaml]\n",
+				"a.yaml":   "- id: karma\n",
+				"b.yaml":   "- id: karma\n",
+			},
+			want: `node "karma" already declared`,
+		},
+		{
+			name: "unknown endpoint",
+			files: map[string]string{
+				"pod.yaml":   "name: dharma\nnodes: [nodes.yaml]\nedges: [edges.yaml]\n",
+				"nodes.yaml": "- id: karma\n",
+				"edges.yaml": "- {from: karma, to: samsara, weight: 1}\n",
+			},
+			want: `unknown node "samsara"`,
+		},
+		{
+			name: "duplicate edge",
+			files: map[string]string{
+				"pod.yaml":   "name: dharma\nnodes: [nodes.yaml]\nedges: [edges.yaml]\n",
+				"nodes.yaml": "- id: karma\n- id: samsara\n",
+				"edges.yaml": "- {from: karma, to: samsara, weight: 1}\n- {from: karma, to: samsara, weight: 2}\n",
+			},
+			want: "already declared",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := writeSpec(t, tt.files)
+			if err == nil {
+				_, err = spec.Graph()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package mantr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PrefetchOptions configures a Prefetcher
+type PrefetchOptions struct {
+	// Fanout is the most follow-up walks predicted per walk (default 3)
+	Fanout int
+	// CreditCap bounds the credits spent on prefetching per Window
+	// (default 100)
+	CreditCap int
+	// Window is the credit cap window (default one minute)
+	Window time.Duration
+	// MinHitRate is the share of prefetches that must be used for the
+	// prefetcher to keep its fanout (default 0.2)
+	MinHitRate float64
+	// Epoch is the number of prefetches between fanout adjustments
+	// (default 20)
+	Epoch int
+	// TTL is how long an unused prefetch counts as pending (default 5m)
+	TTL time.Duration
+	// Workers is the number of background walks run at once (default 2)
+	Workers int
+}
+
+// PrefetchStats reports how a Prefetcher is doing
+type PrefetchStats struct {
+	Walks       int     `json:"walks"`
+	Prefetched  int     `json:"prefetched"`
+	Hits        int     `json:"hits"`
+	Dropped     int     `json:"dropped"`
+	CreditsUsed int     `json:"credits_used"`
+	HitRate     float64 `json:"hit_rate"`
+	Fanout      int     `json:"fanout"`
+}
+
+// Prefetcher wraps a caching client and, after each walk, walks the likely
+// follow-ups in the background so they are served from the cache. A
+// follow-up is the same request seeded from one of the top-ranked frontier
+// nodes, the last nodes of the best paths. The prefetcher tracks how many
+// prefetches are used, lowers its fanout when predictions are not paying off
+// and raises it again when they are.
+type Prefetcher struct {
+	client  *Client
+	opts    PrefetchOptions
+	limiter *CreditLimiter
+	queue   chan *WalkRequest
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[string]time.Time
+	stats   PrefetchStats
+	epoch   struct{ prefetched, hits int }
+	// idle counts walks since the last probe while fanout is zero
+	idle int
+}
+
+// NewPrefetcher creates a prefetcher. The client must have a cache.
+func NewPrefetcher(client *Client, opts PrefetchOptions) (*Prefetcher, error) {
+	if client.cache == nil {
+		return nil, fmt.Errorf("prefetching requires a client cache (WithCache)")
+	}
+	if opts.Fanout <= 0 {
+		opts.Fanout = 3
+	}
+	if opts.CreditCap <= 0 {
+		opts.CreditCap = 100
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.MinHitRate <= 0 {
+		opts.MinHitRate = 0.2
+	}
+	if opts.Epoch <= 0 {
+		opts.Epoch = 20
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+
+	p := &Prefetcher{
+		client:  client,
+		opts:    opts,
+		limiter: NewCreditLimiter(CreditLimits{Global: opts.CreditCap, Window: opts.Window}),
+		queue:   make(chan *WalkRequest, opts.Fanout*opts.Workers),
+		pending: make(map[string]time.Time),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.stats.Fanout = opts.Fanout
+	for i := 0; i < opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p, nil
+}
+
+// WalkContext walks through the client and queues predicted follow-ups. A
+// walk counts as a hit when it was predicted and served from the cache.
+func (p *Prefetcher) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	if len(req.Phonemes) == 0 {
+		return nil, fmt.Errorf("phonemes cannot be empty")
+	}
+	req.setDefaults()
+	key := CacheKey(req)
+
+	resp, err := p.client.WalkContext(ctx, req)
+
+	p.mu.Lock()
+	p.stats.Walks++
+	if at, ok := p.pending[key]; ok {
+		delete(p.pending, key)
+		if err == nil && resp.FromCache && time.Since(at) < p.opts.TTL {
+			p.stats.Hits++
+			p.epoch.hits++
+		}
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	p.predict(req, resp)
+	return resp, nil
+}
+
+// predict queues follow-ups seeded from the frontier of the best paths
+func (p *Prefetcher) predict(req *WalkRequest, resp *WalkResponse) {
+	p.mu.Lock()
+	fanout := p.stats.Fanout
+	if fanout == 0 {
+		// keep measuring with an occasional single prediction
+		p.idle++
+		if p.idle >= p.opts.Epoch {
+			p.idle = 0
+			fanout = 1
+		}
+	}
+	p.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, s := range req.Phonemes {
+		seen[Normalize(s)] = true
+	}
+	queued := 0
+	for _, path := range resp.Paths {
+		if queued == fanout {
+			break
+		}
+		if len(path.Nodes) == 0 {
+			continue
+		}
+		node := path.Nodes[len(path.Nodes)-1]
+		if seen[Normalize(node)] {
+			continue
+		}
+		seen[Normalize(node)] = true
+
+		next := *req
+		next.Phonemes = []string{node}
+		if entry, ok := p.client.cache.Get(CacheKey(&next)); ok && entry.Fresh(time.Now()) {
+			continue
+		}
+		select {
+		case p.queue <- &next:
+			queued++
+		default:
+			p.mu.Lock()
+			p.stats.Dropped++
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *Prefetcher) worker() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case req := <-p.queue:
+			p.prefetch(p.ctx, req)
+		}
+	}
+}
+
+func (p *Prefetcher) prefetch(ctx context.Context, req *WalkRequest) {
+	r, err := p.limiter.Reserve(ctx, req.Pod, "", p.client.estimate(req))
+	if err != nil {
+		p.mu.Lock()
+		p.stats.Dropped++
+		p.mu.Unlock()
+		return
+	}
+	resp, err := p.client.WalkContext(ctx, req)
+	if err != nil {
+		r.Cancel()
+		return
+	}
+	r.Reconcile(resp.CreditsUsed)
+
+	// a response the cache will not serve, e.g. one without caching
+	// headers, cannot be hit; it still counts against the hit rate
+	key := CacheKey(req)
+	now := time.Now()
+	entry, cached := p.client.cache.Get(key)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached && entry.Fresh(now) {
+		p.pending[key] = now
+	}
+	p.stats.Prefetched++
+	p.stats.CreditsUsed += resp.CreditsUsed
+	p.epoch.prefetched++
+	if p.epoch.prefetched >= p.opts.Epoch {
+		p.adjust()
+	}
+}
+
+// adjust sets the fanout from the hit rate of the last epoch and forgets
+// expired predictions. p.mu must be held.
+func (p *Prefetcher) adjust() {
+	rate := float64(p.epoch.hits) / float64(p.epoch.prefetched)
+	switch {
+	case rate < p.opts.MinHitRate && p.stats.Fanout > 0:
+		p.stats.Fanout--
+	case rate >= 2*p.opts.MinHitRate && p.stats.Fanout < p.opts.Fanout:
+		p.stats.Fanout++
+	}
+	p.epoch.prefetched, p.epoch.hits = 0, 0
+
+	now := time.Now()
+	for key, at := range p.pending {
+		if now.Sub(at) >= p.opts.TTL {
+			delete(p.pending, key)
+		}
+	}
+}
+
+// Stats returns the prefetcher's counters
+func (p *Prefetcher) Stats() PrefetchStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.stats
+	if s.Prefetched > 0 {
+		s.HitRate = float64(s.Hits) / float64(s.Prefetched)
+	}
+	return s
+}
+
+// Close stops the background workers, abandoning queued prefetches
+func (p *Prefetcher) Close() {
+	p.once.Do(p.cancel)
+	p.wg.Wait()
+}
@@ -0,0 +1,77 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPrefetcherHits(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		walks        int32
+		hits         int
+	}{
+		{"cacheable", "max-age=60", 2, 1},
+		{"no caching headers", "", 3, 0},
+		{"no-store", "no-store", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var walks int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&walks, 1)
+				var req WalkRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				seed := req.Phonemes[0]
+				json.NewEncoder(w).Encode(WalkResponse{
+					Paths:       []PathResult{{Nodes: []string{seed, seed + "-next"}, Score: 1, Depth: 1}},
+					CreditsUsed: 1,
+				})
+			}))
+			defer server.Close()
+
+			client, err := NewClient("vak_test", WithBaseURL(server.URL), WithCache(NewMemoryCache(100)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := NewPrefetcher(client, PrefetchOptions{Fanout: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			ctx := context.Background()
+			if _, err := p.WalkContext(ctx, &WalkRequest{Phonemes: []string{"karma"}}); err != nil {
+				t.Fatal(err)
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for p.Stats().Prefetched == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("follow-up was not prefetched")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			resp, err := p.WalkContext(ctx, &WalkRequest{Phonemes: []string{"karma-next"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats := p.Stats()
+			if stats.Hits != tt.hits || resp.FromCache != (tt.hits == 1) {
+				t.Errorf("hits = %d, from cache = %v, want %d hits", stats.Hits, resp.FromCache, tt.hits)
+			}
+			if n := atomic.LoadInt32(&walks); n != tt.walks {
+				t.Errorf("server walked %d times, want %d", n, tt.walks)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// RankRequest asks for the nodes most related to a weighted set of phonemes
+// by personalized PageRank
+type RankRequest struct {
+	Phonemes []string `json:"phonemes"`
+	// Weights sets the restart weight of each phoneme (default equal)
+	Weights []float64 `json:"weights,omitempty"`
+	Pod     string    `json:"pod,omitempty"`
+	// Damping is the probability of following an edge rather than
+	// restarting at a seed (default 0.85)
+	Damping float64 `json:"damping,omitempty"`
+	Limit   int     `json:"limit,omitempty"`
+	// ExcludeSeeds omits the seed nodes from the ranking
+	ExcludeSeeds bool `json:"exclude_seeds,omitempty"`
+}
+
+// RankedNode is a node and its personalized PageRank score
+type RankedNode struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// RankResponse represents a rank API response
+type RankResponse struct {
+	Nodes       []RankedNode `json:"nodes"`
+	LatencyUS   int          `json:"latency_us"`
+	CreditsUsed int          `json:"credits_used"`
+	RequestID   string       `json:"request_id,omitempty"`
+}
+
+func (req *RankRequest) validate() error {
+	if len(req.Phonemes) == 0 {
+		return fmt.Errorf("phonemes cannot be empty")
+	}
+	if len(req.Weights) > 0 && len(req.Weights) != len(req.Phonemes) {
+		return fmt.Errorf("got %d weights for %d phonemes", len(req.Weights), len(req.Phonemes))
+	}
+	if len(req.Weights) > 0 {
+		sum := 0.0
+		for _, w := range req.Weights {
+			if w < 0 {
+				return fmt.Errorf("weights cannot be negative")
+			}
+			sum += w
+		}
+		if sum == 0 {
+			return fmt.Errorf("weights cannot all be zero")
+		}
+	}
+	if req.Damping < 0 || req.Damping >= 1 {
+		return fmt.Errorf("damping must be in [0, 1)")
+	}
+	if req.Damping == 0 {
+		req.Damping = 0.85
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+	return nil
+}
+
+// Rank ranks nodes by personalized PageRank seeded by the request's
+// phonemes. If the server does not support ranking and a local fallback is
+// configured, the ranking is computed locally.
+func (c *Client) Rank(ctx context.Context, req *RankRequest) (*RankResponse, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	var resp RankResponse
+	err := c.meter(ctx, req.Pod, 1, func() (int, error) {
+		err := c.do(ctx, "POST", "/v1/rank", req, &resp)
+		return resp.CreditsUsed, err
+	})
+	if c.fallback != nil && errors.Is(err, ErrNotSupported) {
+		return c.fallback.Rank(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Rank computes personalized PageRank over the snapshot by power iteration.
+// Requests for another pod are rejected when the snapshot names its pod.
+func (w *OfflineWalker) Rank(ctx context.Context, req *RankRequest) (*RankResponse, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := w.checkPod(req.Pod); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	// restart distribution over the seeds' nodes
+	restart := make(map[string]float64)
+	total := 0.0
+	for i, p := range req.Phonemes {
+		ids := w.Resolve(p)
+		weight := 1.0
+		if len(req.Weights) > 0 {
+			weight = req.Weights[i]
+		}
+		for _, id := range ids {
+			restart[id] += weight / float64(len(ids))
+			total += weight / float64(len(ids))
+		}
+	}
+	if total <= 0 {
+		return &RankResponse{Nodes: []RankedNode{}}, nil
+	}
+	for id := range restart {
+		restart[id] /= total
+	}
+
+	rank, err := w.pagerank(ctx, restart, req.Damping)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]RankedNode, 0, len(rank))
+	for id, score := range rank {
+		if req.ExcludeSeeds && restart[id] > 0 {
+			continue
+		}
+		nodes = append(nodes, RankedNode{ID: id, Score: score})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Score != nodes[j].Score {
+			return nodes[i].Score > nodes[j].Score
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	if len(nodes) > req.Limit {
+		nodes = nodes[:req.Limit]
+	}
+
+	return &RankResponse{Nodes: nodes, LatencyUS: int(time.Since(start).Microseconds())}, nil
+}
+
+// pagerank runs personalized PageRank by power iteration. restart is the
+// restart distribution and must sum to one.
+func (w *OfflineWalker) pagerank(ctx context.Context, restart map[string]float64, damping float64) (map[string]float64, error) {
+	outWeight := make(map[string]float64, len(w.adj))
+	for id, arcs := range w.adj {
+		for _, a := range arcs {
+			outWeight[id] += a.weight
+		}
+	}
+
+	rank := make(map[string]float64, len(restart))
+	for id, v := range restart {
+		rank[id] = v
+	}
+	for iter := 0; iter < 100; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		next := make(map[string]float64, len(rank))
+		dangling := 0.0
+		for id, r := range rank {
+			if outWeight[id] == 0 {
+				dangling += r
+				continue
+			}
+			for _, a := range w.adj[id] {
+				next[a.to] += damping * r * a.weight / outWeight[id]
+			}
+		}
+		// teleport and mass from dangling nodes return to the seeds
+		for id, v := range restart {
+			next[id] += (1 - damping + damping*dangling) * v
+		}
+
+		delta := 0.0
+		for id, v := range next {
+			delta += math.Abs(v - rank[id])
+		}
+		for id, v := range rank {
+			if _, ok := next[id]; !ok {
+				delta += v
+			}
+		}
+		rank = next
+		if delta < 1e-9 {
+			break
+		}
+	}
+	return rank, nil
+}
@@ -0,0 +1,106 @@
+package mantr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOfflineRank(t *testing.T) {
+	tests := []struct {
+		name string
+		req  RankRequest
+		want []string
+	}{
+		{
+			name: "single seed",
+			req:  RankRequest{Phonemes: []string{"dharma"}},
+			want: []string{"dharma", "karma", "samsara", "moksa"},
+		},
+		{
+			name: "seeds excluded",
+			req:  RankRequest{Phonemes: []string{"dharma"}, ExcludeSeeds: true},
+			want: []string{"karma", "samsara", "moksa"},
+		},
+		{
+			name: "limit",
+			req:  RankRequest{Phonemes: []string{"dharma"}, Limit: 2},
+			want: []string{"dharma", "karma"},
+		},
+		{
+			// moksa outranks samsara, but both pass their mass on to
+			// the hubs dharma and karma
+			name: "weighted seeds",
+			req:  RankRequest{Phonemes: []string{"moksa", "samsara"}, Weights: []float64{3, 1}},
+			want: []string{"karma", "dharma", "moksa", "samsara"},
+		},
+		{
+			name: "zero weight",
+			req:  RankRequest{Phonemes: []string{"moksa", "samsara"}, Weights: []float64{1, 0}, Damping: 0.1},
+			want: []string{"moksa", "dharma", "karma", "samsara"},
+		},
+		{
+			name: "unknown phonemes",
+			req:  RankRequest{Phonemes: []string{"nirvana"}},
+			want: []string{},
+		},
+	}
+	w := NewOfflineWalker(testSnapshot())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := w.Rank(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			sum := 0.0
+			for i, n := range resp.Nodes {
+				ids = append(ids, n.ID)
+				sum += n.Score
+				if i > 0 && n.Score > resp.Nodes[i-1].Score {
+					t.Errorf("%s scored above %s", n.ID, resp.Nodes[i-1].ID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ranking = %v, want %v", ids, tt.want)
+			}
+			// with every node ranked, the scores are a distribution
+			if len(ids) == 4 && round4(sum) != 1 {
+				t.Errorf("scores sum to %v", sum)
+			}
+		})
+	}
+}
+
+func TestRankValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  RankRequest
+		want string
+	}{
+		{"no phonemes", RankRequest{}, "phonemes cannot be empty"},
+		{"weight count", RankRequest{Phonemes: []string{"karma"}, Weights: []float64{1, 2}}, "got 2 weights for 1 phonemes"},
+		{"negative weight", RankRequest{Phonemes: []string{"karma", "dharma"}, Weights: []float64{2, -1}}, "weights cannot be negative"},
+		{"zero weights", RankRequest{Phonemes: []string{"karma", "dharma"}, Weights: []float64{0, 0}}, "weights cannot all be zero"},
+		{"damping", RankRequest{Phonemes: []string{"karma"}, Damping: 1}, "damping must be in [0, 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankFallback(t *testing.T) {
+	testLocalFallback(t, func(c *Client, pod string) (int, error) {
+		resp, err := c.Rank(context.Background(), &RankRequest{Pod: pod, Phonemes: []string{"dharma"}})
+		if err != nil {
+			return 0, err
+		}
+		return len(resp.Nodes), nil
+	})
+}
@@ -0,0 +1,296 @@
+// Package rerank reorders externally retrieved documents by how close their
+// concepts lie to a query's concepts in the pod graph
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/entity"
+)
+
+// Fusion methods
+const (
+	// FusionLinear blends min-max normalized original scores with graph
+	// proximity (the default)
+	FusionLinear = "linear"
+	// FusionRRF combines the original and proximity rankings by reciprocal
+	// rank fusion, ignoring score scales
+	FusionRRF = "rrf"
+)
+
+// Aggregations of concept proximities into a document proximity
+const (
+	// AggregateMean averages over the document's concepts (the default), so
+	// documents mostly about unrelated concepts rank lower
+	AggregateMean = "mean"
+	// AggregateMax takes the document's closest concept
+	AggregateMax = "max"
+	// AggregateSum adds up concept proximities, favoring documents that
+	// mention many related concepts; sums can exceed 1, so pair it with
+	// FusionRRF
+	AggregateSum = "sum"
+)
+
+// Concept is a node a document mentions. Weight defaults to 1.
+type Concept struct {
+	Node   string  `json:"node"`
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Document is a candidate returned by another retriever with its original
+// score and linked concepts
+type Document struct {
+	ID       string    `json:"id"`
+	Score    float64   `json:"score"`
+	Concepts []Concept `json:"concepts"`
+}
+
+// FromSpans builds a document from entity linker output, weighting each node
+// by its summed mention confidence
+func FromSpans(id string, score float64, spans []entity.Span) Document {
+	doc := Document{ID: id, Score: score}
+	index := make(map[string]int)
+	for _, s := range spans {
+		i, ok := index[s.Node]
+		if !ok {
+			i = len(doc.Concepts)
+			index[s.Node] = i
+			doc.Concepts = append(doc.Concepts, Concept{Node: s.Node})
+		}
+		doc.Concepts[i].Weight += s.Confidence
+	}
+	return doc
+}
+
+// Graph holds the query's neighborhood: each node's hop distance from the
+// nearest query concept and its path score mass, scaled so the best node
+// has mass 1
+type Graph struct {
+	dist map[string]int
+	mass map[string]float64
+}
+
+// FromWalk builds a graph from a walk response. Paths start at the query's
+// seeds; a node's distance is its smallest position on a path and its mass
+// is the summed score of the paths through it.
+func FromWalk(resp *mantr.WalkResponse) *Graph {
+	g := &Graph{dist: make(map[string]int), mass: make(map[string]float64)}
+	for _, p := range resp.Paths {
+		seen := make(map[string]bool, len(p.Nodes))
+		for i, id := range p.Nodes {
+			key := mantr.Normalize(id)
+			if d, ok := g.dist[key]; !ok || i < d {
+				g.dist[key] = i
+			}
+			if !seen[key] {
+				seen[key] = true
+				g.mass[key] += p.Score
+			}
+		}
+	}
+	g.scale()
+	return g
+}
+
+// FromSubgraph builds a graph from a subgraph, such as one returned by
+// Client.ExportSubgraph, and the query's seed phonemes. Distances are hop
+// counts over edges in either direction and mass is personalized PageRank
+// from the seeds.
+func FromSubgraph(ctx context.Context, sub *mantr.Snapshot, seeds []string) (*Graph, error) {
+	w := mantr.NewOfflineWalker(sub)
+	var ids []string
+	for _, s := range seeds {
+		ids = append(ids, w.Resolve(s)...)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no seed found in subgraph")
+	}
+
+	adj := make(map[string][]string)
+	for _, e := range sub.Edges {
+		adj[e.From] = append(adj[e.From], e.To)
+		adj[e.To] = append(adj[e.To], e.From)
+	}
+	g := &Graph{dist: make(map[string]int), mass: make(map[string]float64)}
+	frontier := ids
+	for _, id := range ids {
+		g.dist[mantr.Normalize(id)] = 0
+	}
+	for hop := 1; len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, to := range adj[id] {
+				if _, ok := g.dist[mantr.Normalize(to)]; !ok {
+					g.dist[mantr.Normalize(to)] = hop
+					next = append(next, to)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	ranked, err := w.Rank(ctx, &mantr.RankRequest{Phonemes: seeds, Limit: len(sub.Nodes)})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range ranked.Nodes {
+		g.mass[mantr.Normalize(n.ID)] = n.Score
+	}
+	g.scale()
+	return g, nil
+}
+
+func (g *Graph) scale() {
+	top := 0.0
+	for _, m := range g.mass {
+		top = math.Max(top, m)
+	}
+	if top == 0 {
+		return
+	}
+	for k := range g.mass {
+		g.mass[k] /= top
+	}
+}
+
+// Options configures Rerank
+type Options struct {
+	// Fusion selects how original and graph scores are combined
+	// (default FusionLinear)
+	Fusion string
+	// Alpha is the weight of graph proximity against the original score,
+	// in (0, 1] (default 0.5)
+	Alpha float64
+	// K is the reciprocal rank fusion constant (default 60)
+	K int
+	// DistanceWeight mixes a concept's proximity from its inverse distance,
+	// 1/(1+hops), and its path score mass, in (0, 1] (default 0.5)
+	DistanceWeight float64
+	// Aggregate combines concept proximities (default AggregateMean)
+	Aggregate string
+}
+
+// Result is a reranked document. Distance is the fewest hops from a query
+// concept to any of the document's concepts, or -1 if none is in the graph.
+type Result struct {
+	ID        string   `json:"id"`
+	Score     float64  `json:"score"`
+	Original  float64  `json:"original"`
+	Proximity float64  `json:"proximity"`
+	Distance  int      `json:"distance"`
+	Matched   []string `json:"matched,omitempty"`
+}
+
+// Rerank scores every document's graph proximity to the query and fuses it
+// with the original score. Results are sorted by fused score.
+func Rerank(docs []Document, g *Graph, opts Options) ([]Result, error) {
+	if opts.Fusion == "" {
+		opts.Fusion = FusionLinear
+	}
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = 0.5
+	}
+	if opts.K <= 0 {
+		opts.K = 60
+	}
+	if opts.DistanceWeight <= 0 || opts.DistanceWeight > 1 {
+		opts.DistanceWeight = 0.5
+	}
+	switch opts.Aggregate {
+	case "":
+		opts.Aggregate = AggregateMean
+	case AggregateMean, AggregateMax, AggregateSum:
+	default:
+		return nil, fmt.Errorf("unknown aggregate %q", opts.Aggregate)
+	}
+
+	out := make([]Result, len(docs))
+	for i, d := range docs {
+		out[i] = g.proximity(d, opts)
+	}
+
+	switch opts.Fusion {
+	case FusionLinear:
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, r := range out {
+			lo, hi = math.Min(lo, r.Original), math.Max(hi, r.Original)
+		}
+		for i := range out {
+			norm := 1.0
+			if hi > lo {
+				norm = (out[i].Original - lo) / (hi - lo)
+			}
+			out[i].Score = (1-opts.Alpha)*norm + opts.Alpha*out[i].Proximity
+		}
+	case FusionRRF:
+		orig := ranks(out, func(r Result) float64 { return r.Original })
+		prox := ranks(out, func(r Result) float64 { return r.Proximity })
+		k := float64(opts.K)
+		for i := range out {
+			out[i].Score = (1-opts.Alpha)/(k+float64(orig[i])) + opts.Alpha/(k+float64(prox[i]))
+		}
+	default:
+		return nil, fmt.Errorf("unknown fusion %q", opts.Fusion)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out, nil
+}
+
+func (g *Graph) proximity(d Document, opts Options) Result {
+	r := Result{ID: d.ID, Original: d.Score, Distance: -1}
+	total, weights := 0.0, 0.0
+	for _, c := range d.Concepts {
+		w := c.Weight
+		if w == 0 {
+			w = 1
+		}
+		weights += w
+
+		key := mantr.Normalize(c.Node)
+		dist, ok := g.dist[key]
+		if !ok {
+			continue
+		}
+		r.Matched = append(r.Matched, c.Node)
+		if r.Distance < 0 || dist < r.Distance {
+			r.Distance = dist
+		}
+
+		p := opts.DistanceWeight/float64(1+dist) + (1-opts.DistanceWeight)*g.mass[key]
+		if opts.Aggregate == AggregateMax {
+			total = math.Max(total, p)
+		} else {
+			total += w * p
+		}
+	}
+
+	r.Proximity = total
+	if opts.Aggregate == AggregateMean && weights > 0 {
+		r.Proximity = total / weights
+	}
+	return r
+}
+
+// ranks returns each result's 1-based rank by score, ties sharing the best
+// rank
+func ranks(rs []Result, score func(Result) float64) []int {
+	order := make([]int, len(rs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return score(rs[order[a]]) > score(rs[order[b]]) })
+
+	out := make([]int, len(rs))
+	for pos, i := range order {
+		out[i] = pos + 1
+		if pos > 0 && score(rs[i]) == score(rs[order[pos-1]]) {
+			out[i] = out[order[pos-1]]
+		}
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+	"github.com/Mantrnet/go-sdk/entity"
+)
+
+// testGraph has karma at distance 0 with mass 1, and samsara and dharma one
+// hop away with masses 2/3 and 1/3
+func testGraph() *Graph {
+	return FromWalk(&mantr.WalkResponse{Paths: []mantr.PathResult{
+		{Nodes: []string{"karma", "samsara"}, Score: 1},
+		{Nodes: []string{"karma", "dharma"}, Score: 0.5},
+	}})
+}
+
+func testDocs() []Document {
+	return []Document{
+		{ID: "a", Score: 10, Concepts: []Concept{{Node: "samsara"}}},
+		{ID: "b", Score: 5, Concepts: []Concept{{Node: "Karma"}}},
+		{ID: "c", Score: 0, Concepts: []Concept{{Node: "yoga"}}},
+	}
+}
+
+func round4(v float64) float64 { return math.Round(v*1e4) / 1e4 }
+
+func TestFromWalk(t *testing.T) {
+	g := testGraph()
+	if want := map[string]int{"karma": 0, "samsara": 1, "dharma": 1}; !reflect.DeepEqual(g.dist, want) {
+		t.Errorf("dist = %v, want %v", g.dist, want)
+	}
+	if g.mass["karma"] != 1 || g.mass["samsara"] != 1/1.5 || g.mass["dharma"] != 0.5/1.5 {
+		t.Errorf("mass = %v", g.mass)
+	}
+}
+
+func TestFromSubgraph(t *testing.T) {
+	sub := &mantr.Snapshot{
+		Pod:   "dharma",
+		Nodes: []mantr.Node{{ID: "karma"}, {ID: "dharma"}, {ID: "moksa"}, {ID: "samsara"}},
+		Edges: []mantr.Edge{
+			{From: "karma", To: "samsara", Weight: 0.9},
+			{From: "dharma", To: "karma", Weight: 0.7},
+			{From: "dharma", To: "moksa", Weight: 0.5},
+		},
+	}
+	g, err := FromSubgraph(context.Background(), sub, []string{"karma"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// edges count in both directions
+	if want := map[string]int{"karma": 0, "samsara": 1, "dharma": 1, "moksa": 2}; !reflect.DeepEqual(g.dist, want) {
+		t.Errorf("dist = %v, want %v", g.dist, want)
+	}
+	top := 0.0
+	for _, m := range g.mass {
+		top = math.Max(top, m)
+	}
+	if top != 1 || g.mass["moksa"] >= g.mass["dharma"] {
+		t.Errorf("mass = %v", g.mass)
+	}
+
+	if _, err := FromSubgraph(context.Background(), sub, []string{"yoga"}); err == nil {
+		t.Error("FromSubgraph without a resolvable seed succeeded")
+	}
+}
+
+func TestRerank(t *testing.T) {
+	// proximity is 1 for b's karma, 1/4 + 1/3 for a's samsara and 0 for c
+	tests := []struct {
+		name   string
+		opts   Options
+		order  []string
+		scores []float64
+	}{
+		{"linear", Options{}, []string{"a", "b", "c"}, []float64{0.7917, 0.75, 0}},
+		{"graph only", Options{Alpha: 1}, []string{"b", "a", "c"}, []float64{1, 0.5833, 0}},
+		{"distance only", Options{Alpha: 1, DistanceWeight: 1}, []string{"b", "a", "c"}, []float64{1, 0.5, 0}},
+		// a and b swap ranks, so equal weights tie and keep the input order
+		{"rrf tie", Options{Fusion: FusionRRF}, []string{"a", "b", "c"}, []float64{0.0163, 0.0163, 0.0159}},
+		{"rrf", Options{Fusion: FusionRRF, Alpha: 0.8}, []string{"b", "a", "c"}, []float64{0.0163, 0.0162, 0.0159}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Rerank(testDocs(), testGraph(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var order []string
+			var scores []float64
+			for _, r := range res {
+				order = append(order, r.ID)
+				scores = append(scores, round4(r.Score))
+			}
+			if !reflect.DeepEqual(order, tt.order) || !reflect.DeepEqual(scores, tt.scores) {
+				t.Errorf("order %v scores %v, want %v %v", order, scores, tt.order, tt.scores)
+			}
+		})
+	}
+}
+
+func TestRerankResult(t *testing.T) {
+	res, err := Rerank(testDocs(), testGraph(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Result{ID: "b", Score: 0.75, Original: 5, Proximity: 1, Distance: 0, Matched: []string{"Karma"}}
+	if !reflect.DeepEqual(res[1], want) {
+		t.Errorf("result = %+v, want %+v", res[1], want)
+	}
+	if res[2].Distance != -1 || res[2].Matched != nil {
+		t.Errorf("unmatched result = %+v", res[2])
+	}
+}
+
+func TestRerankAggregate(t *testing.T) {
+	doc := Document{ID: "d", Concepts: []Concept{{Node: "karma"}, {Node: "yoga", Weight: 3}}}
+	tests := []struct {
+		aggregate string
+		want      float64
+	}{
+		{"", 0.25},
+		{AggregateMean, 0.25},
+		{AggregateMax, 1},
+		{AggregateSum, 1},
+	}
+	for _, tt := range tests {
+		res, err := Rerank([]Document{doc}, testGraph(), Options{Aggregate: tt.aggregate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res[0].Proximity != tt.want {
+			t.Errorf("%q proximity = %v, want %v", tt.aggregate, res[0].Proximity, tt.want)
+		}
+	}
+
+	if _, err := Rerank(testDocs(), testGraph(), Options{Aggregate: "median"}); err == nil {
+		t.Error("unknown aggregate accepted")
+	}
+	if _, err := Rerank(testDocs(), testGraph(), Options{Fusion: "borda"}); err == nil {
+		t.Error("unknown fusion accepted")
+	}
+}
+
+func TestFromSpans(t *testing.T) {
+	doc := FromSpans("a", 2, []entity.Span{
+		{Node: "karma", Confidence: 1},
+		{Node: "samsara", Confidence: 0.75},
+		{Node: "karma", Confidence: 0.5},
+	})
+	want := Document{ID: "a", Score: 2, Concepts: []Concept{{Node: "karma", Weight: 1.5}, {Node: "samsara", Weight: 0.75}}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %+v, want %+v", doc, want)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	rs := []Result{{Score: 3}, {Score: 1}, {Score: 3}, {Score: 2}}
+	if got := ranks(rs, func(r Result) float64 { return r.Score }); !reflect.DeepEqual(got, []int{1, 4, 1, 3}) {
+		t.Errorf("ranks = %v", got)
+	}
+}
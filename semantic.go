@@ -0,0 +1,359 @@
+package mantr
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Semantic cache correctness modes, from the most to the least conservative
+const (
+	// SemanticStrict serves a cached walk only for the same phonemes after
+	// normalization, in any order. Results match a live call unless the pod
+	// changed within the TTL.
+	SemanticStrict = "strict"
+	// SemanticLemma (the default) also equates plural and singular forms,
+	// ignores stopwords and punctuation and splits multi-word phonemes into
+	// words, so "karma dharma", "dharma, karma" and "karmas and dharma"
+	// share an entry. Misses are walked with the concept set as phonemes so
+	// every request sharing an entry gets the same answer. Results differ
+	// from a live call with the original phonemes when a surface form
+	// resolves to other nodes than its lemma, for example through a plural
+	// alias, or when a multi-word phoneme names a single node.
+	SemanticLemma = "lemma"
+	// SemanticCover also answers top-path walks from a cached walk that is
+	// deeper, longer or seeded by more concepts, keeping only the paths the
+	// narrower request would return. Besides the cases of SemanticLemma,
+	// results differ when path scores depend on the rest of the response,
+	// or when a seed was resolved through a label or alias whose words
+	// differ from its node ID, in which case the cache falls back to a live
+	// call rather than guess.
+	SemanticCover = "cover"
+)
+
+// SemanticOptions configures a SemanticCache
+type SemanticOptions struct {
+	// Mode selects how loosely requests are matched (default SemanticLemma)
+	Mode string
+	// Size is the most walks kept (default 1000)
+	Size int
+	// TTL is how long a walk is served (default five minutes)
+	TTL time.Duration
+}
+
+// SemanticStats counts how requests were answered
+type SemanticStats struct {
+	Hits      int `json:"hits"`
+	CoverHits int `json:"cover_hits"`
+	Misses    int `json:"misses"`
+}
+
+// SemanticCache wraps a Walker and serves walks by the concepts they ask
+// about rather than by exact request. Entries are scoped by pod, walk mode,
+// direction and edge type filters. Sampled walks are only served for the
+// same concepts in the same order, seed and sample settings, and connecting
+// walks never from a broader walk.
+type SemanticCache struct {
+	walker Walker
+	opts   SemanticOptions
+
+	mu     sync.Mutex
+	order  *list.List
+	scopes map[string]map[*list.Element]bool
+	stats  SemanticStats
+}
+
+type semanticEntry struct {
+	scope    string
+	concepts []string
+	depth    int
+	limit    int
+	resp     *WalkResponse
+	expires  time.Time
+}
+
+// NewSemanticCache wraps w with a semantic cache
+func NewSemanticCache(w Walker, opts SemanticOptions) (*SemanticCache, error) {
+	switch opts.Mode {
+	case "":
+		opts.Mode = SemanticLemma
+	case SemanticStrict, SemanticLemma, SemanticCover:
+	default:
+		return nil, fmt.Errorf("unsupported semantic cache mode %q", opts.Mode)
+	}
+	if opts.Size <= 0 {
+		opts.Size = 1000
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	return &SemanticCache{
+		walker: w,
+		opts:   opts,
+		order:  list.New(),
+		scopes: make(map[string]map[*list.Element]bool),
+	}, nil
+}
+
+// WalkContext serves the walk from the cache when a cached walk answers it,
+// and otherwise walks and caches the result
+func (s *SemanticCache) WalkContext(ctx context.Context, req *WalkRequest) (*WalkResponse, error) {
+	if len(req.Phonemes) == 0 {
+		return nil, fmt.Errorf("phonemes cannot be empty")
+	}
+	req.setDefaults()
+	scope := semanticScope(req)
+	concepts := s.concepts(req)
+
+	if resp := s.lookup(scope, concepts, req); resp != nil {
+		return resp, nil
+	}
+
+	walk := req
+	if s.opts.Mode != SemanticStrict {
+		canonical := *req
+		canonical.Phonemes = concepts
+		walk = &canonical
+	}
+	resp, err := s.walker.WalkContext(ctx, walk)
+	if err != nil {
+		return nil, err
+	}
+	s.store(&semanticEntry{
+		scope:    scope,
+		concepts: concepts,
+		depth:    req.Depth,
+		limit:    req.Limit,
+		resp:     copyResponse(resp),
+		expires:  time.Now().Add(s.opts.TTL),
+	})
+	return resp, nil
+}
+
+// Stats returns the cache's counters
+func (s *SemanticCache) Stats() SemanticStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// concepts returns the request's concept set: sorted and without
+// duplicates, except for sampled walks, whose seed order matters
+func (s *SemanticCache) concepts(req *WalkRequest) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(c string) {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	for _, p := range req.Phonemes {
+		if s.opts.Mode == SemanticStrict {
+			add(Normalize(p))
+			continue
+		}
+		words := conceptWords(p)
+		if len(words) == 0 {
+			add(Normalize(p))
+		}
+		for _, w := range words {
+			add(w)
+		}
+	}
+	if req.Mode != ModeSample {
+		sort.Strings(out)
+	}
+	return out
+}
+
+// conceptWords returns the lemmas of the words of s that are not stopwords
+func conceptWords(s string) []string {
+	var words []string
+	for _, t := range Tokenize(s) {
+		if !IsStopword(t.Text) {
+			words = append(words, Lemma(t.Text))
+		}
+	}
+	return words
+}
+
+// semanticScope identifies the settings a cached walk must share with a
+// request, other than its concepts, depth and limit
+func semanticScope(req *WalkRequest) string {
+	mode, dir := req.Mode, req.Direction
+	if mode == "" {
+		mode = ModeTopPaths
+	}
+	if dir == "" {
+		dir = DirectionBoth
+	}
+	types := append([]string(nil), req.EdgeTypes...)
+	exclude := append([]string(nil), req.ExcludeEdgeTypes...)
+	sort.Strings(types)
+	sort.Strings(exclude)
+
+	parts := []string{req.Pod, mode, dir, strings.Join(types, ","), strings.Join(exclude, ",")}
+	if mode == ModeSample {
+		parts = append(parts,
+			strconv.Itoa(req.Samples),
+			strconv.FormatFloat(req.RestartProbability, 'g', -1, 64),
+			strconv.FormatInt(req.Seed, 10))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// lookup returns a copy of a cached walk answering the request, or nil
+func (s *SemanticCache) lookup(scope string, concepts []string, req *WalkRequest) *WalkResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var cover []PathResult
+	var coverEl *list.Element
+	for el := range s.scopes[scope] {
+		e := el.Value.(*semanticEntry)
+		if now.After(e.expires) {
+			s.remove(el)
+			continue
+		}
+		if e.depth == req.Depth && e.limit == req.Limit && equalStrings(e.concepts, concepts) {
+			s.order.MoveToFront(el)
+			s.stats.Hits++
+			return served(e.resp, e.resp.Paths)
+		}
+		if cover == nil && s.opts.Mode == SemanticCover && (req.Mode == "" || req.Mode == ModeTopPaths) {
+			if paths, ok := e.cover(concepts, req.Depth, req.Limit); ok {
+				cover, coverEl = paths, el
+			}
+		}
+	}
+	if coverEl != nil {
+		s.order.MoveToFront(coverEl)
+		s.stats.CoverHits++
+		return served(coverEl.Value.(*semanticEntry).resp, cover)
+	}
+	s.stats.Misses++
+	return nil
+}
+
+// cover returns the paths a narrower top-path walk would return, if the
+// entry is guaranteed to contain them. Top paths are ranked by score and
+// scores never increase along a path, so the best paths of at most depth
+// edges from a subset of the seeds are the first such paths of the broader
+// walk, provided it returned at least limit of them or was not truncated.
+func (e *semanticEntry) cover(concepts []string, depth, limit int) ([]PathResult, bool) {
+	if e.depth < depth || e.limit < limit || len(concepts) > len(e.concepts) {
+		return nil, false
+	}
+	have := make(map[string]bool, len(e.concepts))
+	for _, c := range e.concepts {
+		have[c] = true
+	}
+	want := make(map[string]bool, len(concepts))
+	for _, c := range concepts {
+		if !have[c] {
+			return nil, false
+		}
+		want[c] = true
+	}
+	subset := len(concepts) < len(e.concepts)
+
+	paths := []PathResult{}
+	matched := make(map[string]bool)
+	for _, p := range e.resp.Paths {
+		if p.Depth > depth || len(p.Nodes) == 0 {
+			continue
+		}
+		if subset {
+			words := conceptWords(p.Nodes[0])
+			ok := len(words) > 0
+			for _, w := range words {
+				ok = ok && want[w]
+			}
+			if !ok {
+				continue
+			}
+			for _, w := range words {
+				matched[w] = true
+			}
+		}
+		paths = append(paths, p)
+	}
+	// a concept whose seed cannot be recognized by its node ID may have
+	// paths that were filtered out
+	if subset && len(matched) < len(want) {
+		return nil, false
+	}
+
+	if len(paths) >= limit {
+		return paths[:limit], true
+	}
+	return paths, len(e.resp.Paths) < e.limit
+}
+
+// store adds an entry, replacing one for the same request and evicting the
+// least recently used entry when the cache is full
+func (s *SemanticCache) store(e *semanticEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for el := range s.scopes[e.scope] {
+		old := el.Value.(*semanticEntry)
+		if old.depth == e.depth && old.limit == e.limit && equalStrings(old.concepts, e.concepts) {
+			s.remove(el)
+		}
+	}
+	el := s.order.PushFront(e)
+	if s.scopes[e.scope] == nil {
+		s.scopes[e.scope] = make(map[*list.Element]bool)
+	}
+	s.scopes[e.scope][el] = true
+
+	if s.order.Len() > s.opts.Size {
+		s.remove(s.order.Back())
+	}
+}
+
+// remove deletes an entry. s.mu must be held.
+func (s *SemanticCache) remove(el *list.Element) {
+	e := el.Value.(*semanticEntry)
+	s.order.Remove(el)
+	delete(s.scopes[e.scope], el)
+	if len(s.scopes[e.scope]) == 0 {
+		delete(s.scopes, e.scope)
+	}
+}
+
+// served returns a copy of a cached response with the given paths, charged
+// no credits
+func served(resp *WalkResponse, paths []PathResult) *WalkResponse {
+	out := *resp
+	out.Paths = copyPaths(paths)
+	out.CreditsUsed = 0
+	out.FromCache = true
+	return &out
+}
+
+func copyResponse(resp *WalkResponse) *WalkResponse {
+	out := *resp
+	out.Paths = copyPaths(resp.Paths)
+	return &out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
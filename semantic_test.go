@@ -0,0 +1,62 @@
+package mantr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSemanticCacheCopies(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+	}{
+		{"strict", SemanticStrict},
+		{"lemma", SemanticLemma},
+		{"cover", SemanticCover},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewOfflineWalker(testSnapshot())
+			s, err := NewSemanticCache(w, SemanticOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			uncached, err := w.WalkContext(ctx, &WalkRequest{Pod: "dharma", Phonemes: []string{"dharma"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			walk := func() *WalkResponse {
+				resp, err := s.WalkContext(ctx, &WalkRequest{Pod: "dharma", Phonemes: []string{"dharma"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(resp.Paths) == 0 {
+					t.Fatal("walk returned no paths")
+				}
+				return resp
+			}
+
+			// modifying the live response must not change the cached entry
+			live := walk()
+			live.Paths[0].Nodes[0] = "changed"
+			live.Paths[0].EdgeTypes = append(live.Paths[0].EdgeTypes[:0], "changed")
+
+			// nor modifying a served copy the next one
+			first := walk()
+			first.Paths[0].Nodes[0] = "changed"
+			second := walk()
+
+			if !first.FromCache || !second.FromCache {
+				t.Fatal("repeated walks were not served from the cache")
+			}
+			if !reflect.DeepEqual(second.Paths, uncached.Paths) {
+				t.Errorf("served paths = %+v, want %+v", second.Paths, uncached.Paths)
+			}
+			if stats := s.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+				t.Errorf("stats = %+v", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package mantr
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// similarityBatchSize is the most pairs sent in one similarity request
+const similarityBatchSize = 500
+
+// SimilarityRequest asks how related each pair of concepts is
+type SimilarityRequest struct {
+	Pairs [][2]string `json:"pairs"`
+	Pod   string      `json:"pod,omitempty"`
+	// Depth bounds the walks used when similarity is derived from walk
+	// scores (default 3, as for walks)
+	Depth int `json:"depth,omitempty"`
+	// MaxCredits, when set, rejects the request before any call if its
+	// estimate exceeds this
+	MaxCredits int `json:"-"`
+}
+
+// SimilarityResponse holds a score in [0, 1] per requested pair, in order
+type SimilarityResponse struct {
+	Scores      []float64 `json:"scores"`
+	CreditsUsed int       `json:"credits_used"`
+	RequestID   string    `json:"request_id,omitempty"`
+
+	// Cached counts pairs served from the client's similarity cache
+	Cached int `json:"-"`
+	// Derived is set when scores were derived from walks because the
+	// server does not support similarity requests
+	Derived bool `json:"-"`
+}
+
+// SimilarityEstimate is the expected cost of a similarity request. Credits
+// assumes the worst case, that every uncached pair is derived from walks:
+// one walk per distinct concept.
+type SimilarityEstimate struct {
+	Pairs    int `json:"pairs"`
+	Cached   int `json:"cached"`
+	Requests int `json:"requests"`
+	Walks    int `json:"walks"`
+	Credits  int `json:"credits"`
+}
+
+// SimilarityMatrix holds the pairwise similarity of a list of concepts.
+// Scores is symmetric with ones on the diagonal.
+type SimilarityMatrix struct {
+	Concepts    []string    `json:"concepts"`
+	Scores      [][]float64 `json:"scores"`
+	CreditsUsed int         `json:"credits_used"`
+	Derived     bool        `json:"derived,omitempty"`
+}
+
+// Similarity returns how related two concepts are in the default pod, from
+// 0 (unrelated) to 1 (the same concept)
+func (c *Client) Similarity(ctx context.Context, a, b string) (float64, error) {
+	resp, err := c.Similarities(ctx, &SimilarityRequest{Pairs: [][2]string{{a, b}}})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Scores[0], nil
+}
+
+// SimilarityMatrix scores every pair of concepts in the default pod. Each
+// unordered pair is requested once; use EstimateSimilarity with
+// MatrixPairs to check the cost first, or Similarities with the same pairs
+// for another pod.
+func (c *Client) SimilarityMatrix(ctx context.Context, concepts []string) (*SimilarityMatrix, error) {
+	pairs := MatrixPairs(concepts)
+	m := &SimilarityMatrix{Concepts: concepts, Scores: make([][]float64, len(concepts))}
+	for i := range m.Scores {
+		m.Scores[i] = make([]float64, len(concepts))
+		m.Scores[i][i] = 1
+	}
+	if len(pairs) == 0 {
+		return m, nil
+	}
+
+	resp, err := c.Similarities(ctx, &SimilarityRequest{Pairs: pairs})
+	if err != nil {
+		return nil, err
+	}
+	k := 0
+	for i := range concepts {
+		for j := i + 1; j < len(concepts); j++ {
+			m.Scores[i][j], m.Scores[j][i] = resp.Scores[k], resp.Scores[k]
+			k++
+		}
+	}
+	m.CreditsUsed, m.Derived = resp.CreditsUsed, resp.Derived
+	return m, nil
+}
+
+// MatrixPairs lists the pairs (i, j), i < j, of concepts in row order
+func MatrixPairs(concepts []string) [][2]string {
+	var pairs [][2]string
+	for i := range concepts {
+		for j := i + 1; j < len(concepts); j++ {
+			pairs = append(pairs, [2]string{concepts[i], concepts[j]})
+		}
+	}
+	return pairs
+}
+
+// EstimateSimilarity returns the expected cost of a request without making
+// any calls
+func (c *Client) EstimateSimilarity(req *SimilarityRequest) SimilarityEstimate {
+	plan := c.planSimilarities(req)
+	est := SimilarityEstimate{
+		Pairs:    len(req.Pairs),
+		Cached:   plan.cached,
+		Requests: (len(plan.pairs) + similarityBatchSize - 1) / similarityBatchSize,
+	}
+	for _, x := range conceptsOf(plan.pairs) {
+		est.Walks++
+		est.Credits += c.estimate(similarityWalk(req, x))
+	}
+	return est
+}
+
+// Similarities scores concept pairs. Pairs are normalized and unordered, so
+// (a, b) and (B, a) are requested once and share a cache entry. Uncached
+// pairs are sent to the server in batches. If the server does not support
+// similarity requests, scores are derived from walks, one per concept: a
+// pair's score is the best score of a path from either concept that reaches
+// the other, through the local fallback when one is configured. Derived
+// scores are not cached.
+func (c *Client) Similarities(ctx context.Context, req *SimilarityRequest) (*SimilarityResponse, error) {
+	if len(req.Pairs) == 0 {
+		return nil, fmt.Errorf("pairs cannot be empty")
+	}
+	if req.MaxCredits > 0 {
+		if est := c.EstimateSimilarity(req); est.Credits > req.MaxCredits {
+			return nil, fmt.Errorf("%w: estimated %d credits, allowed %d", ErrCreditLimit, est.Credits, req.MaxCredits)
+		}
+	}
+
+	plan := c.planSimilarities(req)
+	resp := &SimilarityResponse{Scores: plan.scores, Cached: plan.cached}
+	if len(plan.pairs) == 0 {
+		return resp, nil
+	}
+
+	err := c.similarityBatches(ctx, req, plan, resp)
+	if errors.Is(err, ErrNotSupported) {
+		resp.Derived = true
+		err = c.derivedSimilarities(ctx, req, plan, resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// similarityPlan splits a request into pairs answered locally, identical
+// concepts and cache hits, and the distinct pairs still to be scored
+type similarityPlan struct {
+	scores []float64
+	cached int
+	// pairs and keys list the distinct uncached pairs in request order;
+	// pending maps each key to the request indices it answers
+	pairs   [][2]string
+	keys    []string
+	pending map[string][]int
+}
+
+func (c *Client) planSimilarities(req *SimilarityRequest) *similarityPlan {
+	plan := &similarityPlan{scores: make([]float64, len(req.Pairs)), pending: make(map[string][]int)}
+	for i, p := range req.Pairs {
+		if Normalize(p[0]) == Normalize(p[1]) {
+			plan.scores[i] = 1
+			continue
+		}
+		key := similarityKey(req, p[0], p[1])
+		if s, ok := c.sims.get(key); ok {
+			plan.scores[i] = s
+			plan.cached++
+			continue
+		}
+		if _, ok := plan.pending[key]; !ok {
+			plan.pairs = append(plan.pairs, p)
+			plan.keys = append(plan.keys, key)
+		}
+		plan.pending[key] = append(plan.pending[key], i)
+	}
+	return plan
+}
+
+// answer sets the score of the k-th uncached pair at every request index it
+// answers
+func (plan *similarityPlan) answer(resp *SimilarityResponse, k int, score float64) {
+	for _, i := range plan.pending[plan.keys[k]] {
+		resp.Scores[i] = score
+	}
+}
+
+// conceptsOf lists the distinct concepts of pairs, by normalized form
+func conceptsOf(pairs [][2]string) []string {
+	seen := make(map[string]bool)
+	var concepts []string
+	for _, p := range pairs {
+		for _, x := range p {
+			if n := Normalize(x); !seen[n] {
+				seen[n] = true
+				concepts = append(concepts, x)
+			}
+		}
+	}
+	return concepts
+}
+
+// similarityKey identifies an unordered, normalized pair in a pod
+func similarityKey(req *SimilarityRequest, a, b string) string {
+	a, b = Normalize(a), Normalize(b)
+	if b < a {
+		a, b = b, a
+	}
+	depth := req.Depth
+	if depth <= 0 {
+		depth = 3
+	}
+	return fmt.Sprintf("%s\x00%d\x00%s\x00%s", req.Pod, depth, a, b)
+}
+
+// similarityBatches sends the plan's pairs to the server in batches. Each
+// batch is metered with the cost of the walks it replaces and cached as soon
+// as it returns, so the credits spent on it are not lost if a later batch
+// fails.
+func (c *Client) similarityBatches(ctx context.Context, req *SimilarityRequest, plan *similarityPlan, resp *SimilarityResponse) error {
+	for start := 0; start < len(plan.pairs); start += similarityBatchSize {
+		batch := plan.pairs[start:min(start+similarityBatchSize, len(plan.pairs))]
+		sub := &SimilarityRequest{Pairs: batch, Pod: req.Pod, Depth: req.Depth}
+		estimate := 0
+		for _, x := range conceptsOf(batch) {
+			estimate += c.estimate(similarityWalk(req, x))
+		}
+
+		var out SimilarityResponse
+		err := c.meter(ctx, req.Pod, estimate, func() (int, error) {
+			err := c.do(ctx, "POST", "/v1/similarity", sub, &out)
+			return out.CreditsUsed, err
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Scores) != len(batch) {
+			return fmt.Errorf("server returned %d scores for %d pairs", len(out.Scores), len(batch))
+		}
+		for k, score := range out.Scores {
+			c.sims.set(plan.keys[start+k], score)
+			plan.answer(resp, start+k, score)
+		}
+		resp.CreditsUsed += out.CreditsUsed
+		resp.RequestID = out.RequestID
+	}
+	return nil
+}
+
+// derivedSimilarities scores the plan's pairs from one walk per concept.
+// Derived scores are not cached so they never answer for the server.
+func (c *Client) derivedSimilarities(ctx context.Context, req *SimilarityRequest, plan *similarityPlan, resp *SimilarityResponse) error {
+	var w Walker = c
+	if c.fallback != nil {
+		w = c.fallback
+	}
+
+	reach := make(map[string]map[string]float64)
+	walk := func(x string) (map[string]float64, error) {
+		key := Normalize(x)
+		if r, ok := reach[key]; ok {
+			return r, nil
+		}
+		out, err := w.WalkContext(ctx, similarityWalk(req, x))
+		if err != nil {
+			return nil, fmt.Errorf("walk %q: %w", x, err)
+		}
+		resp.CreditsUsed += out.CreditsUsed
+
+		r := make(map[string]float64)
+		for _, p := range out.Paths {
+			for _, id := range p.Nodes[min(1, len(p.Nodes)):] {
+				n := Normalize(id)
+				r[n] = math.Max(r[n], p.Score)
+			}
+		}
+		reach[key] = r
+		return r, nil
+	}
+
+	for k, p := range plan.pairs {
+		ra, err := walk(p[0])
+		if err != nil {
+			return err
+		}
+		rb, err := walk(p[1])
+		if err != nil {
+			return err
+		}
+		s := math.Max(ra[Normalize(p[1])], rb[Normalize(p[0])])
+		plan.answer(resp, k, math.Min(s, 1))
+	}
+	return nil
+}
+
+// similarityWalk is the walk used to derive similarities for a concept
+func similarityWalk(req *SimilarityRequest, concept string) *WalkRequest {
+	return &WalkRequest{Phonemes: []string{concept}, Pod: req.Pod, Depth: req.Depth, Limit: 100}
+}
+
+// WithSimilarityCache sets how many pair scores the client caches and for
+// how long (default 4096 for ten minutes); a size of zero disables caching
+func WithSimilarityCache(size int, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.sims = newSimilarityCache(size, ttl)
+	}
+}
+
+// similarityCache is an LRU cache of pair scores with a time to live. A nil
+// cache stores nothing.
+type similarityCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type similarityItem struct {
+	key     string
+	score   float64
+	expires time.Time
+}
+
+func newSimilarityCache(size int, ttl time.Duration) *similarityCache {
+	if size <= 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &similarityCache{size: size, ttl: ttl, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (s *similarityCache) get(key string) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return 0, false
+	}
+	it := el.Value.(*similarityItem)
+	if time.Now().After(it.expires) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		return 0, false
+	}
+	s.order.MoveToFront(el)
+	return it.score, true
+}
+
+func (s *similarityCache) set(key string, score float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires := time.Now().Add(s.ttl)
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*similarityItem).score = score
+		el.Value.(*similarityItem).expires = expires
+		s.order.MoveToFront(el)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&similarityItem{key: key, score: score, expires: expires})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*similarityItem).key)
+	}
+}
@@ -0,0 +1,167 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSimilaritiesFallback(t *testing.T) {
+	testLocalFallback(t, func(c *Client, pod string) (int, error) {
+		resp, err := c.Similarities(context.Background(), &SimilarityRequest{Pod: pod, Pairs: [][2]string{{"dharma", "karma"}}})
+		if err != nil {
+			return 0, err
+		}
+		if !resp.Derived {
+			return 0, fmt.Errorf("scores were not derived")
+		}
+		n := 0
+		for _, s := range resp.Scores {
+			if s > 0 {
+				n++
+			}
+		}
+		return n, nil
+	})
+}
+
+func TestDerivedSimilarities(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer server.Close()
+	client, err := NewClient("vak_test", WithBaseURL(server.URL), WithLocalFallback(NewOfflineWalker(testSnapshot())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs := [][2]string{{"dharma", "samsara"}, {"Samsara", "dharma"}, {"moksa", "dharma"}, {"karma", "Karma"}, {"moksa", "yoga"}}
+	tests := []struct {
+		depth int
+		want  []float64
+	}{
+		// a pair scores the best path from either concept reaching the
+		// other, where paths score the product of weights over the heaviest
+		{3, []float64{0.7778, 0.7778, 0.5556, 1, 0}},
+		// samsara is two hops from dharma
+		{1, []float64{0, 0, 0.5556, 1, 0}},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ {
+			resp, err := client.Similarities(context.Background(), &SimilarityRequest{Pod: "dharma", Depth: tt.depth, Pairs: pairs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []float64
+			for _, s := range resp.Scores {
+				got = append(got, round4(s))
+			}
+			if !reflect.DeepEqual(got, tt.want) || !resp.Derived || resp.Cached != 0 {
+				t.Errorf("depth %d call %d: scores %v, derived %v, cached %d, want %v", tt.depth, i, got, resp.Derived, resp.Cached, tt.want)
+			}
+		}
+	}
+	// derived scores are not cached, so the server is asked each time
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Errorf("server called %d times, want 4", n)
+	}
+}
+
+func TestSimilarityBatches(t *testing.T) {
+	var concepts []string
+	for i := 0; i < 33; i++ {
+		concepts = append(concepts, fmt.Sprintf("c%02d", i))
+	}
+	pairs := MatrixPairs(concepts)
+
+	var sizes []int
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SimilarityRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sizes = append(sizes, len(req.Pairs))
+		if len(req.Pairs) < similarityBatchSize && fail {
+			fail = false
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := SimilarityResponse{Scores: make([]float64, len(req.Pairs)), CreditsUsed: len(req.Pairs)}
+		for i := range resp.Scores {
+			resp.Scores[i] = 0.5
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	client, err := NewClient("vak_test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &SimilarityRequest{Pairs: pairs}
+	if _, err := client.Similarities(context.Background(), req); err == nil {
+		t.Fatal("failing batch was not reported")
+	}
+	// the first batch was paid for, so only the failed one is sent again
+	resp, err := client.Similarities(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sizes, []int{500, 28, 28}) || resp.Cached != 500 || resp.CreditsUsed != 28 {
+		t.Errorf("batch sizes %v, cached %d, credits %d", sizes, resp.Cached, resp.CreditsUsed)
+	}
+	for i, s := range resp.Scores {
+		if s != 0.5 {
+			t.Fatalf("score %d = %v", i, s)
+		}
+	}
+}
+
+func TestSimilarityMatrix(t *testing.T) {
+	var got [][2]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SimilarityRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		got = req.Pairs
+		// each pair scores a tenth of its position so misplaced scores show
+		resp := SimilarityResponse{CreditsUsed: 3}
+		for i := range req.Pairs {
+			resp.Scores = append(resp.Scores, float64(i+1)/10)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	client, err := NewClient("vak_test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := client.SimilarityMatrix(context.Background(), []string{"karma", "dharma", "moksa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]string{{"karma", "dharma"}, {"karma", "moksa"}, {"dharma", "moksa"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pairs = %v, want %v", got, want)
+	}
+	want := [][]float64{
+		{1, 0.1, 0.2},
+		{0.1, 1, 0.3},
+		{0.2, 0.3, 1},
+	}
+	if !reflect.DeepEqual(m.Scores, want) || m.CreditsUsed != 3 || m.Derived {
+		t.Errorf("matrix = %+v, want scores %v", m, want)
+	}
+
+	// a single concept needs no request
+	got = nil
+	m, err = client.SimilarityMatrix(context.Background(), []string{"karma"})
+	if err != nil || got != nil || !reflect.DeepEqual(m.Scores, [][]float64{{1}}) {
+		t.Errorf("single concept matrix = %+v, %v, pairs %v", m, err, got)
+	}
+}
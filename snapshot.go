@@ -0,0 +1,119 @@
+package mantr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Node is a concept in a pod graph
+type Node struct {
+	ID         string            `json:"id"`
+	Label      string            `json:"label,omitempty"`
+	Aliases    []string          `json:"aliases,omitempty"`
+	Attrs      map[string]string `json:"attrs,omitempty"`
+	Provenance []Provenance      `json:"provenance,omitempty"`
+}
+
+// Edge is a weighted relation between two nodes
+type Edge struct {
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Weight     float64           `json:"weight"`
+	Type       string            `json:"type,omitempty"`
+	Attrs      map[string]string `json:"attrs,omitempty"`
+	Provenance []Provenance      `json:"provenance,omitempty"`
+}
+
+// Provenance records where a node or edge was derived from
+type Provenance struct {
+	Source string `json:"source"`
+	Offset int    `json:"offset"`
+}
+
+// Snapshot is a point-in-time copy of a pod graph
+type Snapshot struct {
+	Pod     string `json:"pod,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Nodes   []Node `json:"nodes"`
+	Edges   []Edge `json:"edges"`
+}
+
+// ReadSnapshot decodes a JSON snapshot
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snap Snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// LoadSnapshot reads a JSON snapshot from a file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSnapshot(f)
+}
+
+// Write encodes the snapshot as JSON
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Save writes the snapshot to a file
+func (s *Snapshot) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sort orders nodes by ID and edges by endpoints and type
+func (s *Snapshot) Sort() {
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].ID < s.Nodes[j].ID })
+	sort.Slice(s.Edges, func(i, j int) bool {
+		a, b := s.Edges[i], s.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+}
+
+// Digest returns a SHA-256 of the snapshot's nodes and edges that does not
+// depend on their order or on the pod name and version
+func (s *Snapshot) Digest() string {
+	sorted := Snapshot{
+		Nodes: append([]Node(nil), s.Nodes...),
+		Edges: append([]Edge(nil), s.Edges...),
+	}
+	sorted.Sort()
+
+	h := sha256.New()
+	json.NewEncoder(h).Encode(sorted)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Key returns the identity of an edge: its endpoints and type
+func (e Edge) Key() EdgeKey {
+	return EdgeKey{From: e.From, To: e.To, Type: e.Type}
+}
@@ -0,0 +1,183 @@
+package mantr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SubgraphRequest asks for the neighborhood of a set of seed phonemes
+type SubgraphRequest struct {
+	Seeds []string `json:"seeds"`
+	Pod   string   `json:"pod,omitempty"`
+	// Radius is the maximum number of hops from a seed
+	Radius int `json:"radius"`
+	// MaxNodes caps the subgraph size; the least important nodes are
+	// pruned first (default 500)
+	MaxNodes int `json:"max_nodes,omitempty"`
+	// PageSize is the number of nodes and edges returned per page
+	PageSize int    `json:"page_size,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+}
+
+// SubgraphPage is one page of a subgraph
+type SubgraphPage struct {
+	Nodes       []Node `json:"nodes"`
+	Edges       []Edge `json:"edges"`
+	Version     int64  `json:"version,omitempty"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	CreditsUsed int    `json:"credits_used"`
+}
+
+func (req *SubgraphRequest) validate() error {
+	if len(req.Seeds) == 0 {
+		return fmt.Errorf("seeds cannot be empty")
+	}
+	if req.Radius < 0 {
+		return fmt.Errorf("radius cannot be negative")
+	}
+	if req.MaxNodes == 0 {
+		req.MaxNodes = 500
+	}
+	return nil
+}
+
+// Subgraph returns the nodes within radius hops of the seeds in the default
+// pod and the edges between them, keeping at most maxNodes
+func (c *Client) Subgraph(ctx context.Context, seeds []string, radius, maxNodes int) (*Snapshot, error) {
+	return c.ExportSubgraph(ctx, &SubgraphRequest{Seeds: seeds, Radius: radius, MaxNodes: maxNodes})
+}
+
+// ExportSubgraph fetches every page of a subgraph. If the server does not
+// support subgraphs and a local fallback is configured, the subgraph is
+// extracted locally.
+func (c *Client) ExportSubgraph(ctx context.Context, req *SubgraphRequest) (*Snapshot, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	page := *req
+	snap := &Snapshot{Pod: req.Pod}
+	for {
+		resp, err := c.SubgraphPage(ctx, &page)
+		if page.Cursor == "" && c.fallback != nil && errors.Is(err, ErrNotSupported) {
+			return c.fallback.ExportSubgraph(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		snap.Nodes = append(snap.Nodes, resp.Nodes...)
+		snap.Edges = append(snap.Edges, resp.Edges...)
+		snap.Version = resp.Version
+		if resp.NextCursor == "" {
+			return snap, nil
+		}
+		page.Cursor = resp.NextCursor
+	}
+}
+
+// SubgraphPage fetches one page of a subgraph. Pass the previous page's
+// NextCursor as the request's Cursor to fetch the next one.
+func (c *Client) SubgraphPage(ctx context.Context, req *SubgraphRequest) (*SubgraphPage, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	var resp SubgraphPage
+	err := c.meter(ctx, req.Pod, 1, func() (int, error) {
+		err := c.do(ctx, "POST", "/v1/subgraph", req, &resp)
+		return resp.CreditsUsed, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Subgraph extracts the nodes within radius hops of the seeds and the edges
+// between them. When more than maxNodes are in range, nodes are kept by
+// personalized PageRank from the seeds, so weakly connected nodes on the
+// fringe are pruned first. Seeds are always kept.
+func (w *OfflineWalker) Subgraph(ctx context.Context, seeds []string, radius, maxNodes int) (*Snapshot, error) {
+	return w.ExportSubgraph(ctx, &SubgraphRequest{Seeds: seeds, Radius: radius, MaxNodes: maxNodes})
+}
+
+// ExportSubgraph extracts a subgraph like Subgraph. Requests for another pod
+// are rejected when the snapshot names its pod.
+func (w *OfflineWalker) ExportSubgraph(ctx context.Context, req *SubgraphRequest) (*Snapshot, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := w.checkPod(req.Pod); err != nil {
+		return nil, err
+	}
+
+	ids := w.seeds(req.Seeds)
+	dist := make(map[string]int, len(ids))
+	frontier := ids
+	for _, id := range ids {
+		dist[id] = 0
+	}
+	for hop := 1; hop <= req.Radius && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, a := range w.adj[id] {
+				if _, ok := dist[a.to]; !ok {
+					dist[a.to] = hop
+					next = append(next, a.to)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	keep := make(map[string]bool, len(dist))
+	if len(dist) <= req.MaxNodes {
+		for id := range dist {
+			keep[id] = true
+		}
+	} else {
+		restart := make(map[string]float64, len(ids))
+		for _, id := range ids {
+			restart[id] = 1 / float64(len(ids))
+		}
+		rank, err := w.pagerank(ctx, restart, 0.85)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]string, 0, len(dist))
+		for id := range dist {
+			candidates = append(candidates, id)
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if (dist[a] == 0) != (dist[b] == 0) {
+				return dist[a] == 0
+			}
+			if rank[a] != rank[b] {
+				return rank[a] > rank[b]
+			}
+			return a < b
+		})
+		for _, id := range candidates[:max(req.MaxNodes, len(ids))] {
+			keep[id] = true
+		}
+	}
+
+	sub := &Snapshot{Pod: w.snap.Pod, Version: w.snap.Version}
+	for _, n := range w.snap.Nodes {
+		if keep[n.ID] {
+			sub.Nodes = append(sub.Nodes, n)
+		}
+	}
+	for _, e := range w.snap.Edges {
+		if keep[e.From] && keep[e.To] {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+	sub.Sort()
+	return sub, nil
+}
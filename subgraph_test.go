@@ -0,0 +1,105 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExportSubgraphPages(t *testing.T) {
+	pages := map[string]SubgraphPage{
+		"": {
+			Nodes:      []Node{{ID: "karma"}, {ID: "samsara"}},
+			Edges:      []Edge{{From: "karma", To: "samsara", Weight: 0.9}},
+			NextCursor: "p2",
+		},
+		"p2": {
+			Nodes:   []Node{{ID: "dharma"}},
+			Edges:   []Edge{{From: "dharma", To: "karma", Weight: 0.7}},
+			Version: 4,
+		},
+	}
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SubgraphRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.PageSize != 2 || req.MaxNodes != 500 {
+			t.Errorf("request = %+v", req)
+		}
+		cursors = append(cursors, req.Cursor)
+		json.NewEncoder(w).Encode(pages[req.Cursor])
+	}))
+	defer server.Close()
+
+	client, err := NewClient("vak_test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := client.ExportSubgraph(context.Background(), &SubgraphRequest{
+		Pod: "dharma", Seeds: []string{"karma"}, Radius: 1, PageSize: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Snapshot{
+		Pod:     "dharma",
+		Version: 4,
+		Nodes:   []Node{{ID: "karma"}, {ID: "samsara"}, {ID: "dharma"}},
+		Edges:   []Edge{{From: "karma", To: "samsara", Weight: 0.9}, {From: "dharma", To: "karma", Weight: 0.7}},
+	}
+	if !reflect.DeepEqual(sub, want) {
+		t.Errorf("subgraph = %+v, want %+v", sub, want)
+	}
+	if !reflect.DeepEqual(cursors, []string{"", "p2"}) {
+		t.Errorf("cursors = %q", cursors)
+	}
+}
+
+func TestOfflineSubgraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		seeds    []string
+		radius   int
+		maxNodes int
+		nodes    []string
+		edges    int
+	}{
+		{"seed only", []string{"karma"}, 0, 0, []string{"karma"}, 0},
+		{"one hop", []string{"karma"}, 1, 0, []string{"dharma", "karma", "samsara"}, 2},
+		{"whole graph", []string{"karma"}, 2, 0, []string{"dharma", "karma", "moksa", "samsara"}, 3},
+		// dharma outranks samsara from karma, so samsara is pruned
+		{"pruned", []string{"karma"}, 1, 2, []string{"dharma", "karma"}, 1},
+		// seeds are kept even past the cap
+		{"seeds kept", []string{"moksa", "samsara"}, 1, 1, []string{"moksa", "samsara"}, 0},
+	}
+	w := NewOfflineWalker(testSnapshot())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := w.Subgraph(context.Background(), tt.seeds, tt.radius, tt.maxNodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, n := range sub.Nodes {
+				ids = append(ids, n.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.nodes) || len(sub.Edges) != tt.edges {
+				t.Errorf("subgraph = %+v, want nodes %v and %d edges", sub, tt.nodes, tt.edges)
+			}
+		})
+	}
+}
+
+func TestExportSubgraphFallback(t *testing.T) {
+	testLocalFallback(t, func(c *Client, pod string) (int, error) {
+		sub, err := c.ExportSubgraph(context.Background(), &SubgraphRequest{Pod: pod, Seeds: []string{"karma"}, Radius: 1})
+		if err != nil {
+			return 0, err
+		}
+		return len(sub.Nodes), nil
+	})
+}
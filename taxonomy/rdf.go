@@ -0,0 +1,65 @@
+package taxonomy
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Namespaces used by the importer
+const (
+	rdfNS  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfsNS = "http://www.w3.org/2000/01/rdf-schema#"
+	owlNS  = "http://www.w3.org/2002/07/owl#"
+	skosNS = "http://www.w3.org/2004/02/skos/core#"
+	xmlNS  = "http://www.w3.org/XML/1998/namespace"
+
+	rdfType  = rdfNS + "type"
+	rdfFirst = rdfNS + "first"
+	rdfRest  = rdfNS + "rest"
+	rdfNil   = rdfNS + "nil"
+)
+
+// Triple is an RDF statement. Blank node subjects and objects start with
+// "_:".
+type Triple struct {
+	Subject   string
+	Predicate string
+	Object    Term
+}
+
+// Term is the object of a triple: an IRI, a blank node or a literal
+type Term struct {
+	Value    string
+	Literal  bool
+	Lang     string
+	Datatype string
+}
+
+// resolve resolves a relative IRI against a base IRI
+func resolve(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	out := b.ResolveReference(r).String()
+	// an empty fragment is dropped by url.URL but ends many namespace IRIs
+	if strings.HasSuffix(ref, "#") && !strings.HasSuffix(out, "#") {
+		out += "#"
+	}
+	return out
+}
+
+// localName returns the part of an IRI after its last '#' or '/'
+func localName(iri string) string {
+	if i := strings.LastIndexAny(iri, "#/"); i >= 0 && i < len(iri)-1 {
+		return iri[i+1:]
+	}
+	return iri
+}
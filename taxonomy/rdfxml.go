@@ -0,0 +1,213 @@
+package taxonomy
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseRDFXML parses RDF in RDF/XML syntax. It supports typed and
+// rdf:Description node elements, rdf:about, rdf:ID and rdf:nodeID, property
+// attributes, resource and literal property elements, nested node elements
+// and rdf:parseType="Resource", as produced by common SKOS and OWL tools.
+func ParseRDFXML(r io.Reader) ([]Triple, error) {
+	p := &xmlParser{dec: xml.NewDecoder(r)}
+	for {
+		tok, err := p.dec.Token()
+		if err == io.EOF {
+			return p.triples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rdf/xml: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		base := p.base(start, "")
+		if name(start.Name) == rdfNS+"RDF" {
+			if err := p.nodeElements(base); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// a document may hold a single node element without rdf:RDF
+		if _, err := p.nodeElement(start, base); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type xmlParser struct {
+	dec     *xml.Decoder
+	blanks  int
+	triples []Triple
+}
+
+func name(n xml.Name) string {
+	return n.Space + n.Local
+}
+
+func (p *xmlParser) base(el xml.StartElement, inherited string) string {
+	for _, a := range el.Attr {
+		if a.Name.Space == xmlNS && a.Name.Local == "base" {
+			return a.Value
+		}
+	}
+	return inherited
+}
+
+func lang(el xml.StartElement, inherited string) string {
+	for _, a := range el.Attr {
+		if a.Name.Space == xmlNS && a.Name.Local == "lang" {
+			return strings.ToLower(a.Value)
+		}
+	}
+	return inherited
+}
+
+func (p *xmlParser) newBlank() string {
+	p.blanks++
+	return "_:x" + strconv.Itoa(p.blanks)
+}
+
+// nodeElements reads node elements until the enclosing end element
+func (p *xmlParser) nodeElements(base string) error {
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return fmt.Errorf("rdf/xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if _, err := p.nodeElement(t, p.base(t, base)); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// nodeElement reads a node element whose start tag has been consumed and
+// returns its subject
+func (p *xmlParser) nodeElement(el xml.StartElement, base string) (string, error) {
+	subject := ""
+	var props []xml.Attr
+	for _, a := range el.Attr {
+		switch {
+		case a.Name.Space == rdfNS && a.Name.Local == "about":
+			subject = resolve(base, a.Value)
+		case a.Name.Space == rdfNS && a.Name.Local == "ID":
+			subject = resolve(base, "#"+a.Value)
+		case a.Name.Space == rdfNS && a.Name.Local == "nodeID":
+			subject = "_:n" + a.Value
+		case a.Name.Space == xmlNS, a.Name.Space == "xmlns", a.Name.Space == "" && a.Name.Local == "xmlns":
+		default:
+			props = append(props, a)
+		}
+	}
+	if subject == "" {
+		subject = p.newBlank()
+	}
+
+	if name(el.Name) != rdfNS+"Description" {
+		p.triples = append(p.triples, Triple{Subject: subject, Predicate: rdfType, Object: Term{Value: name(el.Name)}})
+	}
+	elLang := lang(el, "")
+	for _, a := range props {
+		if name(a.Name) == rdfType {
+			p.triples = append(p.triples, Triple{Subject: subject, Predicate: rdfType, Object: Term{Value: resolve(base, a.Value)}})
+			continue
+		}
+		p.triples = append(p.triples, Triple{Subject: subject, Predicate: name(a.Name), Object: Term{Value: a.Value, Literal: true, Lang: elLang}})
+	}
+
+	return subject, p.propertyElements(subject, base, elLang)
+}
+
+// propertyElements reads the property elements of subject until the node
+// element's end tag
+func (p *xmlParser) propertyElements(subject, base, inheritedLang string) error {
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return fmt.Errorf("rdf/xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := p.propertyElement(subject, t, p.base(t, base), lang(t, inheritedLang)); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (p *xmlParser) propertyElement(subject string, el xml.StartElement, base, elLang string) error {
+	predicate := name(el.Name)
+	var resource, datatype, parseType string
+	for _, a := range el.Attr {
+		if a.Name.Space != rdfNS {
+			continue
+		}
+		switch a.Name.Local {
+		case "resource":
+			resource = resolve(base, a.Value)
+		case "nodeID":
+			resource = "_:n" + a.Value
+		case "datatype":
+			datatype = a.Value
+		case "parseType":
+			parseType = a.Value
+		}
+	}
+
+	if parseType == "Resource" {
+		node := p.newBlank()
+		p.triples = append(p.triples, Triple{Subject: subject, Predicate: predicate, Object: Term{Value: node}})
+		return p.propertyElements(node, base, elLang)
+	}
+
+	var text strings.Builder
+	var object string
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return fmt.Errorf("rdf/xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			if parseType == "Literal" {
+				if err := p.dec.Skip(); err != nil {
+					return fmt.Errorf("rdf/xml: %w", err)
+				}
+				continue
+			}
+			if object, err = p.nodeElement(t, p.base(t, base)); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			switch {
+			case resource != "":
+				p.triples = append(p.triples, Triple{Subject: subject, Predicate: predicate, Object: Term{Value: resource}})
+			case object != "":
+				p.triples = append(p.triples, Triple{Subject: subject, Predicate: predicate, Object: Term{Value: object}})
+			default:
+				p.triples = append(p.triples, Triple{Subject: subject, Predicate: predicate, Object: Term{
+					Value:    strings.TrimSpace(text.String()),
+					Literal:  true,
+					Lang:     elLang,
+					Datatype: datatype,
+				}})
+			}
+			return nil
+		}
+	}
+}
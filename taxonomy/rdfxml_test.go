@@ -0,0 +1,113 @@
+package taxonomy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const xmlHeader = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+         xmlns:rdfs="http://www.w3.org/2000/01/rdf-schema#"
+         xmlns:skos="http://www.w3.org/2004/02/skos/core#"
+         xmlns:ex="http://example.org/"
+         xml:base="http://example.org/">
+`
+
+func TestParseRDFXML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Triple
+	}{
+		{
+			name: "typed node element",
+			src:  `<skos:Concept rdf:about="karma"><skos:broader rdf:resource="action"/></skos:Concept>`,
+			want: []Triple{
+				iri(ex+"karma", rdfType, skosNS+"Concept"),
+				iri(ex+"karma", skosNS+"broader", ex+"action"),
+			},
+		},
+		{
+			name: "description with rdf:type and rdf:ID",
+			src:  `<rdf:Description rdf:ID="karma"><rdf:type rdf:resource="http://www.w3.org/2002/07/owl#Class"/></rdf:Description>`,
+			want: []Triple{iri(ex+"#karma", rdfType, owlNS+"Class")},
+		},
+		{
+			name: "literals with languages and datatypes",
+			src: `<rdf:Description rdf:about="karma" xml:lang="EN">
+  <skos:prefLabel>Karma</skos:prefLabel>
+  <skos:altLabel xml:lang="sa">कर्म</skos:altLabel>
+  <ex:since rdf:datatype="http://www.w3.org/2001/XMLSchema#gYear">1900</ex:since>
+</rdf:Description>`,
+			want: []Triple{
+				lit(ex+"karma", skosNS+"prefLabel", Term{Value: "Karma", Lang: "en"}),
+				lit(ex+"karma", skosNS+"altLabel", Term{Value: "कर्म", Lang: "sa"}),
+				lit(ex+"karma", ex+"since", Term{Value: "1900", Lang: "en", Datatype: "http://www.w3.org/2001/XMLSchema#gYear"}),
+			},
+		},
+		{
+			name: "property attributes",
+			src:  `<rdf:Description rdf:about="karma" skos:notation="K1" xml:lang="en"/>`,
+			want: []Triple{lit(ex+"karma", skosNS+"notation", Term{Value: "K1", Lang: "en"})},
+		},
+		{
+			name: "nested node elements and node IDs",
+			src: `<rdf:Description rdf:about="karma">
+  <skos:related><skos:Concept rdf:about="dharma"/></skos:related>
+  <ex:restriction rdf:nodeID="r1"/>
+</rdf:Description>
+<rdf:Description rdf:nodeID="r1"><ex:on rdf:resource="action"/></rdf:Description>`,
+			want: []Triple{
+				iri(ex+"dharma", rdfType, skosNS+"Concept"),
+				iri(ex+"karma", skosNS+"related", ex+"dharma"),
+				iri(ex+"karma", ex+"restriction", "_:nr1"),
+				iri("_:nr1", ex+"on", ex+"action"),
+			},
+		},
+		{
+			name: "parseType Resource",
+			src:  `<rdf:Description rdf:about="karma"><ex:source rdf:parseType="Resource"><ex:title>Gita</ex:title></ex:source></rdf:Description>`,
+			want: []Triple{
+				iri(ex+"karma", ex+"source", "_:x1"),
+				lit("_:x1", ex+"title", Term{Value: "Gita"}),
+			},
+		},
+		{
+			name: "anonymous node element",
+			src:  `<skos:Concept><skos:prefLabel>anon</skos:prefLabel></skos:Concept>`,
+			want: []Triple{
+				iri("_:x1", rdfType, skosNS+"Concept"),
+				lit("_:x1", skosNS+"prefLabel", Term{Value: "anon"}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRDFXML(strings.NewReader(xmlHeader + tt.src + "\n</rdf:RDF>"))
+			if err != nil {
+				t.Fatalf("ParseRDFXML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRDFXMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"unclosed element", xmlHeader + `<rdf:Description rdf:about="karma">`},
+		{"mismatched tags", xmlHeader + `<rdf:Description rdf:about="karma"></skos:Concept></rdf:RDF>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRDFXML(strings.NewReader(tt.src)); err == nil || !strings.HasPrefix(err.Error(), "rdf/xml:") {
+				t.Errorf("ParseRDFXML error = %v, want an rdf/xml error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package taxonomy imports SKOS thesauri and OWL class hierarchies, written in
+// Turtle or RDF/XML, into Mantr pod graphs
+package taxonomy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+// Edge types produced by the importer
+const (
+	EdgeBroader    = "broader"
+	EdgeNarrower   = "narrower"
+	EdgeRelated    = "related"
+	EdgeSubClassOf = "subClassOf"
+)
+
+// DefaultWeights are the edge weights used for types missing from
+// Options.Weights
+var DefaultWeights = map[string]float64{
+	EdgeBroader:    1,
+	EdgeNarrower:   1,
+	EdgeRelated:    0.5,
+	EdgeSubClassOf: 1,
+}
+
+// relations maps RDF predicates to edge types
+var relations = map[string]string{
+	skosNS + "broader":    EdgeBroader,
+	skosNS + "narrower":   EdgeNarrower,
+	skosNS + "related":    EdgeRelated,
+	rdfsNS + "subClassOf": EdgeSubClassOf,
+}
+
+// Options configures an Importer
+type Options struct {
+	// Weights sets the weight of each edge type; a negative weight drops
+	// the relation (default DefaultWeights)
+	Weights map[string]float64
+	// Language is the preferred label language (default "en")
+	Language string
+	// ID maps a concept IRI to a node ID (default the IRI's local name)
+	ID func(iri string) string
+}
+
+// Importer collects RDF statements and turns them into a pod snapshot.
+// Concepts (skos:Concept, owl:Class and anything linked by a mapped
+// relation) become nodes; skos:prefLabel or rdfs:label becomes the label and
+// other labels become aliases.
+type Importer struct {
+	opts    Options
+	triples []Triple
+}
+
+// NewImporter creates an importer
+func NewImporter(opts Options) *Importer {
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.ID == nil {
+		opts.ID = localName
+	}
+	return &Importer{opts: opts}
+}
+
+// AddFile parses a Turtle (.ttl) or RDF/XML (.rdf, .owl, .xml) file. Other
+// extensions are detected from the content.
+func (im *Importer) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var triples []Triple
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttl", ".turtle":
+		triples, err = ParseTurtle(bytes.NewReader(data))
+	case ".rdf", ".owl", ".xml":
+		triples, err = ParseRDFXML(bytes.NewReader(data))
+	default:
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<?xml")) || bytes.Contains(data, []byte("<rdf:RDF")) {
+			triples, err = ParseRDFXML(bytes.NewReader(data))
+		} else {
+			triples, err = ParseTurtle(bytes.NewReader(data))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	im.Add(triples)
+	return nil
+}
+
+// Add adds parsed statements
+func (im *Importer) Add(triples []Triple) {
+	im.triples = append(im.triples, triples...)
+}
+
+// concept accumulates the labels and attributes of one IRI
+type concept struct {
+	iri    string
+	labels []Term // skos:prefLabel
+	names  []Term // rdfs:label
+	alts   []Term // skos:altLabel and skos:hiddenLabel
+	attrs  map[string]string
+}
+
+// Snapshot converts the collected statements into a pod snapshot. It fails
+// if two concept IRIs map to the same node ID.
+func (im *Importer) Snapshot(pod string) (*mantr.Snapshot, error) {
+	concepts := make(map[string]*concept)
+	get := func(iri string) *concept {
+		c, ok := concepts[iri]
+		if !ok {
+			c = &concept{iri: iri, attrs: map[string]string{"iri": iri}}
+			concepts[iri] = c
+		}
+		return c
+	}
+	// only typed or linked IRIs become nodes, in order of first mention
+	var order []string
+	marked := make(map[string]bool)
+	mark := func(iri string) {
+		if !marked[iri] {
+			marked[iri] = true
+			order = append(order, iri)
+		}
+	}
+
+	type link struct {
+		from, to, typ string
+	}
+	var links []link
+	for _, t := range im.triples {
+		if strings.HasPrefix(t.Subject, "_:") {
+			continue
+		}
+		switch {
+		case t.Predicate == rdfType && !t.Object.Literal:
+			if t.Object.Value == skosNS+"Concept" || t.Object.Value == owlNS+"Class" || t.Object.Value == rdfsNS+"Class" {
+				mark(t.Subject)
+			}
+		case relations[t.Predicate] != "":
+			// restrictions and other anonymous classes are not concepts
+			if t.Object.Literal || strings.HasPrefix(t.Object.Value, "_:") || t.Object.Value == owlNS+"Thing" {
+				continue
+			}
+			typ := relations[t.Predicate]
+			if im.weight(typ) < 0 {
+				continue
+			}
+			mark(t.Subject)
+			mark(t.Object.Value)
+			links = append(links, link{from: t.Subject, to: t.Object.Value, typ: typ})
+		case t.Object.Literal:
+			im.literal(t, get)
+		}
+	}
+
+	snap := &mantr.Snapshot{Pod: pod, Nodes: []mantr.Node{}, Edges: []mantr.Edge{}}
+	ids := make(map[string]string, len(concepts))
+	owner := make(map[string]string, len(concepts))
+	for _, iri := range order {
+		c := get(iri)
+		id := im.opts.ID(iri)
+		if prev, ok := owner[id]; ok {
+			return nil, fmt.Errorf("concepts %s and %s both map to node %q", prev, iri, id)
+		}
+		owner[id] = iri
+		ids[iri] = id
+		snap.Nodes = append(snap.Nodes, im.node(id, c))
+	}
+
+	seen := make(map[mantr.EdgeKey]bool)
+	for _, l := range links {
+		e := mantr.Edge{From: ids[l.from], To: ids[l.to], Type: l.typ, Weight: im.weight(l.typ)}
+		if e.From == e.To || seen[e.Key()] {
+			continue
+		}
+		seen[e.Key()] = true
+		snap.Edges = append(snap.Edges, e)
+	}
+
+	snap.Sort()
+	return snap, nil
+}
+
+// literal records a label or documentation property of an IRI
+func (im *Importer) literal(t Triple, get func(string) *concept) {
+	switch t.Predicate {
+	case skosNS + "prefLabel":
+		c := get(t.Subject)
+		c.labels = append(c.labels, t.Object)
+	case rdfsNS + "label":
+		c := get(t.Subject)
+		c.names = append(c.names, t.Object)
+	case skosNS + "altLabel", skosNS + "hiddenLabel":
+		c := get(t.Subject)
+		c.alts = append(c.alts, t.Object)
+	case skosNS + "definition", rdfsNS + "comment":
+		c := get(t.Subject)
+		if _, ok := c.attrs["definition"]; !ok || t.Object.Lang == im.opts.Language {
+			c.attrs["definition"] = t.Object.Value
+		}
+	case skosNS + "notation":
+		get(t.Subject).attrs["notation"] = t.Object.Value
+	}
+}
+
+func (im *Importer) weight(typ string) float64 {
+	if w, ok := im.opts.Weights[typ]; ok {
+		return w
+	}
+	return DefaultWeights[typ]
+}
+
+// node picks the label in the preferred language, falling back to an
+// untagged label and then to any label, and keeps the rest as aliases
+func (im *Importer) node(id string, c *concept) mantr.Node {
+	n := mantr.Node{ID: id, Attrs: c.attrs}
+
+	candidates := c.labels
+	if len(candidates) == 0 {
+		candidates = c.names
+	}
+	rank := func(l Term) int {
+		switch l.Lang {
+		case im.opts.Language:
+			return 2
+		case "":
+			return 1
+		}
+		return 0
+	}
+	best := -1
+	for i, l := range candidates {
+		if best < 0 || rank(l) > rank(candidates[best]) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		n.Label = candidates[best].Value
+	}
+
+	seen := map[string]bool{n.Label: true, id: true}
+	for _, group := range [][]Term{c.labels, c.names, c.alts} {
+		for _, l := range group {
+			if !seen[l.Value] {
+				seen[l.Value] = true
+				n.Aliases = append(n.Aliases, l.Value)
+			}
+		}
+	}
+	sort.Strings(n.Aliases)
+	return n
+}
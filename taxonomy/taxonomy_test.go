@@ -0,0 +1,132 @@
+package taxonomy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	mantr "github.com/Mantrnet/go-sdk"
+)
+
+const thesaurus = `@prefix skos: <http://www.w3.org/2004/02/skos/core#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+@prefix owl: <http://www.w3.org/2002/07/owl#> .
+@prefix ex: <http://example.org/> .
+
+ex:karma a skos:Concept ;
+    skos:prefLabel "Karma"@en , "कर्म"@sa ;
+    skos:altLabel "kamma" ;
+    skos:definition "Action and its consequences"@en ;
+    skos:broader ex:action ;
+    skos:related ex:dharma .
+
+ex:dharma a skos:Concept ; skos:prefLabel "Dharma" .
+ex:action rdfs:label "action"@en .
+ex:Duty rdfs:subClassOf ex:action , owl:Thing , [ a owl:Restriction ] .
+`
+
+func TestImporterSnapshot(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		nodes []string
+		edges []mantr.Edge
+	}{
+		{
+			name:  "default weights",
+			nodes: []string{"Duty", "action", "dharma", "karma"},
+			edges: []mantr.Edge{
+				{From: "Duty", To: "action", Type: EdgeSubClassOf, Weight: 1},
+				{From: "karma", To: "action", Type: EdgeBroader, Weight: 1},
+				{From: "karma", To: "dharma", Type: EdgeRelated, Weight: 0.5},
+			},
+		},
+		{
+			name:  "dropped relation",
+			opts:  Options{Weights: map[string]float64{EdgeRelated: -1, EdgeBroader: 2}},
+			nodes: []string{"Duty", "action", "dharma", "karma"},
+			edges: []mantr.Edge{
+				{From: "Duty", To: "action", Type: EdgeSubClassOf, Weight: 1},
+				{From: "karma", To: "action", Type: EdgeBroader, Weight: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triples, err := ParseTurtle(strings.NewReader(thesaurus))
+			if err != nil {
+				t.Fatal(err)
+			}
+			im := NewImporter(tt.opts)
+			im.Add(triples)
+			snap, err := im.Snapshot("concepts")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, n := range snap.Nodes {
+				ids = append(ids, n.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.nodes) {
+				t.Errorf("nodes = %v, want %v", ids, tt.nodes)
+			}
+			if !reflect.DeepEqual(snap.Edges, tt.edges) {
+				t.Errorf("edges = %+v, want %+v", snap.Edges, tt.edges)
+			}
+		})
+	}
+}
+
+func TestImporterLabels(t *testing.T) {
+	tests := []struct {
+		language string
+		label    string
+		aliases  []string
+	}{
+		{"en", "Karma", []string{"kamma", "कर्म"}},
+		{"sa", "कर्म", []string{"Karma", "kamma"}},
+		{"de", "Karma", []string{"kamma", "कर्म"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			triples, err := ParseTurtle(strings.NewReader(thesaurus))
+			if err != nil {
+				t.Fatal(err)
+			}
+			im := NewImporter(Options{Language: tt.language})
+			im.Add(triples)
+			snap, err := im.Snapshot("concepts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var n mantr.Node
+			for _, node := range snap.Nodes {
+				if node.ID == "karma" {
+					n = node
+				}
+			}
+			if n.Label != tt.label || !reflect.DeepEqual(n.Aliases, tt.aliases) {
+				t.Errorf("label %q aliases %v, want %q %v", n.Label, n.Aliases, tt.label, tt.aliases)
+			}
+			if n.Attrs["definition"] != "Action and its consequences" || n.Attrs["iri"] != ex+"karma" {
+				t.Errorf("attrs = %v", n.Attrs)
+			}
+		})
+	}
+}
+
+func TestImporterDuplicateIDs(t *testing.T) {
+	src := `@prefix skos: <http://www.w3.org/2004/02/skos/core#> .
+<http://a.example/karma> a skos:Concept .
+<http://b.example/karma> a skos:Concept .`
+	triples, err := ParseTurtle(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := NewImporter(Options{})
+	im.Add(triples)
+	if _, err := im.Snapshot("concepts"); err == nil || !strings.Contains(err.Error(), `both map to node "karma"`) {
+		t.Errorf("Snapshot error = %v, want a duplicate node error", err)
+	}
+}
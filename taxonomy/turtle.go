@@ -0,0 +1,515 @@
+package taxonomy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseTurtle parses RDF in Turtle syntax. It supports prefixes, base IRIs,
+// predicate and object lists, blank nodes, collections and all literal forms,
+// which covers SKOS and OWL files as commonly published.
+func ParseTurtle(r io.Reader) ([]Triple, error) {
+	data, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	p := &turtleParser{src: []rune(string(data)), line: 1, prefixes: make(map[string]string)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.triples, nil
+}
+
+type turtleParser struct {
+	src      []rune
+	pos      int
+	line     int
+	base     string
+	prefixes map[string]string
+	blanks   int
+	triples  []Triple
+}
+
+func (p *turtleParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("turtle line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *turtleParser) parse() error {
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil
+		}
+
+		switch {
+		case p.keyword("@prefix"):
+			if err := p.prefixDirective(true); err != nil {
+				return err
+			}
+		case p.keyword("PREFIX"):
+			if err := p.prefixDirective(false); err != nil {
+				return err
+			}
+		case p.keyword("@base"):
+			if err := p.baseDirective(true); err != nil {
+				return err
+			}
+		case p.keyword("BASE"):
+			if err := p.baseDirective(false); err != nil {
+				return err
+			}
+		default:
+			subject, err := p.subject()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			// a bracketed blank node may stand alone
+			if p.peek() != '.' {
+				if err := p.predicateObjectList(subject); err != nil {
+					return err
+				}
+			}
+			if err := p.expect('.'); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// keyword consumes a directive keyword followed by white space. SPARQL-style
+// directives are case-insensitive, @-directives are not.
+func (p *turtleParser) keyword(kw string) bool {
+	end := p.pos + len(kw)
+	if end >= len(p.src) || !unicode.IsSpace(p.src[end]) {
+		return false
+	}
+	word := string(p.src[p.pos:end])
+	if word != kw && (kw[0] == '@' || !strings.EqualFold(word, kw)) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+// prefixDirective reads the rest of a prefix declaration. Only @prefix is
+// terminated by a dot.
+func (p *turtleParser) prefixDirective(dotted bool) error {
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() && p.peek() != ':' {
+		p.pos++
+	}
+	name := strings.TrimSpace(string(p.src[start:p.pos]))
+	if err := p.expect(':'); err != nil {
+		return err
+	}
+	p.skipSpace()
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.prefixes[name] = iri
+	if !dotted {
+		return nil
+	}
+	return p.expect('.')
+}
+
+func (p *turtleParser) baseDirective(dotted bool) error {
+	p.skipSpace()
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.base = iri
+	if !dotted {
+		return nil
+	}
+	return p.expect('.')
+}
+
+func (p *turtleParser) predicateObjectList(subject string) error {
+	for {
+		p.skipSpace()
+		predicate, err := p.verb()
+		if err != nil {
+			return err
+		}
+		for {
+			object, err := p.object()
+			if err != nil {
+				return err
+			}
+			p.triples = append(p.triples, Triple{Subject: subject, Predicate: predicate, Object: object})
+			p.skipSpace()
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+
+		if p.peek() != ';' {
+			return nil
+		}
+		for p.peek() == ';' {
+			p.pos++
+			p.skipSpace()
+		}
+		if c := p.peek(); c == '.' || c == ']' || p.eof() {
+			return nil
+		}
+	}
+}
+
+func (p *turtleParser) verb() (string, error) {
+	if p.peek() == 'a' && p.pos+1 < len(p.src) && (unicode.IsSpace(p.src[p.pos+1]) || p.src[p.pos+1] == '<') {
+		p.pos++
+		return rdfType, nil
+	}
+	return p.iri()
+}
+
+func (p *turtleParser) subject() (string, error) {
+	switch p.peek() {
+	case '[':
+		return p.blankNodePropertyList()
+	case '(':
+		return p.collection()
+	case '_':
+		return p.blankLabel()
+	}
+	return p.iri()
+}
+
+func (p *turtleParser) object() (Term, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '[':
+		id, err := p.blankNodePropertyList()
+		return Term{Value: id}, err
+	case c == '(':
+		id, err := p.collection()
+		return Term{Value: id}, err
+	case c == '_':
+		id, err := p.blankLabel()
+		return Term{Value: id}, err
+	case c == '"' || c == '\'':
+		return p.literal()
+	case c == '+' || c == '-' || c == '.' || unicode.IsDigit(c):
+		return p.number()
+	case p.word("true"):
+		return Term{Value: "true", Literal: true}, nil
+	case p.word("false"):
+		return Term{Value: "false", Literal: true}, nil
+	}
+	iri, err := p.iri()
+	return Term{Value: iri}, err
+}
+
+// word consumes a bare word such as a boolean
+func (p *turtleParser) word(w string) bool {
+	end := p.pos + len(w)
+	if end > len(p.src) || string(p.src[p.pos:end]) != w {
+		return false
+	}
+	if end < len(p.src) && isNameChar(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *turtleParser) blankNodePropertyList() (string, error) {
+	p.pos++ // [
+	id := p.newBlank()
+	p.skipSpace()
+	if p.peek() != ']' {
+		if err := p.predicateObjectList(id); err != nil {
+			return "", err
+		}
+	}
+	return id, p.expect(']')
+}
+
+func (p *turtleParser) collection() (string, error) {
+	p.pos++ // (
+	head := rdfNil
+	var prev string
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return "", p.errorf("unterminated collection")
+		}
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+		item, err := p.object()
+		if err != nil {
+			return "", err
+		}
+		node := p.newBlank()
+		if prev == "" {
+			head = node
+		} else {
+			p.triples = append(p.triples, Triple{Subject: prev, Predicate: rdfRest, Object: Term{Value: node}})
+		}
+		p.triples = append(p.triples, Triple{Subject: node, Predicate: rdfFirst, Object: item})
+		prev = node
+	}
+	if prev != "" {
+		p.triples = append(p.triples, Triple{Subject: prev, Predicate: rdfRest, Object: Term{Value: rdfNil}})
+	}
+	return head, nil
+}
+
+func (p *turtleParser) newBlank() string {
+	p.blanks++
+	return "_:b" + strconv.Itoa(p.blanks)
+}
+
+func (p *turtleParser) blankLabel() (string, error) {
+	if p.pos+1 >= len(p.src) || p.src[p.pos+1] != ':' {
+		return "", p.errorf("invalid blank node")
+	}
+	p.pos += 2
+	name := p.name()
+	if name == "" {
+		return "", p.errorf("empty blank node label")
+	}
+	// labels are scoped to the document
+	return "_:l" + name, nil
+}
+
+// iri reads an IRI reference or a prefixed name
+func (p *turtleParser) iri() (string, error) {
+	if p.peek() == '<' {
+		return p.iriRef()
+	}
+
+	start := p.pos
+	prefix := p.name()
+	if p.peek() != ':' {
+		p.pos = start
+		return "", p.errorf("expected IRI, found %q", p.snippet())
+	}
+	p.pos++
+	local := p.name()
+	ns, ok := p.prefixes[prefix]
+	if !ok {
+		return "", p.errorf("undefined prefix %q", prefix)
+	}
+	return ns + unescapeLocal(local), nil
+}
+
+func (p *turtleParser) iriRef() (string, error) {
+	if err := p.expect('<'); err != nil {
+		return "", err
+	}
+	start := p.pos
+	for !p.eof() && p.peek() != '>' {
+		if p.peek() == '\n' {
+			return "", p.errorf("unterminated IRI")
+		}
+		p.pos++
+	}
+	iri := string(p.src[start:p.pos])
+	if err := p.expect('>'); err != nil {
+		return "", err
+	}
+	return resolve(p.base, iri), nil
+}
+
+// name reads a prefix or local name; a trailing dot ends the statement
+func (p *turtleParser) name() string {
+	start := p.pos
+	for !p.eof() && (isNameChar(p.peek()) || p.peek() == '\\') {
+		if p.peek() == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	for p.pos > start && p.src[p.pos-1] == '.' {
+		p.pos--
+	}
+	return string(p.src[start:p.pos])
+}
+
+func isNameChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-' || c == '.' || c == '%'
+}
+
+func unescapeLocal(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func (p *turtleParser) literal() (Term, error) {
+	quote := p.peek()
+	long := p.pos+2 < len(p.src) && p.src[p.pos+1] == quote && p.src[p.pos+2] == quote
+	if long {
+		p.pos += 3
+	} else {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return Term{}, p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		if long && c == quote && p.pos+2 < len(p.src) && p.src[p.pos+1] == quote && p.src[p.pos+2] == quote {
+			p.pos += 3
+			break
+		}
+		if !long && c == quote {
+			p.pos++
+			break
+		}
+		if !long && c == '\n' {
+			return Term{}, p.errorf("newline in string")
+		}
+		if c == '\n' {
+			p.line++
+		}
+		if c == '\\' {
+			r, err := p.escape()
+			if err != nil {
+				return Term{}, err
+			}
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune(c)
+		p.pos++
+	}
+
+	t := Term{Value: b.String(), Literal: true}
+	switch {
+	case p.peek() == '@':
+		p.pos++
+		start := p.pos
+		for !p.eof() && (unicode.IsLetter(p.peek()) || unicode.IsDigit(p.peek()) || p.peek() == '-') {
+			p.pos++
+		}
+		t.Lang = strings.ToLower(string(p.src[start:p.pos]))
+	case p.peek() == '^' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '^':
+		p.pos += 2
+		dt, err := p.iri()
+		if err != nil {
+			return Term{}, err
+		}
+		t.Datatype = dt
+	}
+	return t, nil
+}
+
+func (p *turtleParser) escape() (rune, error) {
+	p.pos++ // backslash
+	if p.eof() {
+		return 0, p.errorf("unterminated escape")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		v, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+		if err != nil {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		p.pos += n
+		return rune(v), nil
+	}
+	return c, nil
+}
+
+func (p *turtleParser) number() (Term, error) {
+	start := p.pos
+	for !p.eof() && strings.ContainsRune("+-.0123456789eE", p.peek()) {
+		p.pos++
+	}
+	// a trailing dot ends the statement
+	for p.pos > start+1 && p.src[p.pos-1] == '.' {
+		p.pos--
+	}
+	if p.pos == start {
+		return Term{}, p.errorf("invalid number")
+	}
+	return Term{Value: string(p.src[start:p.pos]), Literal: true}, nil
+}
+
+func (p *turtleParser) skipSpace() {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case unicode.IsSpace(c):
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *turtleParser) expect(c rune) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return p.errorf("expected %q, found %q", c, p.snippet())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *turtleParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *turtleParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *turtleParser) snippet() string {
+	end := min(p.pos+20, len(p.src))
+	return string(p.src[p.pos:end])
+}
@@ -0,0 +1,153 @@
+package taxonomy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ex = "http://example.org/"
+
+func iri(s, p, o string) Triple {
+	return Triple{Subject: s, Predicate: p, Object: Term{Value: o}}
+}
+
+func lit(s, p string, o Term) Triple {
+	o.Literal = true
+	return Triple{Subject: s, Predicate: p, Object: o}
+}
+
+func TestParseTurtle(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Triple
+	}{
+		{
+			name: "prefixed names",
+			src:  "@prefix ex: <http://example.org/> .\nex:karma ex:binds ex:samsara .",
+			want: []Triple{iri(ex+"karma", ex+"binds", ex+"samsara")},
+		},
+		{
+			name: "rdf:type keyword",
+			src:  "@prefix skos: <http://www.w3.org/2004/02/skos/core#> .\n<http://example.org/karma> a skos:Concept .",
+			want: []Triple{iri(ex+"karma", rdfType, skosNS+"Concept")},
+		},
+		{
+			name: "predicate and object lists",
+			src: `@prefix ex: <http://example.org/> .
+ex:karma ex:related ex:dharma , ex:samsara ;
+         ex:broader ex:action ; .`,
+			want: []Triple{
+				iri(ex+"karma", ex+"related", ex+"dharma"),
+				iri(ex+"karma", ex+"related", ex+"samsara"),
+				iri(ex+"karma", ex+"broader", ex+"action"),
+			},
+		},
+		{
+			name: "name ending the statement",
+			src:  "@prefix ex: <http://example.org/> .\nex:a ex:b ex:c.",
+			want: []Triple{iri(ex+"a", ex+"b", ex+"c")},
+		},
+		{
+			name: "SPARQL directives and relative IRIs",
+			src:  "BASE <http://example.org/>\nprefix ex: <vocab#>\n<karma> ex:binds <samsara> .",
+			want: []Triple{iri(ex+"karma", ex+"vocab#binds", ex+"samsara")},
+		},
+		{
+			name: "language tags and datatypes",
+			src: `@prefix ex: <http://example.org/> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+ex:karma ex:label "Karma"@EN , 'कर्म'@sa ;
+         ex:since "1900"^^xsd:gYear ;
+         ex:id "k1"^^<http://example.org/code> .`,
+			want: []Triple{
+				lit(ex+"karma", ex+"label", Term{Value: "Karma", Lang: "en"}),
+				lit(ex+"karma", ex+"label", Term{Value: "कर्म", Lang: "sa"}),
+				lit(ex+"karma", ex+"since", Term{Value: "1900", Datatype: "http://www.w3.org/2001/XMLSchema#gYear"}),
+				lit(ex+"karma", ex+"id", Term{Value: "k1", Datatype: ex + "code"}),
+			},
+		},
+		{
+			name: "long strings and escapes",
+			src:  "@prefix ex: <http://example.org/> .\nex:karma ex:note \"\"\"line one\nline \"two\\\"\"\"\" ; ex:alt \"caf\\u00e9\\t\\\\\" .",
+			want: []Triple{
+				lit(ex+"karma", ex+"note", Term{Value: "line one\nline \"two\""}),
+				lit(ex+"karma", ex+"alt", Term{Value: "café\t\\"}),
+			},
+		},
+		{
+			name: "numbers and booleans",
+			src:  "@prefix ex: <http://example.org/> .\nex:karma ex:weight 0.5 ; ex:rank -2 ; ex:core true ; ex:count 42.",
+			want: []Triple{
+				lit(ex+"karma", ex+"weight", Term{Value: "0.5"}),
+				lit(ex+"karma", ex+"rank", Term{Value: "-2"}),
+				lit(ex+"karma", ex+"core", Term{Value: "true"}),
+				lit(ex+"karma", ex+"count", Term{Value: "42"}),
+			},
+		},
+		{
+			name: "blank nodes",
+			src: `@prefix ex: <http://example.org/> .
+ex:karma ex:restriction [ ex:on ex:action ] .
+_:x ex:label "anon" .`,
+			want: []Triple{
+				iri("_:b1", ex+"on", ex+"action"),
+				iri(ex+"karma", ex+"restriction", "_:b1"),
+				lit("_:lx", ex+"label", Term{Value: "anon"}),
+			},
+		},
+		{
+			name: "collections",
+			src:  "@prefix ex: <http://example.org/> .\nex:karma ex:union ( ex:a ex:b ) ; ex:none () .",
+			want: []Triple{
+				iri("_:b1", rdfFirst, ex+"a"),
+				iri("_:b1", rdfRest, "_:b2"),
+				iri("_:b2", rdfFirst, ex+"b"),
+				iri("_:b2", rdfRest, rdfNil),
+				iri(ex+"karma", ex+"union", "_:b1"),
+				iri(ex+"karma", ex+"none", rdfNil),
+			},
+		},
+		{
+			name: "comments",
+			src:  "# a thesaurus\n@prefix ex: <http://example.org/> . # names\nex:a ex:b ex:c . # done",
+			want: []Triple{iri(ex+"a", ex+"b", ex+"c")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTurtle(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("ParseTurtle: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTurtleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"undefined prefix", "ex:a ex:b ex:c .", `undefined prefix "ex"`},
+		{"missing dot", "@prefix ex: <http://example.org/> .\nex:a ex:b ex:c", `expected '.'`},
+		{"unterminated string", "@prefix ex: <http://example.org/> .\nex:a ex:b \"open .", "unterminated string"},
+		{"newline in string", "@prefix ex: <http://example.org/> .\nex:a ex:b \"one\ntwo\" .", "line 2: newline in string"},
+		{"unterminated IRI", "<http://example.org/a\n> <b> <c> .", "unterminated IRI"},
+		{"unterminated collection", "@prefix ex: <http://example.org/> .\nex:a ex:b ( ex:c", "unterminated collection"},
+		{"invalid unicode escape", "<a> <b> \"\\u12\" .", "invalid unicode escape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTurtle(strings.NewReader(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTurtle error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
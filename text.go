@@ -0,0 +1,137 @@
+package mantr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a normalized word and its byte offset in the source text
+type Token struct {
+	Text   string
+	Offset int
+}
+
+// foldTable maps accented and transliteration letters to their ASCII base
+var foldTable = map[rune]string{
+	'ā': "a", 'á': "a", 'à': "a", 'â': "a", 'ä': "a", 'ã': "a", 'å': "a",
+	'ē': "e", 'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'ī': "i", 'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ō': "o", 'ó': "o", 'ò': "o", 'ô': "o", 'ö': "o", 'õ': "o",
+	'ū': "u", 'ú': "u", 'ù': "u", 'û': "u", 'ü': "u",
+	'ṛ': "r", 'ṝ': "r", 'ḷ': "l", 'ḹ': "l",
+	'ṃ': "m", 'ṁ': "m", 'ḥ': "h",
+	'ṅ': "n", 'ñ': "n", 'ṇ': "n", 'ń': "n",
+	'ṭ': "t", 'ḍ': "d", 'ś': "s", 'ṣ': "s", 'ç': "c",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o",
+}
+
+// stopwords are common English words never treated as concepts
+var stopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "but": true, "by": true, "can": true, "could": true, "do": true,
+	"does": true, "each": true, "for": true, "from": true, "had": true, "has": true,
+	"have": true, "he": true, "her": true, "his": true, "how": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "may": true,
+	"more": true, "most": true, "no": true, "not": true, "of": true, "on": true,
+	"one": true, "only": true, "or": true, "other": true, "our": true, "out": true,
+	"over": true, "she": true, "should": true, "so": true, "some": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "those": true, "through": true,
+	"to": true, "under": true, "up": true, "use": true, "used": true, "using": true,
+	"very": true, "was": true, "we": true, "were": true, "what": true, "when": true,
+	"where": true, "which": true, "while": true, "who": true, "will": true, "with": true,
+	"would": true, "you": true, "your": true,
+}
+
+// Normalize folds a term to its canonical form: lower case, diacritics and
+// transliteration marks removed, punctuation collapsed to single spaces
+func Normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if f, ok := foldTable[r]; ok {
+			b.WriteString(f)
+			space = false
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space && b.Len() > 0 {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Tokenize splits text into normalized words with their byte offsets
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i := 0; i <= len(text); {
+		r, size := utf8.RuneError, 1
+		if i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+		}
+		word := i < len(text) && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r))
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			if t := Normalize(text[start:i]); t != "" {
+				tokens = append(tokens, Token{Text: t, Offset: start})
+			}
+			start = -1
+		}
+		i += size
+	}
+	return tokens
+}
+
+// Lemma reduces a normalized word to its singular form by stripping common
+// English plural suffixes. Words of three letters or fewer are unchanged.
+func Lemma(word string) string {
+	switch {
+	case utf8.RuneCountInString(word) <= 3 || strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// IsStopword reports whether a normalized word is too common to be a concept
+func IsStopword(word string) bool {
+	return stopwords[word]
+}
+
+// ExtractConcepts returns the tokens of text that are concept candidates:
+// words of at least three letters that are not stopwords or numbers
+func ExtractConcepts(text string) []Token {
+	var concepts []Token
+	for _, t := range Tokenize(text) {
+		if utf8.RuneCountInString(t.Text) < 3 || stopwords[t.Text] || isNumber(t.Text) {
+			continue
+		}
+		concepts = append(concepts, t)
+	}
+	return concepts
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrConflict indicates a pod changed after a transaction began
+	ErrConflict = errors.New("mantr: conflict")
+
+	// ErrTxDone indicates a transaction was already committed or rolled back
+	ErrTxDone = errors.New("mantr: transaction already committed or rolled back")
+)
+
+// ConflictError describes a failed commit. Nodes and Edges list what changed
+// since the transaction's base version when the server reports it.
+type ConflictError struct {
+	Pod      string
+	Expected int64
+	Version  int64
+	Nodes    []string
+	Edges    []EdgeKey
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("mantr: conflict: pod %s is at version %d, transaction expected %d", e.Pod, e.Version, e.Expected)
+}
+
+// Is makes errors.Is(err, ErrConflict) match a ConflictError
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// Tx collects graph changes to a pod and commits them atomically against the
+// version the pod had when the transaction began
+type Tx struct {
+	client  *Client
+	pod     string
+	base    int64
+	ops     IngestRequest
+	emulate bool
+	done    bool
+}
+
+// Begin starts a transaction on a pod at its current version
+func (c *Client) Begin(ctx context.Context, pod string) (*Tx, error) {
+	return c.BeginTx(ctx, pod, TxOptions{})
+}
+
+// BeginTx starts a transaction like Begin with options. Only Emulate applies
+// to a single transaction.
+func (c *Client) BeginTx(ctx context.Context, pod string, opts TxOptions) (*Tx, error) {
+	p, err := c.GetPod(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{client: c, pod: pod, base: p.Version, emulate: opts.Emulate}, nil
+}
+
+// BaseVersion is the pod version the transaction commits against
+func (tx *Tx) BaseVersion() int64 {
+	return tx.base
+}
+
+// UpsertNode adds or replaces a node
+func (tx *Tx) UpsertNode(n Node) {
+	tx.ops.Nodes = append(tx.ops.Nodes, n)
+}
+
+// UpsertEdge adds or replaces an edge
+func (tx *Tx) UpsertEdge(e Edge) {
+	tx.ops.Edges = append(tx.ops.Edges, e)
+}
+
+// DeleteNode removes a node
+func (tx *Tx) DeleteNode(id string) {
+	tx.ops.DeleteNodes = append(tx.ops.DeleteNodes, id)
+}
+
+// DeleteEdge removes an edge
+func (tx *Tx) DeleteEdge(k EdgeKey) {
+	tx.ops.DeleteEdges = append(tx.ops.DeleteEdges, k)
+}
+
+// Add queues every change of an ingestion request
+func (tx *Tx) Add(req *IngestRequest) {
+	tx.ops.Nodes = append(tx.ops.Nodes, req.Nodes...)
+	tx.ops.Edges = append(tx.ops.Edges, req.Edges...)
+	tx.ops.DeleteNodes = append(tx.ops.DeleteNodes, req.DeleteNodes...)
+	tx.ops.DeleteEdges = append(tx.ops.DeleteEdges, req.DeleteEdges...)
+}
+
+// Rollback discards the transaction's changes. It is safe to call after
+// Commit, so it can be deferred.
+func (tx *Tx) Rollback() {
+	tx.done = true
+	tx.ops = IngestRequest{}
+}
+
+// Commit applies all changes at once if the pod is still at the base
+// version; otherwise nothing is applied and a *ConflictError is returned. If
+// the server does not support transactions, Commit returns ErrNotSupported
+// and applies nothing, unless the transaction was begun with
+// TxOptions.Emulate.
+func (tx *Tx) Commit(ctx context.Context) (*IngestResponse, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+
+	resp, err := tx.commit(ctx)
+	if errors.Is(err, ErrNotSupported) && tx.emulate {
+		resp, err = tx.commitBatches(ctx)
+	}
+	// a conflicting transaction stays open so it can be rebased
+	if !errors.Is(err, ErrConflict) {
+		tx.done = true
+	}
+	return resp, err
+}
+
+// commitRequest is the body of a transaction commit
+type commitRequest struct {
+	ExpectedVersion int64 `json:"expected_version"`
+	IngestRequest
+}
+
+func (tx *Tx) commit(ctx context.Context) (*IngestResponse, error) {
+	c := tx.client
+	httpReq, err := c.newRequest(ctx, "POST", "/v1/pods/"+url.PathEscape(tx.pod)+"/commit",
+		&commitRequest{ExpectedVersion: tx.base, IngestRequest: tx.ops})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		var body struct {
+			Version int64     `json:"version"`
+			Nodes   []string  `json:"nodes"`
+			Edges   []EdgeKey `json:"edges"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return nil, &ConflictError{Pod: tx.pod, Expected: tx.base, Version: body.Version, Nodes: body.Nodes, Edges: body.Edges}
+	}
+
+	var out IngestResponse
+	if err := decodeResponse(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// commitBatches emulates a commit for servers without transactions. It is
+// not atomic: see TxOptions.Emulate.
+func (tx *Tx) commitBatches(ctx context.Context) (*IngestResponse, error) {
+	c := tx.client
+	live, err := c.GetPod(ctx, tx.pod)
+	if err != nil {
+		return nil, err
+	}
+	if live.Version != tx.base {
+		return nil, &ConflictError{Pod: tx.pod, Expected: tx.base, Version: live.Version}
+	}
+	before, err := c.ExportSnapshot(ctx, tx.pod)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.IngestBatches(ctx, tx.pod, &tx.ops, 0)
+	if err == nil {
+		return resp, nil
+	}
+	if _, rbErr := c.IngestBatches(ctx, tx.pod, undo(before, &tx.ops), 0); rbErr != nil {
+		return nil, fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+	}
+	return nil, fmt.Errorf("%w (rolled back)", err)
+}
+
+// undo returns the changes that restore the nodes and edges touched by req
+// to their state in before
+func undo(before *Snapshot, req *IngestRequest) *IngestRequest {
+	nodes := make(map[string]Node, len(before.Nodes))
+	for _, n := range before.Nodes {
+		nodes[n.ID] = n
+	}
+	edges := make(map[EdgeKey]Edge, len(before.Edges))
+	for _, e := range before.Edges {
+		edges[e.Key()] = e
+	}
+
+	inv := &IngestRequest{}
+	restoredNodes := make(map[string]bool)
+	restoreNode := func(id string) {
+		if restoredNodes[id] {
+			return
+		}
+		restoredNodes[id] = true
+		if n, ok := nodes[id]; ok {
+			inv.Nodes = append(inv.Nodes, n)
+		} else {
+			inv.DeleteNodes = append(inv.DeleteNodes, id)
+		}
+	}
+	restoredEdges := make(map[EdgeKey]bool)
+	restoreEdge := func(k EdgeKey) {
+		if restoredEdges[k] {
+			return
+		}
+		restoredEdges[k] = true
+		if e, ok := edges[k]; ok {
+			inv.Edges = append(inv.Edges, e)
+		} else {
+			inv.DeleteEdges = append(inv.DeleteEdges, k)
+		}
+	}
+
+	for _, n := range req.Nodes {
+		restoreNode(n.ID)
+	}
+	for _, id := range req.DeleteNodes {
+		restoreNode(id)
+		// deleting a node may have removed its edges
+		for _, e := range before.Edges {
+			if e.From == id || e.To == id {
+				restoreEdge(e.Key())
+			}
+		}
+	}
+	for _, e := range req.Edges {
+		restoreEdge(e.Key())
+	}
+	for _, k := range req.DeleteEdges {
+		restoreEdge(k)
+	}
+	return inv
+}
+
+// Rebase moves the transaction onto the version reported by a conflict. It
+// fails with the conflict when rebasing is not safe: when the server did not
+// say what changed, or when the changes touch nodes or edges the transaction
+// also changes.
+func (tx *Tx) Rebase(conflict *ConflictError) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if len(conflict.Nodes) == 0 && len(conflict.Edges) == 0 {
+		return conflict
+	}
+
+	nodes := make(map[string]bool)
+	deleted := make(map[string]bool)
+	for _, n := range tx.ops.Nodes {
+		nodes[n.ID] = true
+	}
+	for _, id := range tx.ops.DeleteNodes {
+		nodes[id], deleted[id] = true, true
+	}
+	edges := make(map[EdgeKey]bool)
+	for _, e := range tx.ops.Edges {
+		edges[e.Key()] = true
+		nodes[e.From], nodes[e.To] = true, true
+	}
+	for _, k := range tx.ops.DeleteEdges {
+		edges[k] = true
+	}
+
+	for _, id := range conflict.Nodes {
+		if nodes[id] {
+			return conflict
+		}
+	}
+	// deleting a node would also remove edges added to it concurrently
+	for _, k := range conflict.Edges {
+		if edges[k] || deleted[k.From] || deleted[k.To] {
+			return conflict
+		}
+	}
+	tx.base = conflict.Version
+	return nil
+}
+
+// TxOptions configures transactions
+type TxOptions struct {
+	// MaxAttempts bounds the number of commits RunTx tries (default 3)
+	MaxAttempts int
+	// Emulate commits on servers without transaction support by checking
+	// the pod version, then ingesting the changes in batches and, if a
+	// batch fails, restoring the nodes and edges it touched. Emulated
+	// commits are NOT atomic or isolated: a concurrent writer can change
+	// the pod between the version check and the batches, readers can see
+	// partly applied changes, and a failed restore leaves the pod partly
+	// changed.
+	Emulate bool
+}
+
+// RunTx runs fn in a transaction and commits it. On a conflict the
+// transaction is rebased and committed again when that is safe; otherwise fn
+// runs again in a fresh transaction. A transaction whose fn returns an error
+// is rolled back and the error returned.
+func (c *Client) RunTx(ctx context.Context, pod string, opts TxOptions, fn func(tx *Tx) error) (*IngestResponse, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+
+	var (
+		tx  *Tx
+		err error
+	)
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		if tx == nil {
+			if tx, err = c.BeginTx(ctx, pod, opts); err != nil {
+				return nil, err
+			}
+			if err = fn(tx); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		var resp *IngestResponse
+		resp, err = tx.Commit(ctx)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return resp, err
+		}
+		if tx.Rebase(conflict) != nil {
+			tx.Rollback()
+			tx = nil
+		}
+	}
+	return nil, err
+}
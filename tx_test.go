@@ -0,0 +1,221 @@
+package mantr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// txServer fakes the pod, snapshot, ingest and commit endpoints of one pod
+type txServer struct {
+	mu sync.Mutex
+	// version is reported by GET /v1/pods/dharma
+	version int64
+	// commits lists the status of each commit in turn; the last repeats
+	commits []int
+	// conflict is the body of 409 responses
+	conflict map[string]interface{}
+	// writes changes the version after each GET, simulating a writer
+	writes   bool
+	expected []int64
+	ingests  int
+}
+
+func (s *txServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v1/pods/dharma":
+		json.NewEncoder(w).Encode(Pod{Name: "dharma", Version: s.version})
+		if s.writes {
+			s.version++
+		}
+	case r.URL.Path == "/v1/pods/dharma/snapshot":
+		json.NewEncoder(w).Encode(testSnapshot())
+	case r.URL.Path == "/v1/pods/dharma/ingest":
+		s.ingests++
+		json.NewEncoder(w).Encode(IngestResponse{NodesUpserted: 1, Version: s.version + 1})
+	case strings.HasSuffix(r.URL.Path, "/commit"):
+		var body commitRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		s.expected = append(s.expected, body.ExpectedVersion)
+		status := s.commits[min(len(s.expected), len(s.commits))-1]
+		w.WriteHeader(status)
+		switch status {
+		case http.StatusOK:
+			json.NewEncoder(w).Encode(IngestResponse{NodesUpserted: 1, Version: body.ExpectedVersion + 1})
+		case http.StatusConflict:
+			json.NewEncoder(w).Encode(s.conflict)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestTxCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   *txServer
+		emulate  bool
+		wantErr  error
+		conflict *ConflictError
+		ingests  int
+		open     bool
+	}{
+		{
+			name:   "committed",
+			server: &txServer{version: 7, commits: []int{http.StatusOK}},
+		},
+		{
+			name: "conflict keeps the transaction open",
+			server: &txServer{version: 7, commits: []int{http.StatusConflict}, conflict: map[string]interface{}{
+				"version": 9, "nodes": []string{"karma"},
+			}},
+			wantErr:  ErrConflict,
+			conflict: &ConflictError{Pod: "dharma", Expected: 7, Version: 9, Nodes: []string{"karma"}},
+			open:     true,
+		},
+		{
+			name:    "unsupported without emulation",
+			server:  &txServer{version: 7, commits: []int{http.StatusNotImplemented}},
+			wantErr: ErrNotSupported,
+		},
+		{
+			name:    "unsupported with emulation",
+			server:  &txServer{version: 7, commits: []int{http.StatusNotImplemented}},
+			emulate: true,
+			ingests: 1,
+		},
+		{
+			name:     "emulated conflict",
+			server:   &txServer{version: 7, commits: []int{http.StatusNotImplemented}, writes: true},
+			emulate:  true,
+			wantErr:  ErrConflict,
+			conflict: &ConflictError{Pod: "dharma", Expected: 7, Version: 8},
+			open:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.server)
+			defer server.Close()
+			client, err := NewClient("vak_test", WithBaseURL(server.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := client.BeginTx(context.Background(), "dharma", TxOptions{Emulate: tt.emulate})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.UpsertNode(Node{ID: "ahimsa"})
+			_, err = tx.Commit(context.Background())
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit = %v, want %v", err, tt.wantErr)
+			}
+			var conflict *ConflictError
+			errors.As(err, &conflict)
+			if !reflect.DeepEqual(conflict, tt.conflict) {
+				t.Errorf("conflict = %+v, want %+v", conflict, tt.conflict)
+			}
+			if tt.server.ingests != tt.ingests {
+				t.Errorf("%d ingestion calls, want %d", tt.server.ingests, tt.ingests)
+			}
+			if _, err := tx.Commit(context.Background()); errors.Is(err, ErrTxDone) == tt.open {
+				t.Errorf("second Commit = %v, want the transaction open = %v", err, tt.open)
+			}
+		})
+	}
+}
+
+func TestTxRebase(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflict ConflictError
+		ok       bool
+	}{
+		{"unknown changes", ConflictError{Version: 9}, false},
+		{"disjoint changes", ConflictError{Version: 9, Nodes: []string{"samsara"}, Edges: []EdgeKey{{From: "karma", To: "samsara", Type: "binds"}}}, true},
+		{"same node", ConflictError{Version: 9, Nodes: []string{"ahimsa"}}, false},
+		{"endpoint of an upserted edge", ConflictError{Version: 9, Nodes: []string{"dharma"}}, false},
+		{"same edge", ConflictError{Version: 9, Edges: []EdgeKey{{From: "ahimsa", To: "dharma", Type: "supports"}}}, false},
+		{"edge of a deleted node", ConflictError{Version: 9, Edges: []EdgeKey{{From: "moksa", To: "karma", Type: "frees"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &Tx{pod: "dharma", base: 7}
+			tx.UpsertNode(Node{ID: "ahimsa"})
+			tx.UpsertEdge(Edge{From: "ahimsa", To: "dharma", Type: "supports", Weight: 1})
+			tx.DeleteNode("moksa")
+
+			conflict := tt.conflict
+			err := tx.Rebase(&conflict)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Rebase = %v, want ok = %v", err, tt.ok)
+			}
+			want := int64(7)
+			if tt.ok {
+				want = 9
+			}
+			if tx.BaseVersion() != want {
+				t.Errorf("base version %d, want %d", tx.BaseVersion(), want)
+			}
+		})
+	}
+}
+
+func TestRunTxRebases(t *testing.T) {
+	s := &txServer{
+		version:  7,
+		commits:  []int{http.StatusConflict, http.StatusOK},
+		conflict: map[string]interface{}{"version": 9, "nodes": []string{"samsara"}},
+	}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	client, err := NewClient("vak_test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	resp, err := client.RunTx(context.Background(), "dharma", TxOptions{}, func(tx *Tx) error {
+		calls++
+		tx.UpsertNode(Node{ID: "ahimsa"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || !reflect.DeepEqual(s.expected, []int64{7, 9}) || resp.Version != 10 {
+		t.Errorf("fn ran %d times, commits expected versions %v, final version %d", calls, s.expected, resp.Version)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	before := testSnapshot()
+	req := &IngestRequest{
+		Nodes:       []Node{{ID: "karma", Label: "Karma"}, {ID: "ahimsa"}},
+		Edges:       []Edge{{From: "ahimsa", To: "dharma", Type: "supports"}},
+		DeleteNodes: []string{"moksa"},
+	}
+	want := &IngestRequest{
+		Nodes:       []Node{{ID: "karma"}, {ID: "moksa"}},
+		Edges:       []Edge{{From: "dharma", To: "moksa", Type: "leads", Weight: 0.5}},
+		DeleteNodes: []string{"ahimsa"},
+		DeleteEdges: []EdgeKey{{From: "ahimsa", To: "dharma", Type: "supports"}},
+	}
+	if got := undo(before, req); !reflect.DeepEqual(got, want) {
+		t.Errorf("undo = %+v, want %+v", got, want)
+	}
+}